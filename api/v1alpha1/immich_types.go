@@ -18,19 +18,27 @@ package v1alpha1
 
 import (
 	"os"
+	"strings"
+	"time"
 
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Environment variable names for disconnected/air-gapped environments
 // These follow the RELATED_IMAGE_* pattern used by OpenShift OLM
 const (
-	EnvRelatedImageImmich              = "RELATED_IMAGE_immich"
-	EnvRelatedImageMachineLearning     = "RELATED_IMAGE_machineLearning"
-	EnvRelatedImageValkey              = "RELATED_IMAGE_valkey"
-	EnvRelatedImagePostgres            = "RELATED_IMAGE_postgres"
+	EnvRelatedImageImmich          = "RELATED_IMAGE_immich"
+	EnvRelatedImageMachineLearning = "RELATED_IMAGE_machineLearning"
+	EnvRelatedImageValkey          = "RELATED_IMAGE_valkey"
+	EnvRelatedImagePostgres        = "RELATED_IMAGE_postgres"
+	EnvRelatedImageBackupS3        = "RELATED_IMAGE_backupS3"
+
+	// Deprecated: the init containers run the PostgreSQL and Valkey images; this variable is ignored.
 	EnvRelatedImageImmichInitContainer = "RELATED_IMAGE_immich_initContainer"
 )
 
@@ -59,6 +67,35 @@ type ImmichSpec struct {
 	// PostgreSQL database configuration
 	// +optional
 	Postgres *PostgresSpec `json:"postgres,omitempty"`
+
+	// UpgradePolicy configures how changes of the Immich server image are rolled out
+	// +optional
+	UpgradePolicy *UpgradePolicySpec `json:"upgradePolicy,omitempty"`
+}
+
+// UpgradePolicySpec defines how Immich version upgrades are orchestrated.
+// When enabled, a change of the server image takes a snapshot of the database, scales the server down,
+// rolls out machine learning, then starts the new server and waits for it to report the new version.
+type UpgradePolicySpec struct {
+	// Orchestrate changes of the server image. When disabled, image changes are rolling updates.
+	// +kubebuilder:default=false
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Snapshot the database with pg_dump before the new version runs its migrations
+	// +kubebuilder:default=true
+	// +optional
+	Snapshot *bool `json:"snapshot,omitempty"`
+
+	// Persistence of the PVC holding the pre-upgrade snapshots
+	// +optional
+	Persistence *BackupPersistenceSpec `json:"persistence,omitempty"`
+
+	// Timeout for the new server to become ready and report the new version,
+	// after which the Immich resource is marked Degraded
+	// +kubebuilder:default="15m"
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
 }
 
 // ImmichConfig defines shared Immich configuration.
@@ -77,8 +114,20 @@ type ImmichConfig struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	Configuration *ConfigurationSpec `json:"configuration,omitempty"`
 
+	// RawConfiguration is Immich configuration passed through as is, for the settings Configuration does not model
+	// (e.g., image, metadata, nightlyTasks or templates). It is merged under Configuration
+	// and the settings derived by the operator, which take precedence.
+	// +optional
+	RawConfiguration *RawConfigurationSpec `json:"rawConfiguration,omitempty"`
+
+	// BackupCopy copies the newest database dump written by Immich (see configuration.backup.database)
+	// from the library volume to a separate PVC, so that backups survive the loss of the library volume
+	// +optional
+	BackupCopy *BackupCopySpec `json:"backupCopy,omitempty"`
+
 	// ConfigurationKind sets the resource Kind to store configuration in.
 	// Must be either ConfigMap or Secret. Defaults to ConfigMap.
+	// A configuration referencing Secrets is always stored in a Secret.
 	// +kubebuilder:validation:Enum=ConfigMap;Secret
 	// +optional
 	ConfigurationKind *string `json:"configurationKind,omitempty"`
@@ -87,6 +136,10 @@ type ImmichConfig struct {
 // ConfigurationSpec holds the raw Immich configuration
 // +kubebuilder:pruning:PreserveUnknownFields
 type ConfigurationSpec struct {
+	// Backup configuration
+	// +optional
+	Backup *BackupConfig `json:"backup,omitempty"`
+
 	// Trash configuration
 	// +optional
 	Trash *TrashConfig `json:"trash,omitempty"`
@@ -152,6 +205,80 @@ type ConfigurationSpec struct {
 	User *UserConfig `json:"user,omitempty"`
 }
 
+// RawConfigurationSpec holds Immich configuration passed through to the config file.
+// Exactly one of inline, configMapRef and secretRef must be set.
+type RawConfigurationSpec struct {
+	// Inline configuration, following the structure of the Immich config file
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Inline *apiextensionsv1.JSON `json:"inline,omitempty"`
+
+	// ConfigMapRef references a ConfigMap key holding the configuration as JSON or YAML
+	// +optional
+	ConfigMapRef *ConfigMapKeySelector `json:"configMapRef,omitempty"`
+
+	// SecretRef references a Secret key holding the configuration as JSON or YAML.
+	// The generated configuration is then stored in a Secret.
+	// +optional
+	SecretRef *SecretKeySelector `json:"secretRef,omitempty"`
+}
+
+// BackupConfig defines the backups performed by Immich
+type BackupConfig struct {
+	// Database configures the database dumps Immich writes to the backups folder of the library volume
+	// +optional
+	Database *DatabaseBackupConfig `json:"database,omitempty"`
+}
+
+// DatabaseBackupConfig defines Immich's built-in database backup job
+type DatabaseBackupConfig struct {
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// Schedule in Cron format
+	// +optional
+	CronExpression *string `json:"cronExpression,omitempty"`
+	// Number of dumps kept by Immich
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	KeepLastAmount *int `json:"keepLastAmount,omitempty"`
+}
+
+// BackupCopySpec defines the CronJob copying Immich database dumps out of the library volume.
+type BackupCopySpec struct {
+	// Enable copying the dumps
+	// +kubebuilder:default=false
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Schedule in Cron format. Should run after the Immich database backup job.
+	// +kubebuilder:default="0 4 * * *"
+	// +optional
+	Schedule *string `json:"schedule,omitempty"`
+
+	// Suspend the copy CronJob without removing it
+	// +optional
+	Suspend *bool `json:"suspend,omitempty"`
+
+	// Number of dumps to keep on the copy PVC. Older copies are deleted after each successful copy.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=7
+	// +optional
+	Retention *int32 `json:"retention,omitempty"`
+
+	// Image used to copy the dumps. Defaults to the server image.
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// Resource requirements for the copy container
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Persistence of the PVC holding the copies
+	// +optional
+	Persistence *BackupPersistenceSpec `json:"persistence,omitempty"`
+}
+
 // TrashConfig defines trash bin settings
 type TrashConfig struct {
 	// +kubebuilder:default=true
@@ -192,6 +319,7 @@ type FFmpegConfig struct {
 	Refs *int `json:"refs,omitempty"`
 	// +optional
 	GOPSize *int `json:"gopSize,omitempty"`
+	// Deprecated: no longer an Immich setting, ignored.
 	// +optional
 	NPL *int `json:"npl,omitempty"`
 	// +optional
@@ -355,7 +483,7 @@ type SMTPTransportConfig struct {
 	// Username for SMTP authentication
 	// +optional
 	Username *string `json:"username,omitempty"`
-	// Reference to a secret containing the SMTP password
+	// Reference to a secret containing the SMTP password, injected as transport.password
 	// +optional
 	PasswordSecretRef *SecretKeySelector `json:"passwordSecretRef,omitempty"`
 	// +optional
@@ -370,7 +498,7 @@ type OAuthConfig struct {
 	IssuerURL *string `json:"issuerUrl,omitempty"`
 	// +optional
 	ClientID *string `json:"clientId,omitempty"`
-	// Reference to a secret containing the OAuth client secret
+	// Reference to a secret containing the OAuth client secret, injected as clientSecret
 	// +optional
 	ClientSecretRef *SecretKeySelector `json:"clientSecretRef,omitempty"`
 	// +optional
@@ -431,6 +559,79 @@ type MetricsSpec struct {
 	// +kubebuilder:default=false
 	// +optional
 	Enabled *bool `json:"enabled,omitempty"`
+
+	// ServiceMonitor configuration.
+	// A ServiceMonitor is created when metrics are enabled and the Prometheus Operator CRDs are installed.
+	// +optional
+	ServiceMonitor *ServiceMonitorSpec `json:"serviceMonitor,omitempty"`
+}
+
+// ServiceMonitorSpec defines the Prometheus Operator ServiceMonitor configuration.
+type ServiceMonitorSpec struct {
+	// Enable ServiceMonitor creation. If not set, auto-detects based on the availability
+	// of the monitoring.coreos.com/v1 API. Set to false to explicitly disable it.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Interval at which metrics should be scraped (e.g., "30s")
+	// If not set, the Prometheus global scrape interval is used.
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	Interval *string `json:"interval,omitempty"`
+
+	// ScrapeTimeout is the timeout after which the scrape is ended (e.g., "10s")
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	ScrapeTimeout *string `json:"scrapeTimeout,omitempty"`
+
+	// Labels to add to the ServiceMonitor, e.g. to match the serviceMonitorSelector of a Prometheus instance
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations to add to the ServiceMonitor
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Relabelings to apply to the target's metadata labels before scraping
+	// +optional
+	Relabelings []RelabelConfig `json:"relabelings,omitempty"`
+
+	// MetricRelabelings to apply to samples before ingestion
+	// +optional
+	MetricRelabelings []RelabelConfig `json:"metricRelabelings,omitempty"`
+}
+
+// RelabelConfig allows dynamic rewriting of the label set.
+// It mirrors the Prometheus Operator RelabelConfig type.
+type RelabelConfig struct {
+	// SourceLabels select values from existing labels
+	// +optional
+	SourceLabels []string `json:"sourceLabels,omitempty"`
+
+	// Separator placed between concatenated source label values
+	// +optional
+	Separator *string `json:"separator,omitempty"`
+
+	// TargetLabel to which the resulting value is written in a replace action
+	// +optional
+	TargetLabel *string `json:"targetLabel,omitempty"`
+
+	// Regex against which the extracted value is matched
+	// +optional
+	Regex *string `json:"regex,omitempty"`
+
+	// Modulus to take of the hash of the source label values
+	// +optional
+	Modulus *int64 `json:"modulus,omitempty"`
+
+	// Replacement value against which a regex replace is performed if the regex matches
+	// +optional
+	Replacement *string `json:"replacement,omitempty"`
+
+	// Action to perform based on the regex matching
+	// +kubebuilder:validation:Enum=replace;Replace;keep;Keep;drop;Drop;hashmod;HashMod;labelmap;LabelMap;labeldrop;LabelDrop;labelkeep;LabelKeep;lowercase;Lowercase;uppercase;Uppercase;keepequal;KeepEqual;dropequal;DropEqual
+	// +optional
+	Action *string `json:"action,omitempty"`
 }
 
 // PersistenceSpec defines persistence configuration.
@@ -483,10 +684,16 @@ type ServerSpec struct {
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
 
 	// Number of replicas
+	// Ignored when autoscaling is enabled
 	// +kubebuilder:default=1
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
 
+	// Autoscaling configuration
+	// When enabled, the operator creates a HorizontalPodAutoscaler and no longer sets the Deployment replicas
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
 	// Resource requirements
 	// +optional
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
@@ -511,6 +718,15 @@ type ServerSpec struct {
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
 
+	// Topology spread constraints
+	// The label selector of a constraint defaults to the pods of this component
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PodDisruptionBudget configuration
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
 	// Ingress configuration (for standard Kubernetes)
 	// +optional
 	Ingress *IngressSpec `json:"ingress,omitempty"`
@@ -520,6 +736,15 @@ type ServerSpec struct {
 	// +optional
 	Route *RouteSpec `json:"route,omitempty"`
 
+	// Workers configures how the api and microservices workers of the server are deployed
+	// +optional
+	Workers *ServerWorkersSpec `json:"workers,omitempty"`
+
+	// HardwareTranscoding overrides the device access the operator gives to the server pods running the
+	// microservices worker, derived from the ffmpeg.accel setting of the Immich configuration
+	// +optional
+	HardwareTranscoding *HardwareTranscodingSpec `json:"hardwareTranscoding,omitempty"`
+
 	// Pod annotations
 	// +optional
 	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
@@ -537,6 +762,137 @@ type ServerSpec struct {
 	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
 }
 
+// Hardware transcoding accelerations, as set in ffmpeg.accel
+const (
+	FFmpegAccelNVENC = "nvenc"
+	FFmpegAccelQSV   = "qsv"
+	FFmpegAccelVAAPI = "vaapi"
+	FFmpegAccelRKMPP = "rkmpp"
+)
+
+// Device access modes for hardware transcoding
+const (
+	HardwareTranscodingDeviceAccessDevicePlugin = "DevicePlugin"
+	HardwareTranscodingDeviceAccessHostPath     = "HostPath"
+)
+
+// HardwareTranscodingSpec overrides how the server pods get access to the transcoding hardware.
+// By default, nvenc requests nvidia.com/gpu with the nvidia runtime class, qsv requests gpu.intel.com/i915,
+// vaapi mounts /dev/dri from the node and rkmpp mounts the Rockchip devices from the node, opened through
+// the supplemental groups of the pods.
+type HardwareTranscodingSpec struct {
+	// Enabled controls whether the pod changes are derived from ffmpeg.accel.
+	// Set to false to provide the device access through the other server settings instead.
+	// +kubebuilder:default=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// DeviceAccess selects how /dev/dri is provided with qsv and vaapi: by a device plugin, through the
+	// resources it advertises, or by mounting the devices of the node.
+	// nvenc always uses the NVIDIA device plugin, and rkmpp always mounts the devices of the node.
+	// Defaults to DevicePlugin with qsv and HostPath with vaapi.
+	// +kubebuilder:validation:Enum=DevicePlugin;HostPath
+	// +optional
+	DeviceAccess *string `json:"deviceAccess,omitempty"`
+
+	// Resources are the device plugin resources to request, e.g. {"amd.com/gpu": 1} for vaapi on AMD GPUs.
+	// Defaults to one nvidia.com/gpu with nvenc and one gpu.intel.com/i915 with qsv.
+	// +optional
+	Resources corev1.ResourceList `json:"resources,omitempty"`
+
+	// SupplementalGroups are added to the pods to open the devices, e.g. the GID of the render group of the nodes.
+	// Defaults to the video group (44) when devices are mounted.
+	// +optional
+	SupplementalGroups []int64 `json:"supplementalGroups,omitempty"`
+
+	// RuntimeClassName of the pods with nvenc, defaulting to "nvidia" as created by the NVIDIA GPU Operator.
+	// Set to an empty string when the NVIDIA runtime is the default runtime of the nodes.
+	// +optional
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+
+	// Privileged runs the server containers privileged when devices of the node are mounted.
+	// Container runtimes usually deny unprivileged containers the devices of the node they did not allocate,
+	// whatever the supplemental groups: a privileged container can open them, but also every other device
+	// of the node and escape its isolation. Prefer a device plugin advertising the devices when there is one.
+	// Ignored when no device of the node is mounted.
+	// +optional
+	Privileged *bool `json:"privileged,omitempty"`
+}
+
+// Machine learning acceleration profiles
+const (
+	MachineLearningAccelerationCPU      = "cpu"
+	MachineLearningAccelerationCUDA     = "cuda"
+	MachineLearningAccelerationOpenVINO = "openvino"
+	MachineLearningAccelerationROCm     = "rocm"
+	MachineLearningAccelerationARMNN    = "armnn"
+	MachineLearningAccelerationRKNN     = "rknn"
+)
+
+// Server worker modes
+const (
+	ServerWorkersModeCombined = "combined"
+	ServerWorkersModeSplit    = "split"
+)
+
+// ServerWorkersSpec defines how the Immich server workers are deployed.
+// In split mode, the api worker runs in a <name>-server-api Deployment behind the Service, Ingress and Route,
+// and the microservices worker, running background jobs such as thumbnails and transcoding,
+// in a <name>-server-microservices Deployment. Both share the library volume and the generated configuration.
+type ServerWorkersSpec struct {
+	// Mode of the server: combined runs both workers in the <name>-server Deployment,
+	// split runs them in separate Deployments
+	// +kubebuilder:validation:Enum=combined;split
+	// +kubebuilder:default=combined
+	// +optional
+	Mode *string `json:"mode,omitempty"`
+
+	// API worker Deployment, in split mode
+	// +optional
+	API *ServerWorkerSpec `json:"api,omitempty"`
+
+	// Microservices worker Deployment, in split mode
+	// +optional
+	Microservices *ServerWorkerSpec `json:"microservices,omitempty"`
+}
+
+// ServerWorkerSpec defines the Deployment of a server worker in split mode.
+// Unset fields default to the settings of spec.server.
+type ServerWorkerSpec struct {
+	// Number of replicas
+	// Ignored when autoscaling is enabled
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling configuration
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// Resource requirements
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Node selector
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Liveness probe of the server container
+	// Defaults to /api/server/ping for the api worker, and to none for the microservices worker, which serves no HTTP
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// Readiness probe of the server container
+	// Defaults to /api/server/ping for the api worker, and to none for the microservices worker
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// Startup probe of the server container
+	// Defaults to /api/server/ping for the api worker, and to none for the microservices worker
+	// +optional
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+}
+
 // MachineLearningSpec defines the machine learning component configuration.
 // When enabled=true (default), the operator deploys an ML Deployment.
 // When enabled=false, ML is disabled unless an external URL is provided.
@@ -550,6 +906,7 @@ type MachineLearningSpec struct {
 
 	// Image is the full image reference (e.g., "ghcr.io/immich-app/immich-machine-learning:v1.125.7")
 	// If not set, defaults to RELATED_IMAGE_machineLearning environment variable
+	// With an acceleration other than cpu, the tag is suffixed with the acceleration (e.g., "v1.125.7-cuda")
 	// +optional
 	Image *string `json:"image,omitempty"`
 
@@ -557,11 +914,27 @@ type MachineLearningSpec struct {
 	// +optional
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
 
+	// Acceleration is the hardware acceleration profile of the machine learning component.
+	// It selects the matching image variant, and adds the GPU resource requests, device mounts and
+	// environment variables the profile needs:
+	// cuda requests nvidia.com/gpu, openvino gpu.intel.com/i915 and rocm amd.com/gpu from their device plugins,
+	// while armnn and rknn mount the devices of the node, opened through the video group, on arm64 nodes.
+	// +kubebuilder:validation:Enum=cpu;cuda;openvino;rocm;armnn;rknn
+	// +kubebuilder:default=cpu
+	// +optional
+	Acceleration *string `json:"acceleration,omitempty"`
+
 	// Number of replicas
+	// Ignored when autoscaling is enabled
 	// +kubebuilder:default=1
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
 
+	// Autoscaling configuration
+	// When enabled, the operator creates a HorizontalPodAutoscaler and no longer sets the Deployment replicas
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
 	// Resource requirements
 	// +optional
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
@@ -586,6 +959,15 @@ type MachineLearningSpec struct {
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
 
+	// Topology spread constraints
+	// The label selector of a constraint defaults to the pods of this component
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PodDisruptionBudget configuration
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
 	// Persistence configuration for ML cache
 	// +optional
 	Persistence *MachineLearningPersistenceSpec `json:"persistence,omitempty"`
@@ -606,6 +988,13 @@ type MachineLearningSpec struct {
 	// +optional
 	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
 
+	// Privileged runs the machine learning containers privileged when the acceleration mounts devices of the
+	// node, with armnn and rknn. Container runtimes usually deny unprivileged containers the devices of the node
+	// they did not allocate: a privileged container can open them, but also every other device of the node
+	// and escape its isolation. Ignored with the other accelerations.
+	// +optional
+	Privileged *bool `json:"privileged,omitempty"`
+
 	// --- External ML service configuration (used when enabled=false) ---
 
 	// URL of the external ML service (optional, used when enabled=false)
@@ -613,6 +1002,78 @@ type MachineLearningSpec struct {
 	// Example: "http://external-ml-service:3003"
 	// +optional
 	URL *string `json:"url,omitempty"`
+
+	// Backends of the machine learning component, in priority order: Immich sends requests to the first
+	// backend and fails over to the next ones when it is unreachable.
+	// A backend with a url is a remote service. The other backends are built-in: each runs in its own
+	// <name>-machine-learning-<backend> Deployment and Service, with the settings of spec.machineLearning
+	// unless the backend overrides them, and only while enabled=true.
+	// When not set, the <name>-machine-learning Deployment, or the external url when enabled=false, is the only backend.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	Backends []MachineLearningBackendSpec `json:"backends,omitempty"`
+}
+
+// MachineLearningBackendSpec defines a machine learning backend.
+// Unset fields of a built-in backend default to the settings of spec.machineLearning.
+type MachineLearningBackendSpec struct {
+	// Name of the backend, unique within the backends
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=20
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// URL of a remote machine learning service (e.g., "http://desktop-gpu.lan:3003")
+	// When set, the operator deploys nothing for the backend and the other fields are ignored
+	// +optional
+	URL *string `json:"url,omitempty"`
+
+	// Image is the full image reference
+	// With an acceleration other than cpu, the tag is suffixed with the acceleration (e.g., "v1.125.7-cuda")
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// Acceleration is the hardware acceleration profile of the backend
+	// +kubebuilder:validation:Enum=cpu;cuda;openvino;rocm;armnn;rknn
+	// +optional
+	Acceleration *string `json:"acceleration,omitempty"`
+
+	// Number of replicas
+	// Ignored when autoscaling is enabled
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling configuration
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// Resource requirements
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Additional environment variables, appended to the ones of spec.machineLearning
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Node selector
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity rules
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+}
+
+// IsRemote returns true if the backend is a remote service, not deployed by the operator
+func (b *MachineLearningBackendSpec) IsRemote() bool {
+	return b.URL != nil && *b.URL != ""
 }
 
 // MachineLearningPersistenceSpec defines ML cache persistence.
@@ -635,11 +1096,67 @@ type MachineLearningPersistenceSpec struct {
 	// +optional
 	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
 
-	// Use an existing PVC instead of creating one
+	// Use an existing PVC instead of creating one.
+	// It is shared by the built-in backends: with several of them, it must be ReadWriteMany, declared in accessModes.
 	// +optional
 	ExistingClaim *string `json:"existingClaim,omitempty"`
 }
 
+// AutoscalingSpec defines the HorizontalPodAutoscaler of a component.
+// Without any target or metric, the component scales on a CPU utilization of 80%.
+type AutoscalingSpec struct {
+	// Enable autoscaling
+	// +kubebuilder:default=false
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Minimum number of replicas
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// Maximum number of replicas, required when autoscaling is enabled
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+
+	// Target average CPU utilization, as a percentage of the requested CPU
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	// Target average memory utilization, as a percentage of the requested memory
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+
+	// Additional metrics, e.g. Pods or External metrics served by a custom metrics adapter
+	// +optional
+	Metrics []autoscalingv2.MetricSpec `json:"metrics,omitempty"`
+
+	// Scaling behavior in the up and down directions
+	// +optional
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+// PodDisruptionBudgetSpec defines the PodDisruptionBudget of a component.
+// At most one of minAvailable and maxUnavailable can be set; without either, one pod may be unavailable at a time.
+type PodDisruptionBudgetSpec struct {
+	// Create a PodDisruptionBudget
+	// Defaults to true when the component runs more than one replica
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Minimum number or percentage of pods that must remain available during voluntary disruptions
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// Maximum number or percentage of pods that can be unavailable during voluntary disruptions
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
 // ValkeySpec defines the Valkey (Redis) component configuration.
 // When enabled=true (default), the operator deploys a Valkey StatefulSet.
 // When enabled=false, you must provide external Redis connection details.
@@ -667,6 +1184,21 @@ type ValkeySpec struct {
 	// +optional
 	Persistence *ValkeyPersistenceSpec `json:"persistence,omitempty"`
 
+	// Auth configures password authentication of the built-in Valkey
+	// +optional
+	Auth *ValkeyAuthSpec `json:"auth,omitempty"`
+
+	// Mode of the built-in Valkey: standalone runs a single-replica Deployment,
+	// sentinel runs a StatefulSet of replicas monitored by Sentinel for automatic failover
+	// +kubebuilder:validation:Enum=standalone;sentinel
+	// +kubebuilder:default=standalone
+	// +optional
+	Mode *string `json:"mode,omitempty"`
+
+	// Sentinel configures the sentinel mode
+	// +optional
+	Sentinel *ValkeySentinelSpec `json:"sentinel,omitempty"`
+
 	// Node selector
 	// +optional
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
@@ -679,6 +1211,15 @@ type ValkeySpec struct {
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
 
+	// Topology spread constraints
+	// The label selector of a constraint defaults to the pods of this component
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PodDisruptionBudget configuration
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
 	// Pod annotations
 	// +optional
 	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
@@ -711,9 +1252,25 @@ type ValkeySpec struct {
 	// +optional
 	DbIndex *int32 `json:"dbIndex,omitempty"`
 
-	// Reference to a secret containing the Redis password
+	// Reference to a secret containing the Redis password.
+	// With the built-in Valkey, defaults to a Secret generated by the operator.
 	// +optional
 	PasswordSecretRef *SecretKeySelector `json:"passwordSecretRef,omitempty"`
+
+	// TLS configures encryption of the connection to the external Redis/Valkey server.
+	// Only the disable, require and verify-full SSL modes are supported.
+	// +optional
+	TLS *TLSSpec `json:"tls,omitempty"`
+}
+
+// ValkeyAuthSpec defines password authentication of the built-in Valkey.
+type ValkeyAuthSpec struct {
+	// Require a password to connect to the built-in Valkey. The password is read from passwordSecretRef,
+	// or from a <name>-valkey-credentials Secret generated by the operator.
+	// Editing the password in the Secret restarts Valkey and the server with the new password.
+	// +kubebuilder:default=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
 }
 
 // PostgresPersistenceSpec defines PostgreSQL persistence.
@@ -736,12 +1293,51 @@ type PostgresPersistenceSpec struct {
 	ExistingClaim *string `json:"existingClaim,omitempty"`
 }
 
-// ValkeyPersistenceSpec defines Valkey persistence.
-type ValkeyPersistenceSpec struct {
-	// Enable persistence for Valkey data
-	// +kubebuilder:default=false
+// Valkey modes
+const (
+	ValkeyModeStandalone = "standalone"
+	ValkeyModeSentinel   = "sentinel"
+)
+
+// ValkeySentinelSpec defines the highly available Valkey deployment.
+// The first Valkey pod starts as the primary and the others as its replicas; the Sentinel pods
+// elect a new primary when it becomes unreachable, and the server discovers it through Sentinel.
+type ValkeySentinelSpec struct {
+	// Number of Valkey pods, including the primary
+	// +kubebuilder:validation:Minimum=2
+	// +kubebuilder:default=3
 	// +optional
-	Enabled *bool `json:"enabled,omitempty"`
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Number of Sentinel pods
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3
+	// +optional
+	SentinelReplicas *int32 `json:"sentinelReplicas,omitempty"`
+
+	// Number of Sentinels that need to agree the primary is unreachable to start a failover.
+	// Valkey is reported ready only while at least this many Sentinels are ready.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=2
+	// +optional
+	Quorum *int32 `json:"quorum,omitempty"`
+
+	// Name of the primary monitored by Sentinel
+	// +kubebuilder:default="immich"
+	// +optional
+	MasterName *string `json:"masterName,omitempty"`
+
+	// Resource requirements for the Sentinel containers
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ValkeyPersistenceSpec defines Valkey persistence.
+type ValkeyPersistenceSpec struct {
+	// Enable persistence for Valkey data
+	// +kubebuilder:default=false
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
 
 	// Size of the data PVC
 	// +kubebuilder:default="10Gi"
@@ -761,8 +1357,35 @@ type ValkeyPersistenceSpec struct {
 	ExistingClaim *string `json:"existingClaim,omitempty"`
 }
 
+// PostgreSQL providers
+const (
+	PostgresProviderBuiltin       = "builtin"
+	PostgresProviderCloudNativePG = "cloudnative-pg"
+)
+
+// CloudNativePGSpec defines the CloudNativePG Cluster created for provider=cloudnative-pg.
+// The Cluster uses spec.postgres.image, which must be a CloudNativePG-compatible image
+// shipping a vector extension, e.g. ghcr.io/tensorchord/cloudnative-vectorchord.
+type CloudNativePGSpec struct {
+	// Number of PostgreSQL instances: one primary and its streaming replicas
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	Instances *int32 `json:"instances,omitempty"`
+
+	// Libraries loaded by PostgreSQL at startup, as required by the vector extension
+	// Defaults to vchord.so (VectorChord)
+	// +optional
+	SharedPreloadLibraries []string `json:"sharedPreloadLibraries,omitempty"`
+
+	// Extensions created in the Immich database at bootstrap, as the Immich user is not a superuser
+	// Defaults to vchord and earthdistance
+	// +optional
+	Extensions []string `json:"extensions,omitempty"`
+}
+
 // PostgresSpec defines PostgreSQL database configuration.
-// When enabled=true (default), the operator deploys a PostgreSQL StatefulSet.
+// When enabled=true (default), the operator deploys a PostgreSQL StatefulSet, or a CloudNativePG Cluster.
 // When enabled=false, you must provide external database connection details.
 type PostgresSpec struct {
 	// Enable the built-in PostgreSQL deployment
@@ -771,6 +1394,18 @@ type PostgresSpec struct {
 	// +optional
 	Enabled *bool `json:"enabled,omitempty"`
 
+	// Provider deploying PostgreSQL when enabled=true:
+	// builtin runs a single PostgreSQL StatefulSet,
+	// cloudnative-pg creates a CloudNativePG Cluster (requires the CloudNativePG operator)
+	// +kubebuilder:validation:Enum=builtin;cloudnative-pg
+	// +kubebuilder:default="builtin"
+	// +optional
+	Provider *string `json:"provider,omitempty"`
+
+	// CloudNativePG configures the Cluster created with provider=cloudnative-pg
+	// +optional
+	CloudNativePG *CloudNativePGSpec `json:"cloudNativePG,omitempty"`
+
 	// Image is the full image reference for the PostgreSQL container
 	// Must include the pgvecto.rs extension for Immich to work
 	// If not set, defaults to RELATED_IMAGE_postgres environment variable
@@ -801,6 +1436,15 @@ type PostgresSpec struct {
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
 
+	// Topology spread constraints
+	// The label selector of a constraint defaults to the pods of this component
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PodDisruptionBudget configuration
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+
 	// Pod annotations
 	// +optional
 	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
@@ -817,6 +1461,14 @@ type PostgresSpec struct {
 	// +optional
 	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
 
+	// Backup configuration for the built-in PostgreSQL
+	// +optional
+	Backup *PostgresBackupSpec `json:"backup,omitempty"`
+
+	// Upgrade configuration for major version changes of the built-in PostgreSQL image
+	// +optional
+	Upgrade *PostgresUpgradeSpec `json:"upgrade,omitempty"`
+
 	// --- External PostgreSQL configuration (used when enabled=false) ---
 
 	// Hostname of the external PostgreSQL server (required when enabled=false)
@@ -847,6 +1499,144 @@ type PostgresSpec struct {
 	// If set, overrides host/port/database/username/password
 	// +optional
 	URLSecretRef *SecretKeySelector `json:"urlSecretRef,omitempty"`
+
+	// TLS configures encryption of the connection to the external PostgreSQL server
+	// +optional
+	TLS *TLSSpec `json:"tls,omitempty"`
+}
+
+// PostgresBackupSpec defines scheduled backups of the built-in PostgreSQL.
+// When enabled, the operator creates a CronJob dumping the database to a PVC or an S3-compatible endpoint.
+type PostgresBackupSpec struct {
+	// Enable scheduled backups
+	// +kubebuilder:default=false
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Schedule in Cron format
+	// +kubebuilder:default="0 2 * * *"
+	// +optional
+	Schedule *string `json:"schedule,omitempty"`
+
+	// Suspend the backup CronJob without removing it
+	// +optional
+	Suspend *bool `json:"suspend,omitempty"`
+
+	// Method used to dump the database: pg_dump dumps the Immich database only,
+	// pg_dumpall dumps the whole cluster including roles.
+	// +kubebuilder:validation:Enum=pg_dump;pg_dumpall
+	// +kubebuilder:default="pg_dump"
+	// +optional
+	Method *string `json:"method,omitempty"`
+
+	// Compression applied to the dump files
+	// +kubebuilder:validation:Enum=gzip;none
+	// +kubebuilder:default="gzip"
+	// +optional
+	Compression *string `json:"compression,omitempty"`
+
+	// Number of dump files to keep. Older dumps are deleted after each successful backup.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=7
+	// +optional
+	Retention *int32 `json:"retention,omitempty"`
+
+	// Image used to run the dump. Defaults to the PostgreSQL image, so that the
+	// client tools match the server version.
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// Resource requirements for the backup containers
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Persistence stores the dumps in a PVC.
+	// Used when s3 is not set.
+	// +optional
+	Persistence *BackupPersistenceSpec `json:"persistence,omitempty"`
+
+	// S3 uploads the dumps to an S3-compatible endpoint instead of a PVC
+	// +optional
+	S3 *BackupS3Spec `json:"s3,omitempty"`
+}
+
+// PostgresUpgradeSpec defines how major version upgrades of the built-in PostgreSQL are handled.
+// When spec.postgres.image moves to a new major version, the operator dumps the database with the
+// previous version, moves the previous data directory aside, and restores the dump with the new version.
+type PostgresUpgradeSpec struct {
+	// Automatically upgrade the database when the image changes major version.
+	// When disabled, PostgreSQL keeps running the previous image and the Upgrading condition
+	// reports the required upgrade.
+	// +kubebuilder:default=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Size of the PVC holding the dump and the copy of the previous data directory.
+	// Defaults to twice the size of the PostgreSQL data PVC.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// StorageClass for the upgrade PVC
+	// +optional
+	StorageClass *string `json:"storageClass,omitempty"`
+}
+
+// BackupPersistenceSpec defines the PVC holding PostgreSQL dumps.
+type BackupPersistenceSpec struct {
+	// Size of the backup PVC
+	// +kubebuilder:default="10Gi"
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// StorageClass for the backup PVC
+	// +optional
+	StorageClass *string `json:"storageClass,omitempty"`
+
+	// Access modes for the backup PVC
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+
+	// Use an existing PVC instead of creating one
+	// +optional
+	ExistingClaim *string `json:"existingClaim,omitempty"`
+}
+
+// BackupS3Spec defines an S3-compatible destination for PostgreSQL dumps.
+type BackupS3Spec struct {
+	// Bucket to upload the dumps to
+	Bucket string `json:"bucket"`
+
+	// Prefix (folder) within the bucket
+	// +optional
+	Prefix *string `json:"prefix,omitempty"`
+
+	// Endpoint URL of the S3-compatible service (e.g., "https://minio.example.com").
+	// If not set, AWS S3 is used.
+	// +optional
+	Endpoint *string `json:"endpoint,omitempty"`
+
+	// Region of the bucket
+	// +optional
+	Region *string `json:"region,omitempty"`
+
+	// Reference to a secret containing the access key ID
+	AccessKeyIDSecretRef SecretKeySelector `json:"accessKeyIdSecretRef"`
+
+	// Reference to a secret containing the secret access key
+	SecretAccessKeySecretRef SecretKeySelector `json:"secretAccessKeySecretRef"`
+
+	// Image is the full image reference of the AWS CLI used to upload the dumps
+	// If not set, defaults to RELATED_IMAGE_backupS3 environment variable
+	// +optional
+	Image *string `json:"image,omitempty"`
+}
+
+// ConfigMapKeySelector selects a key from a ConfigMap.
+type ConfigMapKeySelector struct {
+	// Name of the ConfigMap
+	Name string `json:"name"`
+	// Key in the ConfigMap
+	Key string `json:"key"`
 }
 
 // SecretKeySelector selects a key from a Secret.
@@ -857,6 +1647,40 @@ type SecretKeySelector struct {
 	Key string `json:"key"`
 }
 
+// TLSSpec configures TLS for the connection to an external service.
+type TLSSpec struct {
+	// SSLMode follows the PostgreSQL sslmode semantics: disable turns TLS off, require encrypts the
+	// connection without verifying the server certificate, verify-ca verifies the certificate against the CA,
+	// and verify-full also checks that it matches the host.
+	// +kubebuilder:validation:Enum=disable;allow;prefer;require;verify-ca;verify-full
+	// +kubebuilder:default=verify-full
+	// +optional
+	SSLMode *string `json:"sslMode,omitempty"`
+
+	// Reference to a secret containing the CA certificate (PEM) verifying the server certificate.
+	// Required for PostgreSQL with verify-ca and verify-full, defaults to the system trust store for Redis/Valkey.
+	// +optional
+	CASecretRef *SecretKeySelector `json:"caSecretRef,omitempty"`
+
+	// Reference to a secret containing the client certificate (PEM), for servers requiring client authentication
+	// +optional
+	ClientCertSecretRef *SecretKeySelector `json:"clientCertSecretRef,omitempty"`
+
+	// Reference to a secret containing the private key (PEM) of the client certificate
+	// +optional
+	ClientKeySecretRef *SecretKeySelector `json:"clientKeySecretRef,omitempty"`
+}
+
+// SSL modes of the connections to external services
+const (
+	SSLModeDisable    = "disable"
+	SSLModeAllow      = "allow"
+	SSLModePrefer     = "prefer"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+)
+
 // IngressSpec defines ingress configuration.
 type IngressSpec struct {
 	// Enable ingress
@@ -1018,10 +1842,236 @@ type ImmichStatus struct {
 	// URL is the URL to access Immich (from Route or Ingress)
 	// +optional
 	URL string `json:"url,omitempty"`
+
+	// ConfigHash is the hash of the effective Immich configuration applied to the server pods
+	// +optional
+	ConfigHash string `json:"configHash,omitempty"`
+
+	// LastSuccessfulBackupTime is the last time the PostgreSQL backup CronJob completed successfully
+	// +optional
+	LastSuccessfulBackupTime *metav1.Time `json:"lastSuccessfulBackupTime,omitempty"`
+
+	// DatabaseBackup reports the newest Immich database dump copied by the backup copy CronJob
+	// +optional
+	DatabaseBackup *DatabaseBackupStatus `json:"databaseBackup,omitempty"`
+
+	// PostgresImage is the image the built-in PostgreSQL data directory is known to run with
+	// +optional
+	PostgresImage string `json:"postgresImage,omitempty"`
+
+	// PostgresUpgrade tracks the PostgreSQL image change in progress, if any
+	// +optional
+	PostgresUpgrade *PostgresUpgradeStatus `json:"postgresUpgrade,omitempty"`
+
+	// ServerImage is the server image Immich is known to run, used to detect version changes
+	// +optional
+	ServerImage string `json:"serverImage,omitempty"`
+
+	// Upgrade tracks the Immich version upgrade in progress, if any
+	// +optional
+	Upgrade *ImmichUpgradeStatus `json:"upgrade,omitempty"`
+
+	// Version is the Immich version reported by the server through /api/server/version
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Components reports the state of each deployed component
+	// +optional
+	Components *ComponentsStatus `json:"components,omitempty"`
+
+	// ConfigurationSources reports which source each key of the generated Immich configuration comes from
+	// +optional
+	ConfigurationSources []ConfigurationSourceStatus `json:"configurationSources,omitempty"`
+}
+
+// Sources of the generated Immich configuration, in increasing order of precedence
+const (
+	ConfigurationSourceRaw           = "RawConfiguration"
+	ConfigurationSourceOperator      = "Operator"
+	ConfigurationSourceConfiguration = "Configuration"
+	ConfigurationSourceSecrets       = "Secrets"
+)
+
+// ConfigurationSourceStatus lists the keys of the generated Immich configuration set by a source.
+type ConfigurationSourceStatus struct {
+	// Source of the keys: RawConfiguration, Operator, Configuration or Secrets
+	Source string `json:"source"`
+
+	// Keys set by the source, as dotted paths (e.g., ffmpeg.crf).
+	// Keys overridden by a source of higher precedence are listed under that source only.
+	// +optional
+	Keys []string `json:"keys,omitempty"`
+}
+
+// DatabaseBackupStatus reports the Immich database dumps seen by the backup copy CronJob.
+type DatabaseBackupStatus struct {
+	// LatestFile is the name of the newest dump found in the backups folder of the library volume
+	// +optional
+	LatestFile string `json:"latestFile,omitempty"`
+
+	// LastCopyTime is the last time the newest dump was copied successfully
+	// +optional
+	LastCopyTime *metav1.Time `json:"lastCopyTime,omitempty"`
+}
+
+// ComponentsStatus reports the state of the components deployed by the operator.
+// Components that are disabled or external are omitted.
+type ComponentsStatus struct {
+	// Server component status
+	// +optional
+	Server *ComponentStatus `json:"server,omitempty"`
+
+	// ServerMicroservices is the status of the microservices worker, when the server workers are split.
+	// Server then reports the api worker.
+	// +optional
+	ServerMicroservices *ComponentStatus `json:"serverMicroservices,omitempty"`
+
+	// MachineLearning component status
+	// +optional
+	MachineLearning *ComponentStatus `json:"machineLearning,omitempty"`
+
+	// MachineLearningBackends is the status of each machine learning backend, in priority order,
+	// when spec.machineLearning.backends is set
+	// +optional
+	MachineLearningBackends []MachineLearningBackendStatus `json:"machineLearningBackends,omitempty"`
+
+	// Valkey component status
+	// +optional
+	Valkey *ComponentStatus `json:"valkey,omitempty"`
+
+	// ValkeySentinel component status, in sentinel mode.
+	// Ready while at least a quorum of Sentinels is ready.
+	// +optional
+	ValkeySentinel *ComponentStatus `json:"valkeySentinel,omitempty"`
+
+	// Postgres component status
+	// +optional
+	Postgres *ComponentStatus `json:"postgres,omitempty"`
+}
+
+// ComponentStatus reports the state of a component workload.
+type ComponentStatus struct {
+	// Image deployed for the component
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Ready indicates if all desired replicas are ready
+	Ready bool `json:"ready"`
+
+	// Replicas is the desired number of replicas
+	Replicas int32 `json:"replicas"`
+
+	// ReadyReplicas is the number of ready replicas
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// LastTransitionTime is the last time the component became ready or not ready
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// MachineLearningBackendStatus reports the state of a machine learning backend.
+// A built-in backend reports its Deployment, while a remote backend is ready when it answers /ping.
+type MachineLearningBackendStatus struct {
+	// Name of the backend
+	Name string `json:"name"`
+
+	// URL of the backend in the Immich configuration
+	URL string `json:"url"`
+
+	// Remote is true for a backend not deployed by the operator
+	// +optional
+	Remote bool `json:"remote,omitempty"`
+
+	ComponentStatus `json:",inline"`
+
+	// Message explains why the backend is not ready
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Immich upgrade phases
+const (
+	UpgradePhaseSnapshotting             = "Snapshotting"
+	UpgradePhaseScalingDown              = "ScalingDown"
+	UpgradePhaseUpgradingMachineLearning = "UpgradingMachineLearning"
+	UpgradePhaseStartingServer           = "StartingServer"
+	UpgradePhaseFailed                   = "Failed"
+)
+
+// ImmichUpgradeStatus tracks an orchestrated change of the Immich server image.
+type ImmichUpgradeStatus struct {
+	// Phase of the upgrade: Snapshotting, ScalingDown, UpgradingMachineLearning, StartingServer or Failed
+	Phase string `json:"phase"`
+
+	// FromImage is the server image before the upgrade
+	FromImage string `json:"fromImage"`
+
+	// ToImage is the server image being rolled out
+	ToImage string `json:"toImage"`
+
+	// FromVersion is the version of FromImage, from its tag
+	// +optional
+	FromVersion string `json:"fromVersion,omitempty"`
+
+	// ToVersion is the version of ToImage, from its tag
+	// +optional
+	ToVersion string `json:"toVersion,omitempty"`
+
+	// FromMachineLearningImage is the machine learning image before the upgrade
+	// +optional
+	FromMachineLearningImage string `json:"fromMachineLearningImage,omitempty"`
+
+	// SnapshotName is the file of the pre-upgrade database snapshot in the snapshot PVC
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// StartTime is the time the upgrade started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// LastTransitionTime is the time the upgrade entered its current phase
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// PostgreSQL upgrade phases
+const (
+	PostgresUpgradePhaseVersionCheck     = "VersionCheck"
+	PostgresUpgradePhaseDumping          = "Dumping"
+	PostgresUpgradePhaseStoppingDatabase = "StoppingDatabase"
+	PostgresUpgradePhaseMovingData       = "MovingData"
+	PostgresUpgradePhaseStarting         = "Starting"
+	PostgresUpgradePhaseRestoring        = "Restoring"
+	PostgresUpgradePhaseRollingBack      = "RollingBack"
+)
+
+// PostgresUpgradeStatus tracks a change of the built-in PostgreSQL image.
+type PostgresUpgradeStatus struct {
+	// Phase of the upgrade: VersionCheck, Dumping, StoppingDatabase, MovingData, Starting, Restoring or RollingBack
+	Phase string `json:"phase"`
+
+	// FromImage is the image PostgreSQL ran before the change
+	FromImage string `json:"fromImage"`
+
+	// ToImage is the image PostgreSQL is being moved to
+	ToImage string `json:"toImage"`
+
+	// FromVersion is the major version of the data directory
+	// +optional
+	FromVersion string `json:"fromVersion,omitempty"`
+
+	// ToVersion is the major version of the target image
+	// +optional
+	ToVersion string `json:"toVersion,omitempty"`
+
+	// BackupPath is the directory of the upgrade PVC holding the dump and the previous data directory
+	// +optional
+	BackupPath string `json:"backupPath,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version",description="Immich version reported by the server"
 // +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready",description="Whether all components are ready"
 // +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".status.url",description="URL to access Immich"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
@@ -1066,6 +2116,27 @@ func (i *Immich) IsMachineLearningEnabled() bool {
 	return *i.Spec.MachineLearning.Enabled
 }
 
+// IsEnabled returns true if an autoscaling configuration is set and enabled
+func (a *AutoscalingSpec) IsEnabled() bool {
+	return a != nil && a.Enabled != nil && *a.Enabled
+}
+
+// IsServerWorkersSplit returns true if the api and microservices workers of the server run in separate Deployments
+func (i *Immich) IsServerWorkersSplit() bool {
+	return i.IsServerEnabled() && i.Spec.Server != nil && i.Spec.Server.Workers != nil &&
+		i.Spec.Server.Workers.Mode != nil && *i.Spec.Server.Workers.Mode == ServerWorkersModeSplit
+}
+
+// IsServerAutoscalingEnabled returns true if the server replicas are managed by a HorizontalPodAutoscaler
+func (i *Immich) IsServerAutoscalingEnabled() bool {
+	return i.IsServerEnabled() && i.Spec.Server != nil && i.Spec.Server.Autoscaling.IsEnabled()
+}
+
+// IsMachineLearningAutoscalingEnabled returns true if the ML replicas are managed by a HorizontalPodAutoscaler
+func (i *Immich) IsMachineLearningAutoscalingEnabled() bool {
+	return i.IsMachineLearningEnabled() && i.Spec.MachineLearning != nil && i.Spec.MachineLearning.Autoscaling.IsEnabled()
+}
+
 // IsValkeyEnabled returns true if the Valkey component is enabled
 func (i *Immich) IsValkeyEnabled() bool {
 	if i.Spec.Valkey == nil || i.Spec.Valkey.Enabled == nil {
@@ -1074,6 +2145,84 @@ func (i *Immich) IsValkeyEnabled() bool {
 	return *i.Spec.Valkey.Enabled
 }
 
+// IsValkeyAuthEnabled returns true if the built-in Valkey requires a password
+func (i *Immich) IsValkeyAuthEnabled() bool {
+	if !i.IsValkeyEnabled() {
+		return false
+	}
+	if i.Spec.Valkey == nil || i.Spec.Valkey.Auth == nil || i.Spec.Valkey.Auth.Enabled == nil {
+		return true // default to enabled
+	}
+	return *i.Spec.Valkey.Auth.Enabled
+}
+
+// IsValkeySentinelEnabled returns true if the built-in Valkey runs in sentinel mode
+func (i *Immich) IsValkeySentinelEnabled() bool {
+	return i.IsValkeyEnabled() && i.Spec.Valkey != nil && i.Spec.Valkey.Mode != nil && *i.Spec.Valkey.Mode == ValkeyModeSentinel
+}
+
+// GetValkeyReplicas returns the number of Valkey pods: 1 in standalone mode, 3 by default in sentinel mode
+func (i *Immich) GetValkeyReplicas() int32 {
+	if !i.IsValkeySentinelEnabled() {
+		return 1
+	}
+	if i.Spec.Valkey.Sentinel != nil && i.Spec.Valkey.Sentinel.Replicas != nil {
+		return *i.Spec.Valkey.Sentinel.Replicas
+	}
+	return 3
+}
+
+// GetValkeySentinelReplicas returns the number of Sentinel pods in sentinel mode
+func (i *Immich) GetValkeySentinelReplicas() int32 {
+	if i.Spec.Valkey != nil && i.Spec.Valkey.Sentinel != nil && i.Spec.Valkey.Sentinel.SentinelReplicas != nil {
+		return *i.Spec.Valkey.Sentinel.SentinelReplicas
+	}
+	return 3
+}
+
+// GetValkeySentinelQuorum returns the number of Sentinels that need to agree to start a failover
+func (i *Immich) GetValkeySentinelQuorum() int32 {
+	if i.Spec.Valkey != nil && i.Spec.Valkey.Sentinel != nil && i.Spec.Valkey.Sentinel.Quorum != nil {
+		return *i.Spec.Valkey.Sentinel.Quorum
+	}
+	return 2
+}
+
+// GetValkeySentinelMasterName returns the name of the primary monitored by Sentinel
+func (i *Immich) GetValkeySentinelMasterName() string {
+	if i.Spec.Valkey != nil && i.Spec.Valkey.Sentinel != nil && i.Spec.Valkey.Sentinel.MasterName != nil && *i.Spec.Valkey.Sentinel.MasterName != "" {
+		return *i.Spec.Valkey.Sentinel.MasterName
+	}
+	return "immich"
+}
+
+// GetSSLMode returns the SSL mode of a TLS configuration, defaulting to verify-full
+func (t *TLSSpec) GetSSLMode() string {
+	if t == nil {
+		return SSLModeDisable
+	}
+	if t.SSLMode != nil && *t.SSLMode != "" {
+		return *t.SSLMode
+	}
+	return SSLModeVerifyFull
+}
+
+// GetPostgresTLS returns the TLS configuration of the external PostgreSQL connection, or nil if it is not encrypted
+func (i *Immich) GetPostgresTLS() *TLSSpec {
+	if i.IsPostgresEnabled() || i.Spec.Postgres == nil || i.Spec.Postgres.TLS.GetSSLMode() == SSLModeDisable {
+		return nil
+	}
+	return i.Spec.Postgres.TLS
+}
+
+// GetValkeyTLS returns the TLS configuration of the external Redis/Valkey connection, or nil if it is not encrypted
+func (i *Immich) GetValkeyTLS() *TLSSpec {
+	if i.IsValkeyEnabled() || i.Spec.Valkey == nil || i.Spec.Valkey.TLS.GetSSLMode() == SSLModeDisable {
+		return nil
+	}
+	return i.Spec.Valkey.TLS
+}
+
 // GetServerImage returns the full server image reference
 // Priority order:
 // 1. spec.server.image (user-specified in CR takes precedence)
@@ -1094,14 +2243,88 @@ func (i *Immich) GetServerImage() string {
 // 1. spec.machineLearning.image (user-specified in CR takes precedence)
 // 2. RELATED_IMAGE_machineLearning environment variable (for disconnected environments)
 // Returns empty string if neither is set (caller should handle as error)
+// With an acceleration other than cpu, the variant of the image for the acceleration is returned,
+// see GetMachineLearningImageVariant.
 func (i *Immich) GetMachineLearningImage() string {
+	image, _ := i.GetMachineLearningImageVariant()
+	return image
+}
+
+// GetMachineLearningImageVariant returns the ML image for the acceleration, and false if the variant cannot be
+// derived from the configured image, in which case the configured image is returned.
+// The variant of "immich-machine-learning:v1.125.7" for cuda is "immich-machine-learning:v1.125.7-cuda".
+// An image whose tag already ends with the suffix is used as is, so an image pinned by digest must carry the
+// variant tag as well (e.g., "immich-machine-learning:v1.125.7-cuda@sha256:...").
+func (i *Immich) GetMachineLearningImageVariant() (string, bool) {
+	image := os.Getenv(EnvRelatedImageMachineLearning)
 	// User-specified image takes precedence
 	if i.Spec.MachineLearning != nil && i.Spec.MachineLearning.Image != nil && *i.Spec.MachineLearning.Image != "" {
-		return *i.Spec.MachineLearning.Image
+		image = *i.Spec.MachineLearning.Image
 	}
+	return getMachineLearningImageVariant(image, i.GetMachineLearningAcceleration())
+}
 
-	// Fall back to environment variable (disconnected/air-gapped support)
-	return os.Getenv(EnvRelatedImageMachineLearning)
+// GetMachineLearningBackendImageVariant returns the image of a built-in ML backend for its acceleration, see
+// GetMachineLearningImageVariant. The image of the backend defaults to the image of spec.machineLearning.
+func (i *Immich) GetMachineLearningBackendImageVariant(backend *MachineLearningBackendSpec) (string, bool) {
+	image := os.Getenv(EnvRelatedImageMachineLearning)
+	if i.Spec.MachineLearning != nil && i.Spec.MachineLearning.Image != nil && *i.Spec.MachineLearning.Image != "" {
+		image = *i.Spec.MachineLearning.Image
+	}
+	if backend.Image != nil && *backend.Image != "" {
+		image = *backend.Image
+	}
+	return getMachineLearningImageVariant(image, i.GetMachineLearningBackendAcceleration(backend))
+}
+
+// getMachineLearningImageVariant returns the variant of an ML image for the acceleration
+func getMachineLearningImageVariant(image, acceleration string) (string, bool) {
+	if image == "" || acceleration == MachineLearningAccelerationCPU {
+		return image, true
+	}
+
+	suffix := "-" + acceleration
+	name, _, pinned := strings.Cut(image, "@")
+	tagIndex := strings.LastIndex(name, ":")
+	if tagIndex <= strings.LastIndex(name, "/") {
+		// No tag to derive the variant from
+		return image, false
+	}
+	if strings.HasSuffix(name, suffix) {
+		return image, true
+	}
+	if pinned {
+		// The digest identifies the image without the variant
+		return image, false
+	}
+	return name + suffix, true
+}
+
+// GetFFmpegAccel returns the hardware transcoding acceleration set in the typed configuration,
+// or an empty string when transcoding is not accelerated or configured elsewhere
+func (i *Immich) GetFFmpegAccel() string {
+	if i.Spec.Immich == nil || i.Spec.Immich.Configuration == nil || i.Spec.Immich.Configuration.FFmpeg == nil ||
+		i.Spec.Immich.Configuration.FFmpeg.Accel == nil {
+		return ""
+	}
+	return *i.Spec.Immich.Configuration.FFmpeg.Accel
+}
+
+// GetMachineLearningAcceleration returns the hardware acceleration profile of the ML component (default: cpu)
+func (i *Immich) GetMachineLearningAcceleration() string {
+	if i.Spec.MachineLearning == nil || i.Spec.MachineLearning.Acceleration == nil || *i.Spec.MachineLearning.Acceleration == "" {
+		return MachineLearningAccelerationCPU
+	}
+	return *i.Spec.MachineLearning.Acceleration
+}
+
+// GetMachineLearningBackendAcceleration returns the hardware acceleration profile of a built-in ML backend,
+// defaulting to the acceleration of spec.machineLearning
+func (i *Immich) GetMachineLearningBackendAcceleration(backend *MachineLearningBackendSpec) string {
+	if backend.Acceleration == nil || *backend.Acceleration == "" {
+		return i.GetMachineLearningAcceleration()
+	}
+	return *backend.Acceleration
 }
 
 // GetValkeyImage returns the full Valkey image reference
@@ -1177,6 +2400,48 @@ func (i *Immich) IsPostgresEnabled() bool {
 	return *i.Spec.Postgres.Enabled
 }
 
+// GetPostgresProvider returns the provider deploying PostgreSQL, defaulting to builtin
+func (i *Immich) GetPostgresProvider() string {
+	if i.Spec.Postgres != nil && i.Spec.Postgres.Provider != nil && *i.Spec.Postgres.Provider != "" {
+		return *i.Spec.Postgres.Provider
+	}
+	return PostgresProviderBuiltin
+}
+
+// IsCloudNativePGEnabled returns true if PostgreSQL is deployed as a CloudNativePG Cluster
+func (i *Immich) IsCloudNativePGEnabled() bool {
+	return i.IsPostgresEnabled() && i.GetPostgresProvider() == PostgresProviderCloudNativePG
+}
+
+// IsPostgresStatefulSetEnabled returns true if PostgreSQL is deployed as the operator StatefulSet
+func (i *Immich) IsPostgresStatefulSetEnabled() bool {
+	return i.IsPostgresEnabled() && !i.IsCloudNativePGEnabled()
+}
+
+// GetCloudNativePGInstances returns the number of instances of the CloudNativePG Cluster
+func (i *Immich) GetCloudNativePGInstances() int32 {
+	if i.Spec.Postgres != nil && i.Spec.Postgres.CloudNativePG != nil && i.Spec.Postgres.CloudNativePG.Instances != nil {
+		return *i.Spec.Postgres.CloudNativePG.Instances
+	}
+	return 1
+}
+
+// GetCloudNativePGSharedPreloadLibraries returns the libraries loaded by the CloudNativePG instances
+func (i *Immich) GetCloudNativePGSharedPreloadLibraries() []string {
+	if i.Spec.Postgres != nil && i.Spec.Postgres.CloudNativePG != nil && len(i.Spec.Postgres.CloudNativePG.SharedPreloadLibraries) > 0 {
+		return i.Spec.Postgres.CloudNativePG.SharedPreloadLibraries
+	}
+	return []string{"vchord.so"}
+}
+
+// GetCloudNativePGExtensions returns the extensions created in the database of the CloudNativePG Cluster
+func (i *Immich) GetCloudNativePGExtensions() []string {
+	if i.Spec.Postgres != nil && i.Spec.Postgres.CloudNativePG != nil && len(i.Spec.Postgres.CloudNativePG.Extensions) > 0 {
+		return i.Spec.Postgres.CloudNativePG.Extensions
+	}
+	return []string{"vchord", "earthdistance"}
+}
+
 // GetPostgresImage returns the full PostgreSQL image reference
 // Priority order:
 // 1. spec.postgres.image (user-specified in CR takes precedence)
@@ -1191,10 +2456,159 @@ func (i *Immich) GetPostgresImage() string {
 
 // GetImmichInitContainerImage returns the image to use for Immich init containers.
 // Falls back to RELATED_IMAGE_immich_initContainer environment variable.
+//
+// Deprecated: the init containers run the PostgreSQL and Valkey images, see GetPostgresImage and GetValkeyImage.
 func GetImmichInitContainerImage() string {
 	return os.Getenv(EnvRelatedImageImmichInitContainer)
 }
 
+// IsPostgresBackupEnabled returns true if scheduled backups of the built-in PostgreSQL are enabled
+func (i *Immich) IsPostgresBackupEnabled() bool {
+	if !i.IsPostgresStatefulSetEnabled() || i.Spec.Postgres == nil || i.Spec.Postgres.Backup == nil || i.Spec.Postgres.Backup.Enabled == nil {
+		return false // default to disabled
+	}
+	return *i.Spec.Postgres.Backup.Enabled
+}
+
+// IsPostgresBackupToS3 returns true if PostgreSQL dumps are uploaded to an S3-compatible endpoint
+func (i *Immich) IsPostgresBackupToS3() bool {
+	return i.IsPostgresBackupEnabled() && i.Spec.Postgres.Backup.S3 != nil
+}
+
+// GetPostgresBackupImage returns the image used to dump the database.
+// Defaults to the PostgreSQL image so that pg_dump matches the server version.
+func (i *Immich) GetPostgresBackupImage() string {
+	if i.Spec.Postgres != nil && i.Spec.Postgres.Backup != nil && i.Spec.Postgres.Backup.Image != nil && *i.Spec.Postgres.Backup.Image != "" {
+		return *i.Spec.Postgres.Backup.Image
+	}
+	return i.GetPostgresImage()
+}
+
+// GetPostgresBackupS3Image returns the image used to upload dumps to S3
+// Priority order:
+// 1. spec.postgres.backup.s3.image (user-specified in CR takes precedence)
+// 2. RELATED_IMAGE_backupS3 environment variable (for disconnected environments)
+// Returns empty string if neither is set (caller should handle as error)
+func (i *Immich) GetPostgresBackupS3Image() string {
+	if i.Spec.Postgres != nil && i.Spec.Postgres.Backup != nil && i.Spec.Postgres.Backup.S3 != nil &&
+		i.Spec.Postgres.Backup.S3.Image != nil && *i.Spec.Postgres.Backup.S3.Image != "" {
+		return *i.Spec.Postgres.Backup.S3.Image
+	}
+	return os.Getenv(EnvRelatedImageBackupS3)
+}
+
+// GetPostgresBackupPVCName returns the name of the PVC holding PostgreSQL dumps
+func (i *Immich) GetPostgresBackupPVCName() string {
+	if i.Spec.Postgres != nil && i.Spec.Postgres.Backup != nil && i.Spec.Postgres.Backup.Persistence != nil {
+		if i.Spec.Postgres.Backup.Persistence.ExistingClaim != nil && *i.Spec.Postgres.Backup.Persistence.ExistingClaim != "" {
+			return *i.Spec.Postgres.Backup.Persistence.ExistingClaim
+		}
+	}
+	return i.Name + "-postgres-backup"
+}
+
+// IsBackupCopyEnabled returns true if Immich database dumps are copied out of the library volume
+func (i *Immich) IsBackupCopyEnabled() bool {
+	if i.Spec.Immich == nil || i.Spec.Immich.BackupCopy == nil || i.Spec.Immich.BackupCopy.Enabled == nil {
+		return false
+	}
+	return *i.Spec.Immich.BackupCopy.Enabled
+}
+
+// GetBackupCopyImage returns the image used to copy Immich database dumps.
+// Defaults to the server image.
+func (i *Immich) GetBackupCopyImage() string {
+	if i.Spec.Immich != nil && i.Spec.Immich.BackupCopy != nil && i.Spec.Immich.BackupCopy.Image != nil && *i.Spec.Immich.BackupCopy.Image != "" {
+		return *i.Spec.Immich.BackupCopy.Image
+	}
+	return i.GetServerImage()
+}
+
+// GetBackupCopyPVCName returns the name of the PVC holding the copies of Immich database dumps
+func (i *Immich) GetBackupCopyPVCName() string {
+	if i.Spec.Immich != nil && i.Spec.Immich.BackupCopy != nil && i.Spec.Immich.BackupCopy.Persistence != nil {
+		if i.Spec.Immich.BackupCopy.Persistence.ExistingClaim != nil && *i.Spec.Immich.BackupCopy.Persistence.ExistingClaim != "" {
+			return *i.Spec.Immich.BackupCopy.Persistence.ExistingClaim
+		}
+	}
+	return i.Name + "-backup-copy"
+}
+
+// ShouldCreateBackupCopyPVC returns true if the operator should create a PVC for the copies of Immich database dumps
+func (i *Immich) ShouldCreateBackupCopyPVC() bool {
+	if !i.IsBackupCopyEnabled() {
+		return false
+	}
+	if i.Spec.Immich.BackupCopy.Persistence != nil {
+		return i.Spec.Immich.BackupCopy.Persistence.ExistingClaim == nil || *i.Spec.Immich.BackupCopy.Persistence.ExistingClaim == ""
+	}
+	return true
+}
+
+// ShouldCreatePostgresBackupPVC returns true if the operator should create a PVC for PostgreSQL dumps
+func (i *Immich) ShouldCreatePostgresBackupPVC() bool {
+	if !i.IsPostgresBackupEnabled() || i.IsPostgresBackupToS3() {
+		return false
+	}
+	if i.Spec.Postgres.Backup.Persistence != nil {
+		return i.Spec.Postgres.Backup.Persistence.ExistingClaim == nil || *i.Spec.Postgres.Backup.Persistence.ExistingClaim == ""
+	}
+	return true
+}
+
+// IsPostgresUpgradeEnabled returns true if major version upgrades of the built-in PostgreSQL are automated
+func (i *Immich) IsPostgresUpgradeEnabled() bool {
+	if i.Spec.Postgres == nil || i.Spec.Postgres.Upgrade == nil || i.Spec.Postgres.Upgrade.Enabled == nil {
+		return true // default to enabled
+	}
+	return *i.Spec.Postgres.Upgrade.Enabled
+}
+
+// IsPostgresUpgradeInProgress returns true while the server must stay down for a PostgreSQL upgrade.
+// The version check runs while the previous PostgreSQL version keeps serving.
+func (i *Immich) IsPostgresUpgradeInProgress() bool {
+	return i.Status.PostgresUpgrade != nil && i.Status.PostgresUpgrade.Phase != PostgresUpgradePhaseVersionCheck
+}
+
+// GetPostgresUpgradePVCName returns the name of the PVC holding the data of PostgreSQL upgrades
+func (i *Immich) GetPostgresUpgradePVCName() string {
+	return i.Name + "-postgres-upgrade"
+}
+
+// IsUpgradePolicyEnabled returns true if changes of the server image are orchestrated
+func (i *Immich) IsUpgradePolicyEnabled() bool {
+	return i.Spec.UpgradePolicy != nil && i.Spec.UpgradePolicy.Enabled != nil && *i.Spec.UpgradePolicy.Enabled
+}
+
+// IsUpgradeSnapshotEnabled returns true if the database is snapshotted before an orchestrated upgrade
+func (i *Immich) IsUpgradeSnapshotEnabled() bool {
+	if !i.IsUpgradePolicyEnabled() {
+		return false
+	}
+	if i.Spec.UpgradePolicy.Snapshot == nil {
+		return true // default to enabled
+	}
+	return *i.Spec.UpgradePolicy.Snapshot
+}
+
+// GetUpgradeTimeout returns how long the new server has to report the new version
+func (i *Immich) GetUpgradeTimeout() time.Duration {
+	if i.Spec.UpgradePolicy != nil && i.Spec.UpgradePolicy.Timeout != nil && i.Spec.UpgradePolicy.Timeout.Duration > 0 {
+		return i.Spec.UpgradePolicy.Timeout.Duration
+	}
+	return 15 * time.Minute
+}
+
+// GetUpgradeSnapshotPVCName returns the name of the PVC holding pre-upgrade snapshots
+func (i *Immich) GetUpgradeSnapshotPVCName() string {
+	if i.Spec.UpgradePolicy != nil && i.Spec.UpgradePolicy.Persistence != nil {
+		if i.Spec.UpgradePolicy.Persistence.ExistingClaim != nil && *i.Spec.UpgradePolicy.Persistence.ExistingClaim != "" {
+			return *i.Spec.UpgradePolicy.Persistence.ExistingClaim
+		}
+	}
+	return i.Name + "-upgrade-snapshots"
+}
+
 // GetPostgresPVCName returns the name of the PVC for PostgreSQL data.
 // When using VolumeClaimTemplates, the PVC is named: <volumeClaimTemplate.name>-<statefulset.name>-<ordinal>
 func (i *Immich) GetPostgresPVCName() string {
@@ -1208,8 +2622,12 @@ func (i *Immich) GetPostgresPVCName() string {
 }
 
 // GetPostgresHost returns the hostname to connect to PostgreSQL.
-// If built-in is enabled, returns the service name. Otherwise returns the external host.
+// If built-in is enabled, returns the service name (the read-write service of the CloudNativePG Cluster).
+// Otherwise returns the external host.
 func (i *Immich) GetPostgresHost() string {
+	if i.IsCloudNativePGEnabled() {
+		return i.Name + "-postgres-rw"
+	}
 	if i.IsPostgresEnabled() {
 		return i.Name + "-postgres"
 	}
@@ -1263,16 +2681,40 @@ func (i *Immich) GetValkeyPort() int32 {
 	return 6379
 }
 
-// GetMachineLearningURL returns the URL for the machine learning service.
-// If built-in is enabled, returns the internal service URL. Otherwise returns the external URL.
-func (i *Immich) GetMachineLearningURL() string {
-	if i.IsMachineLearningEnabled() {
-		return "http://" + i.Name + "-machine-learning:3003"
+// GetMachineLearningURLs returns the URLs of the machine learning backends, in priority order.
+// Without backends, this is the built-in service URL, the external URL when the built-in ML is disabled,
+// or none. Built-in backends are only listed while the built-in ML is enabled.
+func (i *Immich) GetMachineLearningURLs() []string {
+	if !i.HasMachineLearningBackends() {
+		if i.IsMachineLearningEnabled() {
+			return []string{"http://" + i.Name + "-machine-learning:3003"}
+		}
+		if i.Spec.MachineLearning != nil && i.Spec.MachineLearning.URL != nil && *i.Spec.MachineLearning.URL != "" {
+			return []string{*i.Spec.MachineLearning.URL}
+		}
+		return nil
 	}
-	if i.Spec.MachineLearning != nil && i.Spec.MachineLearning.URL != nil {
-		return *i.Spec.MachineLearning.URL
+
+	var urls []string
+	for _, backend := range i.Spec.MachineLearning.Backends {
+		if backend.IsRemote() || i.IsMachineLearningEnabled() {
+			urls = append(urls, i.GetMachineLearningBackendURL(&backend))
+		}
 	}
-	return ""
+	return urls
+}
+
+// GetMachineLearningBackendURL returns the URL of an ML backend: its url, or the URL of its Service when built-in
+func (i *Immich) GetMachineLearningBackendURL(backend *MachineLearningBackendSpec) string {
+	if backend.IsRemote() {
+		return *backend.URL
+	}
+	return "http://" + i.Name + "-machine-learning-" + backend.Name + ":3003"
+}
+
+// HasMachineLearningBackends returns true if the ML backends are listed in spec.machineLearning.backends
+func (i *Immich) HasMachineLearningBackends() bool {
+	return i.Spec.MachineLearning != nil && len(i.Spec.MachineLearning.Backends) > 0
 }
 
 // IsIngressEnabled returns true if ingress is enabled for the server
@@ -1324,14 +2766,54 @@ func (i *Immich) IsMetricsEnabled() bool {
 	return *i.Spec.Immich.Metrics.Enabled
 }
 
-// GetConfigurationKind returns the kind of resource to store configuration in
+// IsServiceMonitorExplicitlyDisabled returns true if the ServiceMonitor is explicitly disabled (set to false)
+func (i *Immich) IsServiceMonitorExplicitlyDisabled() bool {
+	if i.Spec.Immich == nil || i.Spec.Immich.Metrics == nil || i.Spec.Immich.Metrics.ServiceMonitor == nil ||
+		i.Spec.Immich.Metrics.ServiceMonitor.Enabled == nil {
+		return false // not explicitly disabled, just not set
+	}
+	return !*i.Spec.Immich.Metrics.ServiceMonitor.Enabled
+}
+
+// ShouldCreateServiceMonitor returns true if a ServiceMonitor should be created.
+// It requires metrics to be enabled, the ServiceMonitor API to be available,
+// and the ServiceMonitor not to be explicitly disabled.
+func (i *Immich) ShouldCreateServiceMonitor(serviceMonitorAPIAvailable bool) bool {
+	return i.IsMetricsEnabled() && serviceMonitorAPIAvailable && !i.IsServiceMonitorExplicitlyDisabled()
+}
+
+// GetConfigurationKind returns the kind of resource to store configuration in.
+// Configuration referencing Secrets is always stored in a Secret, as it holds their values.
 func (i *Immich) GetConfigurationKind() string {
+	if i.HasConfigurationSecretRefs() {
+		return "Secret"
+	}
 	if i.Spec.Immich != nil && i.Spec.Immich.ConfigurationKind != nil && *i.Spec.Immich.ConfigurationKind != "" {
 		return *i.Spec.Immich.ConfigurationKind
 	}
 	return "ConfigMap"
 }
 
+// HasConfigurationSecretRefs returns true if the Immich configuration references Secrets,
+// such as the SMTP password, the OAuth client secret or a raw configuration stored in a Secret
+func (i *Immich) HasConfigurationSecretRefs() bool {
+	if i.Spec.Immich == nil {
+		return false
+	}
+	if i.Spec.Immich.RawConfiguration != nil && i.Spec.Immich.RawConfiguration.SecretRef != nil {
+		return true
+	}
+	if i.Spec.Immich.Configuration == nil {
+		return false
+	}
+	configuration := i.Spec.Immich.Configuration
+	if configuration.Notifications != nil && configuration.Notifications.SMTP != nil &&
+		configuration.Notifications.SMTP.Transport != nil && configuration.Notifications.SMTP.Transport.PasswordSecretRef != nil {
+		return true
+	}
+	return configuration.OAuth != nil && configuration.OAuth.ClientSecretRef != nil
+}
+
 // GetServerReplicas returns the number of server replicas
 func (i *Immich) GetServerReplicas() int32 {
 	if i.Spec.Server != nil && i.Spec.Server.Replicas != nil {
@@ -1489,7 +2971,8 @@ func (i *Immich) ShouldCreateMLCachePVC() bool {
 
 // ShouldCreateValkeyPVC returns true if the operator should create a PVC for Valkey
 func (i *Immich) ShouldCreateValkeyPVC() bool {
-	if !i.IsValkeyPersistenceEnabled() {
+	// In sentinel mode, each Valkey pod gets its own PVC from the StatefulSet
+	if !i.IsValkeyPersistenceEnabled() || i.IsValkeySentinelEnabled() {
 		return false
 	}
 	if i.Spec.Valkey != nil && i.Spec.Valkey.Persistence != nil {
@@ -0,0 +1,181 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Restore phases
+const (
+	RestorePhasePending     = "Pending"
+	RestorePhaseScalingDown = "ScalingDown"
+	RestorePhaseRestoring   = "Restoring"
+	RestorePhaseCompleted   = "Completed"
+	RestorePhaseFailed      = "Failed"
+)
+
+// ImmichRestoreSpec defines the desired state of ImmichRestore.
+type ImmichRestoreSpec struct {
+	// ImmichRef is the name of the Immich instance to restore, in the same namespace.
+	// Only the built-in PostgreSQL database can be restored.
+	// +kubebuilder:validation:MinLength=1
+	ImmichRef string `json:"immichRef"`
+
+	// Source of the database dump
+	Source RestoreSource `json:"source"`
+
+	// Image used to run the restore. Defaults to the PostgreSQL image of the Immich instance,
+	// so that the client tools match the server version. The image must provide bash, pg_isready and psql.
+	// +optional
+	Image *string `json:"image,omitempty"`
+
+	// Resource requirements for the restore containers
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// RestoreSource defines where the database dump is read from.
+// Exactly one of pvc or s3 must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.pvc) != has(self.s3)",message="exactly one of pvc or s3 must be set"
+type RestoreSource struct {
+	// PVC reads the dump from a PersistentVolumeClaim, e.g. the PostgreSQL backup PVC
+	// +optional
+	PVC *RestorePVCSource `json:"pvc,omitempty"`
+
+	// S3 downloads the dump from an S3-compatible endpoint
+	// +optional
+	S3 *RestoreS3Source `json:"s3,omitempty"`
+}
+
+// RestorePVCSource defines a dump stored in a PVC.
+type RestorePVCSource struct {
+	// Name of the PVC containing the dump
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+
+	// Path of the dump file, relative to the root of the PVC (e.g., "immich-20250101020000.sql.gz").
+	// Dumps compressed with gzip must have a .gz extension.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+}
+
+// RestoreS3Source defines a dump stored in an S3-compatible bucket.
+type RestoreS3Source struct {
+	// Bucket containing the dump
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+
+	// Key of the dump in the bucket (e.g., "immich/immich-20250101020000.sql.gz").
+	// Dumps compressed with gzip must have a .gz extension.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+
+	// Endpoint URL of the S3-compatible service (e.g., "https://minio.example.com").
+	// If not set, AWS S3 is used.
+	// +optional
+	Endpoint *string `json:"endpoint,omitempty"`
+
+	// Region of the bucket
+	// +optional
+	Region *string `json:"region,omitempty"`
+
+	// Reference to a secret containing the access key ID
+	AccessKeyIDSecretRef SecretKeySelector `json:"accessKeyIdSecretRef"`
+
+	// Reference to a secret containing the secret access key
+	SecretAccessKeySecretRef SecretKeySelector `json:"secretAccessKeySecretRef"`
+
+	// Image is the full image reference of the AWS CLI used to download the dump
+	// If not set, defaults to RELATED_IMAGE_backupS3 environment variable
+	// +optional
+	Image *string `json:"image,omitempty"`
+}
+
+// ImmichRestoreStatus defines the observed state of ImmichRestore.
+type ImmichRestoreStatus struct {
+	// Phase of the restore: Pending, ScalingDown, Restoring, Completed or Failed
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// Conditions represent the latest available observations of the restore's state
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// StartTime is the time the restore was started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the restore completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Immich",type="string",JSONPath=".spec.immichRef",description="Immich instance being restored"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Phase of the restore"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ImmichRestore is the Schema for the immichrestores API.
+// It restores the built-in PostgreSQL database of an Immich instance from a dump,
+// scaling the server down for the duration of the restore.
+type ImmichRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ImmichRestoreSpec   `json:"spec,omitempty"`
+	Status ImmichRestoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ImmichRestoreList contains a list of ImmichRestore.
+type ImmichRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ImmichRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ImmichRestore{}, &ImmichRestoreList{})
+}
+
+// IsFinished returns true if the restore has completed or failed
+func (r *ImmichRestore) IsFinished() bool {
+	return r.Status.Phase == RestorePhaseCompleted || r.Status.Phase == RestorePhaseFailed
+}
+
+// GetRestoreImage returns the image running the restore, defaulting to the PostgreSQL image of the Immich instance
+func (r *ImmichRestore) GetRestoreImage(immich *Immich) string {
+	if r.Spec.Image != nil && *r.Spec.Image != "" {
+		return *r.Spec.Image
+	}
+	return immich.GetPostgresImage()
+}
+
+// GetS3Image returns the AWS CLI image used to download the dump
+// Falls back to RELATED_IMAGE_backupS3 environment variable.
+func (r *ImmichRestore) GetS3Image() string {
+	if r.Spec.Source.S3 != nil && r.Spec.Source.S3.Image != nil && *r.Spec.Source.S3.Image != "" {
+		return *r.Spec.Source.S3.Image
+	}
+	return os.Getenv(EnvRelatedImageBackupS3)
+}
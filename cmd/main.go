@@ -40,6 +40,7 @@ import (
 
 	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
 	"github.com/rm3l/immich-operator/internal/controller"
+	webhookv1alpha1 "github.com/rm3l/immich-operator/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -214,10 +215,28 @@ func main() {
 		Client:          mgr.GetClient(),
 		Scheme:          mgr.GetScheme(),
 		DiscoveryClient: discoveryClient,
+		APIReader:       mgr.GetAPIReader(),
+		Recorder:        mgr.GetEventRecorderFor("immich-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Immich")
 		os.Exit(1)
 	}
+	if err := (&controller.ImmichRestoreReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ImmichRestore")
+		os.Exit(1)
+	}
+	// The webhooks are served unless ENABLE_WEBHOOKS=false, the default of make run: their serving certificates are
+	// provisioned in the cluster, by cert-manager with make deploy or by OLM with the bundle
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1alpha1.SetupImmichWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Immich")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
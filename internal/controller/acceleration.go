@@ -0,0 +1,207 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// videoGroupID is the GID of the video group owning /dev/dri devices on most distributions
+const videoGroupID int64 = 44
+
+// GPU resources advertised by the NVIDIA, Intel and AMD device plugins
+const (
+	resourceNvidiaGPU corev1.ResourceName = "nvidia.com/gpu"
+	resourceIntelGPU  corev1.ResourceName = "gpu.intel.com/i915"
+	resourceAMDGPU    corev1.ResourceName = "amd.com/gpu"
+)
+
+// hostDevice is a file of the node mounted into a container
+type hostDevice struct {
+	name     string
+	path     string
+	hostType corev1.HostPathType
+	readOnly bool
+}
+
+// accelerationProfile is what a hardware acceleration adds to a Deployment
+type accelerationProfile struct {
+	// resources are the device plugin resources to request, unless the resources of the component already request them
+	resources corev1.ResourceList
+	// devices are mounted from the node, opened through the supplemental groups unless the container is privileged
+	devices            []hostDevice
+	supplementalGroups []int64
+	// privileged opts in to a privileged container when devices are mounted, for container runtimes denying
+	// the devices of the node to unprivileged containers
+	privileged       bool
+	runtimeClassName *string
+	env              []corev1.EnvVar
+	// arch is the only node architecture supporting the acceleration
+	arch string
+}
+
+// isEnabled returns true if the profile changes the Deployment
+func (p accelerationProfile) isEnabled() bool {
+	return len(p.resources) > 0 || len(p.devices) > 0 || len(p.supplementalGroups) > 0 ||
+		p.runtimeClassName != nil || len(p.env) > 0 || p.arch != ""
+}
+
+// getAccelerationProfile returns the profile of a machine learning acceleration, following the
+// hardware acceleration setup documented by Immich. The device plugins of the GPU vendors mount
+// the devices of the GPUs they allocate, while ARM NN and RKNN rely on devices of the node.
+func getAccelerationProfile(acceleration string) accelerationProfile {
+	switch acceleration {
+	case mediav1alpha1.MachineLearningAccelerationCUDA:
+		return accelerationProfile{resources: corev1.ResourceList{resourceNvidiaGPU: resource.MustParse("1")}}
+	case mediav1alpha1.MachineLearningAccelerationOpenVINO:
+		return accelerationProfile{resources: corev1.ResourceList{resourceIntelGPU: resource.MustParse("1")}}
+	case mediav1alpha1.MachineLearningAccelerationROCm:
+		return accelerationProfile{resources: corev1.ResourceList{resourceAMDGPU: resource.MustParse("1")}}
+	case mediav1alpha1.MachineLearningAccelerationARMNN:
+		return accelerationProfile{
+			devices: []hostDevice{
+				{name: "dev-mali", path: "/dev/mali0", hostType: corev1.HostPathCharDev},
+				{name: "mali-firmware", path: "/lib/firmware/mali_csffw.bin", hostType: corev1.HostPathFile, readOnly: true},
+				{name: "libmali", path: "/usr/lib/libmali.so", hostType: corev1.HostPathFile, readOnly: true},
+			},
+			supplementalGroups: []int64{videoGroupID},
+			env:                []corev1.EnvVar{{Name: "MACHINE_LEARNING_ANN", Value: "true"}},
+			arch:               "arm64",
+		}
+	case mediav1alpha1.MachineLearningAccelerationRKNN:
+		return accelerationProfile{
+			devices: []hostDevice{
+				{name: "dev-dri", path: "/dev/dri", hostType: corev1.HostPathDirectory},
+				{name: "dev-dma-heap", path: "/dev/dma_heap", hostType: corev1.HostPathDirectory},
+				{name: "dev-mpp-service", path: "/dev/mpp_service", hostType: corev1.HostPathCharDev},
+			},
+			supplementalGroups: []int64{videoGroupID},
+			env:                []corev1.EnvVar{{Name: "MACHINE_LEARNING_RKNN", Value: "true"}},
+			arch:               "arm64",
+		}
+	default:
+		return accelerationProfile{}
+	}
+}
+
+// getResources returns the resource requirements of the component with the device plugin resources of the profile.
+// Extended resources cannot be overcommitted, so they are set in both requests and limits.
+func (p accelerationProfile) getResources(resources corev1.ResourceRequirements) corev1.ResourceRequirements {
+	if len(p.resources) == 0 {
+		return resources
+	}
+
+	result := *resources.DeepCopy()
+	for name, quantity := range p.resources {
+		if _, ok := resources.Limits[name]; ok {
+			continue
+		}
+		if _, ok := resources.Requests[name]; ok {
+			continue
+		}
+		if result.Limits == nil {
+			result.Limits = corev1.ResourceList{}
+		}
+		if result.Requests == nil {
+			result.Requests = corev1.ResourceList{}
+		}
+		result.Limits[name] = quantity.DeepCopy()
+		result.Requests[name] = quantity.DeepCopy()
+	}
+	return result
+}
+
+// getNodeSelector returns the node selector of the component, restricted to the architecture of the profile
+// unless it already selects one
+func (p accelerationProfile) getNodeSelector(nodeSelector map[string]string) map[string]string {
+	if p.arch == "" {
+		return nodeSelector
+	}
+	if _, ok := nodeSelector[corev1.LabelArchStable]; ok {
+		return nodeSelector
+	}
+	result := maps.Clone(nodeSelector)
+	if result == nil {
+		result = map[string]string{}
+	}
+	result[corev1.LabelArchStable] = p.arch
+	return result
+}
+
+// getSecurityContext returns the container security context, privileged when devices of the node are mounted
+// and the profile opts in to it, unless the container explicitly sets privileged
+func (p accelerationProfile) getSecurityContext(securityContext *corev1.SecurityContext) *corev1.SecurityContext {
+	if len(p.devices) == 0 || !p.privileged {
+		return securityContext
+	}
+	if securityContext == nil {
+		return &corev1.SecurityContext{Privileged: ptr.To(true)}
+	}
+	if securityContext.Privileged != nil {
+		return securityContext
+	}
+	result := securityContext.DeepCopy()
+	result.Privileged = ptr.To(true)
+	return result
+}
+
+// getVolumes returns the hostPath volumes of the devices of the profile
+func (p accelerationProfile) getVolumes() []corev1.Volume {
+	var volumes []corev1.Volume
+	for _, device := range p.devices {
+		volumes = append(volumes, corev1.Volume{
+			Name: device.name,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: device.path, Type: ptr.To(device.hostType)},
+			},
+		})
+	}
+	return volumes
+}
+
+// getPodSecurityContext returns the pod security context with the supplemental groups of the profile
+func (p accelerationProfile) getPodSecurityContext(securityContext *corev1.PodSecurityContext) *corev1.PodSecurityContext {
+	if len(p.supplementalGroups) == 0 {
+		return securityContext
+	}
+	result := &corev1.PodSecurityContext{}
+	if securityContext != nil {
+		result = securityContext.DeepCopy()
+	}
+	for _, group := range p.supplementalGroups {
+		if !slices.Contains(result.SupplementalGroups, group) {
+			result.SupplementalGroups = append(result.SupplementalGroups, group)
+		}
+	}
+	return result
+}
+
+// getVolumeMounts returns the mounts of the devices of the profile, at the same path as on the node
+func (p accelerationProfile) getVolumeMounts() []corev1.VolumeMount {
+	var mounts []corev1.VolumeMount
+	for _, device := range p.devices {
+		mounts = append(mounts, corev1.VolumeMount{Name: device.name, MountPath: device.path, ReadOnly: device.readOnly})
+	}
+	return mounts
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestGetMachineLearningImageVariant(t *testing.T) {
+	const relatedImage = "ghcr.io/immich-app/immich-machine-learning:v1.125.7"
+
+	tests := []struct {
+		name         string
+		acceleration string
+		image        string
+		wantImage    string
+		wantOK       bool
+	}{
+		{
+			name:         "cpu uses the image as is",
+			acceleration: mediav1alpha1.MachineLearningAccelerationCPU,
+			wantImage:    relatedImage,
+			wantOK:       true,
+		},
+		{
+			name:         "variant of the related image",
+			acceleration: mediav1alpha1.MachineLearningAccelerationCUDA,
+			wantImage:    relatedImage + "-cuda",
+			wantOK:       true,
+		},
+		{
+			name:         "variant of an image on a registry with a port",
+			acceleration: mediav1alpha1.MachineLearningAccelerationOpenVINO,
+			image:        "registry.local:5000/immich-machine-learning:release",
+			wantImage:    "registry.local:5000/immich-machine-learning:release-openvino",
+			wantOK:       true,
+		},
+		{
+			name:         "image already carrying the variant",
+			acceleration: mediav1alpha1.MachineLearningAccelerationROCm,
+			image:        "ghcr.io/immich-app/immich-machine-learning:v1.125.7-rocm@sha256:abc",
+			wantImage:    "ghcr.io/immich-app/immich-machine-learning:v1.125.7-rocm@sha256:abc",
+			wantOK:       true,
+		},
+		{
+			name:         "image pinned by digest",
+			acceleration: mediav1alpha1.MachineLearningAccelerationARMNN,
+			image:        "ghcr.io/immich-app/immich-machine-learning:v1.125.7@sha256:abc",
+			wantImage:    "ghcr.io/immich-app/immich-machine-learning:v1.125.7@sha256:abc",
+			wantOK:       false,
+		},
+		{
+			name:         "image without tag",
+			acceleration: mediav1alpha1.MachineLearningAccelerationRKNN,
+			image:        "registry.local:5000/immich-machine-learning",
+			wantImage:    "registry.local:5000/immich-machine-learning",
+			wantOK:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(mediav1alpha1.EnvRelatedImageMachineLearning, relatedImage)
+			immich := &mediav1alpha1.Immich{
+				Spec: mediav1alpha1.ImmichSpec{
+					MachineLearning: &mediav1alpha1.MachineLearningSpec{Acceleration: ptr.To(tt.acceleration)},
+				},
+			}
+			if tt.image != "" {
+				immich.Spec.MachineLearning.Image = ptr.To(tt.image)
+			}
+
+			image, ok := immich.GetMachineLearningImageVariant()
+			if image != tt.wantImage || ok != tt.wantOK {
+				t.Errorf("GetMachineLearningImageVariant() = %q, %v, want %q, %v", image, ok, tt.wantImage, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReconcileMLDeployment_Acceleration(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImageMachineLearning, "ghcr.io/immich-app/immich-machine-learning:v1.125.7")
+
+	tests := []struct {
+		name           string
+		acceleration   string
+		privileged     *bool
+		wantImage      string
+		wantGPU        corev1.ResourceName
+		wantDevices    []string
+		wantEnv        string
+		wantPrivileged bool
+	}{
+		{
+			name:         "cpu",
+			acceleration: mediav1alpha1.MachineLearningAccelerationCPU,
+			wantImage:    "ghcr.io/immich-app/immich-machine-learning:v1.125.7",
+		},
+		{
+			name:         "cuda",
+			acceleration: mediav1alpha1.MachineLearningAccelerationCUDA,
+			wantImage:    "ghcr.io/immich-app/immich-machine-learning:v1.125.7-cuda",
+			wantGPU:      "nvidia.com/gpu",
+		},
+		{
+			name:         "openvino",
+			acceleration: mediav1alpha1.MachineLearningAccelerationOpenVINO,
+			wantImage:    "ghcr.io/immich-app/immich-machine-learning:v1.125.7-openvino",
+			wantGPU:      "gpu.intel.com/i915",
+		},
+		{
+			name:         "rocm",
+			acceleration: mediav1alpha1.MachineLearningAccelerationROCm,
+			wantImage:    "ghcr.io/immich-app/immich-machine-learning:v1.125.7-rocm",
+			wantGPU:      "amd.com/gpu",
+		},
+		{
+			name:         "armnn",
+			acceleration: mediav1alpha1.MachineLearningAccelerationARMNN,
+			wantImage:    "ghcr.io/immich-app/immich-machine-learning:v1.125.7-armnn",
+			wantDevices:  []string{"/dev/mali0", "/lib/firmware/mali_csffw.bin", "/usr/lib/libmali.so"},
+			wantEnv:      "MACHINE_LEARNING_ANN",
+		},
+		{
+			name:         "rknn",
+			acceleration: mediav1alpha1.MachineLearningAccelerationRKNN,
+			wantImage:    "ghcr.io/immich-app/immich-machine-learning:v1.125.7-rknn",
+			wantDevices:  []string{"/dev/dri", "/dev/dma_heap", "/dev/mpp_service"},
+			wantEnv:      "MACHINE_LEARNING_RKNN",
+		},
+		{
+			name:           "rknn in a privileged container",
+			acceleration:   mediav1alpha1.MachineLearningAccelerationRKNN,
+			privileged:     ptr.To(true),
+			wantImage:      "ghcr.io/immich-app/immich-machine-learning:v1.125.7-rknn",
+			wantDevices:    []string{"/dev/dri", "/dev/dma_heap", "/dev/mpp_service"},
+			wantEnv:        "MACHINE_LEARNING_RKNN",
+			wantPrivileged: true,
+		},
+		{
+			name:         "privileged ignored with a device plugin",
+			acceleration: mediav1alpha1.MachineLearningAccelerationCUDA,
+			privileged:   ptr.To(true),
+			wantImage:    "ghcr.io/immich-app/immich-machine-learning:v1.125.7-cuda",
+			wantGPU:      "nvidia.com/gpu",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := &mediav1alpha1.Immich{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+				Spec: mediav1alpha1.ImmichSpec{
+					MachineLearning: &mediav1alpha1.MachineLearningSpec{
+						Acceleration: ptr.To(tt.acceleration),
+						Privileged:   tt.privileged,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+						},
+					},
+				},
+			}
+
+			applied := map[string]client.Object{}
+			r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+			if err := r.reconcileMLDeployment(context.Background(), immich, getMachineLearningWorkloads(immich)[0]); err != nil {
+				t.Fatalf("reconcileMLDeployment() error = %v", err)
+			}
+
+			podSpec := applied["Deployment/test-immich-machine-learning"].(*appsv1.Deployment).Spec.Template.Spec
+			container := podSpec.Containers[0]
+			if container.Image != tt.wantImage {
+				t.Errorf("image = %q, want %q", container.Image, tt.wantImage)
+			}
+			if !container.Resources.Requests.Memory().Equal(resource.MustParse("2Gi")) {
+				t.Errorf("requests = %v, want the configured memory kept", container.Resources.Requests)
+			}
+
+			for _, name := range []corev1.ResourceName{resourceNvidiaGPU, resourceIntelGPU, resourceAMDGPU} {
+				limit, requested := container.Resources.Limits[name]
+				if name != tt.wantGPU {
+					if requested {
+						t.Errorf("unexpected %s limit", name)
+					}
+					continue
+				}
+				if !requested || limit.Value() != 1 || container.Resources.Requests.Name(name, resource.DecimalSI).Value() != 1 {
+					t.Errorf("%s = %v, want one GPU requested", name, container.Resources)
+				}
+			}
+
+			mounts := map[string]bool{}
+			for _, mount := range container.VolumeMounts {
+				mounts[mount.MountPath] = true
+			}
+			for _, device := range tt.wantDevices {
+				if !mounts[device] {
+					t.Errorf("device %s not mounted, mounts = %v", device, container.VolumeMounts)
+				}
+			}
+			if len(container.VolumeMounts) != 1+len(tt.wantDevices) || len(podSpec.Volumes) != 1+len(tt.wantDevices) {
+				t.Errorf("volumes = %d, mounts = %d, want the cache and %d devices", len(podSpec.Volumes), len(container.VolumeMounts), len(tt.wantDevices))
+			}
+
+			if tt.wantEnv != "" {
+				if env := findEnv(container.Env, tt.wantEnv); env == nil || env.Value != "true" {
+					t.Errorf("%s = %+v, want true", tt.wantEnv, env)
+				}
+			}
+
+			privileged := container.SecurityContext != nil && ptr.Deref(container.SecurityContext.Privileged, false)
+			if privileged != tt.wantPrivileged {
+				t.Errorf("privileged = %v, want %v", privileged, tt.wantPrivileged)
+			}
+			mountsDevices := len(tt.wantDevices) > 0
+			if mountsDevices && podSpec.NodeSelector[corev1.LabelArchStable] != "arm64" {
+				t.Errorf("nodeSelector = %v, want arm64 nodes", podSpec.NodeSelector)
+			}
+			if !mountsDevices && podSpec.NodeSelector != nil {
+				t.Errorf("nodeSelector = %v, want none", podSpec.NodeSelector)
+			}
+			hasVideoGroup := podSpec.SecurityContext != nil && slices.Contains(podSpec.SecurityContext.SupplementalGroups, videoGroupID)
+			if hasVideoGroup != mountsDevices {
+				t.Errorf("podSecurityContext = %+v, want the video group with devices of the node", podSpec.SecurityContext)
+			}
+		})
+	}
+}
+
+func TestAccelerationProfile_KeepsUserSettings(t *testing.T) {
+	profile := getAccelerationProfile(mediav1alpha1.MachineLearningAccelerationCUDA)
+	resources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{resourceNvidiaGPU: resource.MustParse("2")},
+	}
+	if got := profile.getResources(resources); got.Limits.Name(resourceNvidiaGPU, resource.DecimalSI).Value() != 2 || got.Requests != nil {
+		t.Errorf("resources = %+v, want the configured GPUs kept", got)
+	}
+
+	profile = getAccelerationProfile(mediav1alpha1.MachineLearningAccelerationRKNN)
+	profile.privileged = true
+	securityContext := &corev1.SecurityContext{Privileged: ptr.To(false)}
+	if got := profile.getSecurityContext(securityContext); *got.Privileged {
+		t.Error("an explicit privileged=false should be kept")
+	}
+	nodeSelector := map[string]string{corev1.LabelArchStable: "arm64", "board": "rk3588"}
+	if got := profile.getNodeSelector(nodeSelector); len(got) != 2 {
+		t.Errorf("nodeSelector = %v, want it unchanged", got)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// defaultTargetCPUUtilizationPercentage is the CPU target used when no target or metric is configured
+const defaultTargetCPUUtilizationPercentage = 80
+
+// getDeploymentReplicas returns the replicas to apply on a Deployment.
+// With autoscaling, the replicas are left out of the applied configuration so that the HorizontalPodAutoscaler
+// owns them, except while the component is scaled down for maintenance. The HorizontalPodAutoscaler does not scale
+// a Deployment at 0 replicas; once the replicas are left out again, they are reset to 1 and it resumes scaling.
+func getDeploymentReplicas(replicas *int32, autoscaling bool, scaledDown bool) *int32 {
+	if scaledDown {
+		return ptr.To(int32(0))
+	}
+	if autoscaling {
+		return nil
+	}
+	return ptr.To(ptr.Deref(replicas, 1))
+}
+
+// getAutoscalingMetrics returns the metrics of a HorizontalPodAutoscaler: the CPU and memory utilization targets,
+// followed by the additional metrics
+func getAutoscalingMetrics(spec *mediav1alpha1.AutoscalingSpec) []autoscalingv2.MetricSpec {
+	var metrics []autoscalingv2.MetricSpec
+
+	resourceMetric := func(name corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: name,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: ptr.To(utilization),
+				},
+			},
+		}
+	}
+
+	if spec.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceCPU, *spec.TargetCPUUtilizationPercentage))
+	}
+	if spec.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceMemory, *spec.TargetMemoryUtilizationPercentage))
+	}
+	metrics = append(metrics, spec.Metrics...)
+
+	if len(metrics) == 0 {
+		metrics = append(metrics, resourceMetric(corev1.ResourceCPU, defaultTargetCPUUtilizationPercentage))
+	}
+	return metrics
+}
+
+// reconcileHorizontalPodAutoscaler creates or updates the HorizontalPodAutoscaler of a component Deployment
+// using server-side apply. The HorizontalPodAutoscaler has the same name as the Deployment it scales.
+func (r *ImmichReconciler) reconcileHorizontalPodAutoscaler(ctx context.Context, immich *mediav1alpha1.Immich, name string, component string, spec *mediav1alpha1.AutoscalingSpec) error {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: autoscalingv2.SchemeGroupVersion.String(),
+			Kind:       "HorizontalPodAutoscaler",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    r.getLabels(immich, component),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       "Deployment",
+				Name:       name,
+			},
+			MinReplicas: ptr.To(ptr.Deref(spec.MinReplicas, 1)),
+			MaxReplicas: ptr.Deref(spec.MaxReplicas, ptr.Deref(spec.MinReplicas, 1)),
+			Metrics:     getAutoscalingMetrics(spec),
+			Behavior:    spec.Behavior,
+		},
+	}
+
+	return r.apply(ctx, hpa)
+}
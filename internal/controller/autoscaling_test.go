@@ -0,0 +1,153 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestReconcileServer_Autoscaling(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "ghcr.io/immich-app/immich-server:v1.125.7")
+
+	podsMetric := autoscalingv2.MetricSpec{
+		Type: autoscalingv2.PodsMetricSourceType,
+		Pods: &autoscalingv2.PodsMetricSource{
+			Metric: autoscalingv2.MetricIdentifier{Name: "http_requests_per_second"},
+			Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType},
+		},
+	}
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Server: &mediav1alpha1.ServerSpec{
+				Replicas: ptr.To(int32(2)),
+				Autoscaling: &mediav1alpha1.AutoscalingSpec{
+					Enabled:                           ptr.To(true),
+					MinReplicas:                       ptr.To(int32(2)),
+					MaxReplicas:                       ptr.To(int32(5)),
+					TargetMemoryUtilizationPercentage: ptr.To(int32(75)),
+					Metrics:                           []autoscalingv2.MetricSpec{podsMetric},
+				},
+			},
+		},
+	}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+
+	if err := r.reconcileServer(ctx, immich); err != nil {
+		t.Fatalf("reconcileServer() error = %v", err)
+	}
+
+	if replicas := applied["Deployment/test-immich-server"].(*appsv1.Deployment).Spec.Replicas; replicas != nil {
+		t.Errorf("replicas = %d, want them left to the HorizontalPodAutoscaler", *replicas)
+	}
+
+	hpa := applied["HorizontalPodAutoscaler/test-immich-server"].(*autoscalingv2.HorizontalPodAutoscaler)
+	if hpa.Spec.ScaleTargetRef.Kind != "Deployment" || hpa.Spec.ScaleTargetRef.Name != "test-immich-server" {
+		t.Errorf("scaleTargetRef = %+v", hpa.Spec.ScaleTargetRef)
+	}
+	if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 5 {
+		t.Errorf("minReplicas = %d, maxReplicas = %d", *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+	if len(hpa.Spec.Metrics) != 2 || hpa.Spec.Metrics[0].Resource.Name != corev1.ResourceMemory ||
+		*hpa.Spec.Metrics[0].Resource.Target.AverageUtilization != 75 || hpa.Spec.Metrics[1].Pods == nil {
+		t.Errorf("metrics = %+v", hpa.Spec.Metrics)
+	}
+	if len(hpa.OwnerReferences) != 1 || hpa.Labels[labelComponent] != "server" {
+		t.Errorf("ownerReferences = %+v, labels = %v", hpa.OwnerReferences, hpa.Labels)
+	}
+
+	// The server is still scaled down while the database is upgraded
+	immich.Status.PostgresUpgrade = &mediav1alpha1.PostgresUpgradeStatus{Phase: mediav1alpha1.PostgresUpgradePhaseDumping}
+	if err := r.reconcileServerDeployment(ctx, immich, getServerHTTPWorkload(immich)); err != nil {
+		t.Fatalf("reconcileServerDeployment() error = %v", err)
+	}
+	if replicas := applied["Deployment/test-immich-server"].(*appsv1.Deployment).Spec.Replicas; replicas == nil || *replicas != 0 {
+		t.Errorf("replicas = %v, want 0 during the upgrade", replicas)
+	}
+}
+
+func TestReconcileMachineLearning_Autoscaling(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageMachineLearning, "ghcr.io/immich-app/immich-machine-learning:v1.125.7")
+
+	tests := []struct {
+		name         string
+		autoscaling  *mediav1alpha1.AutoscalingSpec
+		wantReplicas *int32
+	}{
+		{
+			name:         "fixed replicas",
+			autoscaling:  &mediav1alpha1.AutoscalingSpec{Enabled: ptr.To(false), MaxReplicas: ptr.To(int32(4))},
+			wantReplicas: ptr.To(int32(3)),
+		},
+		{
+			name:         "autoscaling on the default CPU target",
+			autoscaling:  &mediav1alpha1.AutoscalingSpec{Enabled: ptr.To(true), MaxReplicas: ptr.To(int32(4))},
+			wantReplicas: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := &mediav1alpha1.Immich{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+				Spec: mediav1alpha1.ImmichSpec{
+					MachineLearning: &mediav1alpha1.MachineLearningSpec{
+						Replicas:    ptr.To(int32(3)),
+						Autoscaling: tt.autoscaling,
+					},
+				},
+			}
+
+			applied := map[string]client.Object{}
+			r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+			if err := r.reconcileMachineLearning(ctx, immich); err != nil {
+				t.Fatalf("reconcileMachineLearning() error = %v", err)
+			}
+
+			replicas := applied["Deployment/test-immich-machine-learning"].(*appsv1.Deployment).Spec.Replicas
+			if ptr.Deref(replicas, -1) != ptr.Deref(tt.wantReplicas, -1) {
+				t.Errorf("replicas = %v, want %v", replicas, tt.wantReplicas)
+			}
+
+			obj, found := applied["HorizontalPodAutoscaler/test-immich-machine-learning"]
+			if found != (tt.wantReplicas == nil) {
+				t.Fatalf("HorizontalPodAutoscaler applied = %v", found)
+			}
+			if !found {
+				return
+			}
+			hpa := obj.(*autoscalingv2.HorizontalPodAutoscaler)
+			if *hpa.Spec.MinReplicas != 1 || len(hpa.Spec.Metrics) != 1 || hpa.Spec.Metrics[0].Resource.Name != corev1.ResourceCPU ||
+				*hpa.Spec.Metrics[0].Resource.Target.AverageUtilization != defaultTargetCPUUtilizationPercentage {
+				t.Errorf("spec = %+v", hpa.Spec)
+			}
+		})
+	}
+}
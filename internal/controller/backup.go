@@ -0,0 +1,391 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// backupDir is the directory where dumps are written in the backup pods
+const backupDir = "/backups"
+
+// reconcilePostgresBackup creates or updates the PostgreSQL backup CronJob and its PVC
+func (r *ImmichReconciler) reconcilePostgresBackup(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+	log.V(1).Info("Reconciling PostgreSQL backup")
+
+	// Create backup PVC (must be created before the CronJob)
+	if immich.ShouldCreatePostgresBackupPVC() {
+		if err := r.reconcilePostgresBackupPVC(ctx, immich); err != nil {
+			return err
+		}
+	}
+
+	return r.reconcilePostgresBackupCronJob(ctx, immich)
+}
+
+// reconcilePostgresBackupPVC creates the PVC holding PostgreSQL dumps if needed.
+// Note: Backup PVCs do NOT have an owner reference, so that dumps survive the Immich CR.
+func (r *ImmichReconciler) reconcilePostgresBackupPVC(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+
+	name := immich.GetPostgresBackupPVCName()
+	labels := r.getLabels(immich, "backup")
+
+	// Check if PVC already exists - PVCs are mostly immutable
+	existing := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, existing)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	persistence := ptr.Deref(immich.Spec.Postgres.Backup.Persistence, mediav1alpha1.BackupPersistenceSpec{})
+
+	size := resource.MustParse("10Gi")
+	if persistence.Size != nil && !persistence.Size.IsZero() {
+		size = *persistence.Size
+	}
+
+	accessModes := persistence.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      accessModes,
+			StorageClassName: persistence.StorageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+
+	log.Info("Creating PostgreSQL backup PVC (no owner reference for data safety)", "name", name, "size", size.String())
+	if err := r.Create(ctx, pvc); err != nil {
+		return err
+	}
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonPVCCreated, "Created PersistentVolumeClaim %s", pvc.Name)
+	return nil
+}
+
+// reconcilePostgresBackupCronJob creates or updates the PostgreSQL backup CronJob using server-side apply.
+// With a PVC destination, a single container dumps the database and prunes old dumps.
+// With an S3 destination, the dump is written to an emptyDir by an init container,
+// then uploaded (and old dumps pruned) by an AWS CLI container.
+func (r *ImmichReconciler) reconcilePostgresBackupCronJob(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	name := fmt.Sprintf("%s-postgres-backup", immich.Name)
+	labels := r.getLabels(immich, "backup")
+
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+	backupSpec := ptr.Deref(postgresSpec.Backup, mediav1alpha1.PostgresBackupSpec{})
+
+	image := immich.GetPostgresBackupImage()
+	if image == "" {
+		return fmt.Errorf("PostgreSQL backup image not configured: set spec.postgres.backup.image, spec.postgres.image or RELATED_IMAGE_postgres environment variable")
+	}
+
+	env := r.getPostgresBackupEnv(immich)
+
+	// Backups are suspended while the PostgreSQL image changes, as the database may be stopped
+	suspend := backupSpec.Suspend
+	if immich.Status.PostgresUpgrade != nil {
+		suspend = ptr.To(true)
+	}
+
+	// PostgreSQL certificates, referenced by the libpq environment
+	tlsVolumes, tlsVolumeMounts := getPostgresTLSVolumes(immich)
+
+	dumpContainer := corev1.Container{
+		Name:            "dump",
+		Image:           image,
+		ImagePullPolicy: postgresSpec.ImagePullPolicy,
+		Command:         []string{"/bin/sh", "-c", getPostgresDumpScript(immich)},
+		Env:             env,
+		Resources:       backupSpec.Resources,
+		SecurityContext: postgresSpec.SecurityContext,
+		VolumeMounts: append([]corev1.VolumeMount{
+			{Name: "backups", MountPath: backupDir},
+		}, tlsVolumeMounts...),
+	}
+
+	var initContainers, containers []corev1.Container
+	var backupsVolume corev1.VolumeSource
+
+	if immich.IsPostgresBackupToS3() {
+		s3 := backupSpec.S3
+		s3Image := immich.GetPostgresBackupS3Image()
+		if s3Image == "" {
+			return fmt.Errorf("PostgreSQL backup S3 image not configured: set spec.postgres.backup.s3.image or %s environment variable", mediav1alpha1.EnvRelatedImageBackupS3)
+		}
+
+		s3Env := append(append([]corev1.EnvVar{}, env...),
+			corev1.EnvVar{Name: "S3_BUCKET", Value: s3.Bucket},
+			corev1.EnvVar{Name: "S3_PREFIX", Value: getBackupS3Prefix(s3)},
+		)
+		s3Env = append(s3Env, getAWSEnv(s3.AccessKeyIDSecretRef, s3.SecretAccessKeySecretRef, s3.Endpoint, s3.Region)...)
+
+		initContainers = []corev1.Container{dumpContainer}
+		containers = []corev1.Container{
+			{
+				Name:            "upload",
+				Image:           s3Image,
+				ImagePullPolicy: postgresSpec.ImagePullPolicy,
+				Command:         []string{"/bin/sh", "-c", postgresBackupUploadScript},
+				Env:             s3Env,
+				Resources:       backupSpec.Resources,
+				SecurityContext: postgresSpec.SecurityContext,
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "backups", MountPath: backupDir},
+				},
+			},
+		}
+		backupsVolume = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	} else {
+		containers = []corev1.Container{dumpContainer}
+		backupsVolume = corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: immich.GetPostgresBackupPVCName(),
+			},
+		}
+	}
+
+	cronJob := &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+			Kind:       "CronJob",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   ptr.Deref(backupSpec.Schedule, "0 2 * * *"),
+			Suspend:                    suspend,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: ptr.To(int32(3)),
+			FailedJobsHistoryLimit:     ptr.To(int32(1)),
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: ptr.To(int32(2)),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy:    corev1.RestartPolicyNever,
+							ImagePullSecrets: immich.Spec.ImagePullSecrets,
+							SecurityContext:  postgresSpec.PodSecurityContext,
+							NodeSelector:     postgresSpec.NodeSelector,
+							Tolerations:      postgresSpec.Tolerations,
+							InitContainers:   initContainers,
+							Containers:       containers,
+							Volumes: append([]corev1.Volume{
+								{Name: "backups", VolumeSource: backupsVolume},
+							}, tlsVolumes...),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return r.apply(ctx, cronJob)
+}
+
+// getPostgresBackupEnv returns the environment shared by the backup containers
+func (r *ImmichReconciler) getPostgresBackupEnv(immich *mediav1alpha1.Immich) []corev1.EnvVar {
+	backupSpec := ptr.Deref(immich.Spec.Postgres.Backup, mediav1alpha1.PostgresBackupSpec{})
+
+	return append(getPostgresClientEnv(immich),
+		corev1.EnvVar{Name: "BACKUP_DIR", Value: backupDir},
+		corev1.EnvVar{Name: "BACKUP_PREFIX", Value: immich.Name},
+		corev1.EnvVar{Name: "BACKUP_RETENTION", Value: fmt.Sprintf("%d", ptr.Deref(backupSpec.Retention, 7))},
+	)
+}
+
+// getPostgresClientEnv returns the libpq environment variables connecting to the Immich database like the server,
+// with the password taken from the same secret as the PostgreSQL StatefulSet and the TLS settings of the connection.
+// When the database is only known by its URL (spec.postgres.urlSecretRef), DB_URL is set instead of PGHOST, PGPORT,
+// PGUSER, PGDATABASE and PGPASSWORD, and the scripts pass it as the database name, which libpq expands.
+func getPostgresClientEnv(immich *mediav1alpha1.Immich) []corev1.EnvVar {
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+
+	var env []corev1.EnvVar
+	if !immich.IsCloudNativePGEnabled() && postgresSpec.URLSecretRef != nil {
+		// Over TLS, the server connects with the URL rendered with the TLS options
+		urlRef := *postgresSpec.URLSecretRef
+		if immich.GetPostgresTLS() != nil {
+			urlRef = mediav1alpha1.SecretKeySelector{Name: getDatabaseURLSecretName(immich), Key: "url"}
+		}
+		env = append(env, corev1.EnvVar{
+			Name: "DB_URL",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: urlRef.Name},
+					Key:                  urlRef.Key,
+				},
+			},
+		})
+	} else {
+		secretRef := getPostgresPasswordSecretRef(immich)
+		env = append(env,
+			corev1.EnvVar{Name: "PGHOST", Value: immich.GetPostgresHost()},
+			corev1.EnvVar{Name: "PGPORT", Value: fmt.Sprintf("%d", immich.GetPostgresPort())},
+			corev1.EnvVar{Name: "PGUSER", Value: immich.GetPostgresUsername()},
+			corev1.EnvVar{Name: "PGDATABASE", Value: immich.GetPostgresDatabase()},
+			corev1.EnvVar{
+				Name: "PGPASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secretRef.Name},
+						Key:                  secretRef.Key,
+					},
+				},
+			},
+		)
+	}
+
+	return append(env, getPostgresTLSEnv(immich)...)
+}
+
+// getPostgresDumpScript returns the shell script dumping the database into BACKUP_DIR.
+// Dumps are named <prefix>-<UTC timestamp>.sql[.gz], so that sorting them by name sorts them by date.
+// When the dumps are kept on a PVC, the script also deletes the dumps exceeding the retention count.
+func getPostgresDumpScript(immich *mediav1alpha1.Immich) string {
+	backupSpec := ptr.Deref(immich.Spec.Postgres.Backup, mediav1alpha1.PostgresBackupSpec{})
+
+	dumpCmd := `pg_dump --clean --if-exists ${DB_URL:+-d "${DB_URL}"} -f "${tmp}"`
+	if ptr.Deref(backupSpec.Method, "pg_dump") == "pg_dumpall" {
+		dumpCmd = `pg_dumpall --clean --if-exists ${DB_URL:+-d "${DB_URL}"} ${PGDATABASE:+-l "${PGDATABASE}"} -f "${tmp}"`
+	}
+
+	var script strings.Builder
+	script.WriteString(`set -eu
+file="${BACKUP_PREFIX}-$(date -u +%Y%m%d%H%M%S).sql"
+tmp="${BACKUP_DIR}/.${file}.partial"
+echo "Dumping the database${PGDATABASE:+ ${PGDATABASE}}${PGHOST:+ from ${PGHOST}:${PGPORT}}..."
+` + dumpCmd + "\n")
+	if ptr.Deref(backupSpec.Compression, "gzip") == "gzip" {
+		script.WriteString(`gzip "${tmp}"
+tmp="${tmp}.gz"
+file="${file}.gz"
+`)
+	}
+	script.WriteString(`mv "${tmp}" "${BACKUP_DIR}/${file}"
+echo "Backup written to ${BACKUP_DIR}/${file}"
+`)
+
+	if !immich.IsPostgresBackupToS3() {
+		script.WriteString(`ls -1 "${BACKUP_DIR}" | grep "^${BACKUP_PREFIX}-[0-9]*\.sql" | sort -r | tail -n +$((BACKUP_RETENTION + 1)) | while read -r old; do
+  echo "Removing old backup ${old}"
+  rm -f "${BACKUP_DIR}/${old}"
+done
+`)
+	}
+
+	return script.String()
+}
+
+// postgresBackupUploadScript uploads the dump to S3, then deletes the dumps exceeding the retention count.
+const postgresBackupUploadScript = `set -eu
+for f in "${BACKUP_DIR}/${BACKUP_PREFIX}"-*.sql*; do
+  echo "Uploading $(basename "${f}") to s3://${S3_BUCKET}/${S3_PREFIX}"
+  aws s3 cp "${f}" "s3://${S3_BUCKET}/${S3_PREFIX}$(basename "${f}")"
+done
+aws s3 ls "s3://${S3_BUCKET}/${S3_PREFIX}" | awk '{print $4}' | grep "^${BACKUP_PREFIX}-[0-9]*\.sql" | sort -r | tail -n +$((BACKUP_RETENTION + 1)) | while read -r old; do
+  echo "Removing old backup ${old}"
+  aws s3 rm "s3://${S3_BUCKET}/${S3_PREFIX}${old}"
+done
+`
+
+// getAWSEnv returns the environment configuring the AWS CLI credentials and, if set, the endpoint and region
+func getAWSEnv(accessKeyIDRef, secretAccessKeyRef mediav1alpha1.SecretKeySelector, endpoint, region *string) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{
+			Name: "AWS_ACCESS_KEY_ID",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: accessKeyIDRef.Name},
+					Key:                  accessKeyIDRef.Key,
+				},
+			},
+		},
+		{
+			Name: "AWS_SECRET_ACCESS_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretAccessKeyRef.Name},
+					Key:                  secretAccessKeyRef.Key,
+				},
+			},
+		},
+	}
+	if endpoint != nil && *endpoint != "" {
+		env = append(env, corev1.EnvVar{Name: "AWS_ENDPOINT_URL", Value: *endpoint})
+	}
+	if region != nil && *region != "" {
+		env = append(env, corev1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: *region})
+	}
+	return env
+}
+
+// getBackupS3Prefix returns the key prefix of the dumps in the bucket, with a trailing slash if not empty
+func getBackupS3Prefix(s3 *mediav1alpha1.BackupS3Spec) string {
+	prefix := strings.Trim(ptr.Deref(s3.Prefix, ""), "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
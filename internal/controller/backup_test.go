@@ -0,0 +1,200 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// newApplyCapturingClient returns a fake client holding the given objects, recording the objects passed to
+// server-side apply
+func newApplyCapturingClient(t *testing.T, applied map[string]client.Object, objects ...client.Object) client.Client {
+	t.Helper()
+	return fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(objects...).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+			applied[obj.GetObjectKind().GroupVersionKind().Kind+"/"+obj.GetName()] = obj
+			return nil
+		},
+	}).Build()
+}
+
+func TestReconcilePostgresBackup_PVC(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:14")
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Postgres: &mediav1alpha1.PostgresSpec{
+				Backup: &mediav1alpha1.PostgresBackupSpec{
+					Enabled:   ptr.To(true),
+					Schedule:  ptr.To("30 3 * * *"),
+					Retention: ptr.To(int32(3)),
+				},
+			},
+		},
+	}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+
+	if err := r.reconcilePostgresBackup(ctx, immich); err != nil {
+		t.Fatalf("reconcilePostgresBackup() error = %v", err)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "test-immich-postgres-backup", Namespace: "default"}, pvc); err != nil {
+		t.Fatalf("backup PVC should have been created: %v", err)
+	}
+	if len(pvc.OwnerReferences) != 0 {
+		t.Error("backup PVC should not have an owner reference")
+	}
+
+	obj, ok := applied["CronJob/test-immich-postgres-backup"]
+	if !ok {
+		t.Fatal("backup CronJob should have been applied")
+	}
+	cronJob := obj.(*batchv1.CronJob)
+	if cronJob.Spec.Schedule != "30 3 * * *" {
+		t.Errorf("schedule = %s, want 30 3 * * *", cronJob.Spec.Schedule)
+	}
+
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 0 || len(podSpec.Containers) != 1 {
+		t.Fatalf("expected a single dump container, got %d init containers and %d containers",
+			len(podSpec.InitContainers), len(podSpec.Containers))
+	}
+	if podSpec.Volumes[0].PersistentVolumeClaim == nil || podSpec.Volumes[0].PersistentVolumeClaim.ClaimName != "test-immich-postgres-backup" {
+		t.Errorf("backups volume should use the backup PVC, got %+v", podSpec.Volumes[0])
+	}
+
+	container := podSpec.Containers[0]
+	if container.Image != "postgres:14" {
+		t.Errorf("image = %s, want the PostgreSQL image", container.Image)
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, e := range container.Env {
+		env[e.Name] = e
+	}
+	if env["PGHOST"].Value != "test-immich-postgres" {
+		t.Errorf("PGHOST = %s, want test-immich-postgres", env["PGHOST"].Value)
+	}
+	if env["BACKUP_RETENTION"].Value != "3" {
+		t.Errorf("BACKUP_RETENTION = %s, want 3", env["BACKUP_RETENTION"].Value)
+	}
+	passwordRef := env["PGPASSWORD"].ValueFrom.SecretKeyRef
+	if passwordRef.Name != "test-immich-postgres-credentials" || passwordRef.Key != "password" {
+		t.Errorf("PGPASSWORD should come from the generated credentials, got %s/%s", passwordRef.Name, passwordRef.Key)
+	}
+
+	script := container.Command[2]
+	for _, substr := range []string{"pg_dump --clean", "gzip", "rm -f"} {
+		if !strings.Contains(script, substr) {
+			t.Errorf("dump script should contain %q:\n%s", substr, script)
+		}
+	}
+}
+
+func TestReconcilePostgresBackup_S3(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:14")
+	t.Setenv(mediav1alpha1.EnvRelatedImageBackupS3, "aws-cli:2")
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Postgres: &mediav1alpha1.PostgresSpec{
+				Backup: &mediav1alpha1.PostgresBackupSpec{
+					Enabled:     ptr.To(true),
+					Method:      ptr.To("pg_dumpall"),
+					Compression: ptr.To("none"),
+					S3: &mediav1alpha1.BackupS3Spec{
+						Bucket:                   "backups",
+						Prefix:                   ptr.To("/immich/"),
+						Endpoint:                 ptr.To("https://minio.example.com"),
+						AccessKeyIDSecretRef:     mediav1alpha1.SecretKeySelector{Name: "s3", Key: "access-key"},
+						SecretAccessKeySecretRef: mediav1alpha1.SecretKeySelector{Name: "s3", Key: "secret-key"},
+					},
+				},
+			},
+		},
+	}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+
+	if err := r.reconcilePostgresBackup(ctx, immich); err != nil {
+		t.Fatalf("reconcilePostgresBackup() error = %v", err)
+	}
+
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, pvcs); err != nil {
+		t.Fatalf("failed to list PVCs: %v", err)
+	}
+	if len(pvcs.Items) != 0 {
+		t.Errorf("no backup PVC should be created for S3 backups, got %d", len(pvcs.Items))
+	}
+
+	cronJob := applied["CronJob/test-immich-postgres-backup"].(*batchv1.CronJob)
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 1 || len(podSpec.Containers) != 1 {
+		t.Fatalf("expected a dump init container and an upload container, got %d init containers and %d containers",
+			len(podSpec.InitContainers), len(podSpec.Containers))
+	}
+	if podSpec.Volumes[0].EmptyDir == nil {
+		t.Errorf("backups volume should be an emptyDir, got %+v", podSpec.Volumes[0])
+	}
+
+	dumpScript := podSpec.InitContainers[0].Command[2]
+	if !strings.Contains(dumpScript, "pg_dumpall") {
+		t.Errorf("dump script should use pg_dumpall:\n%s", dumpScript)
+	}
+	if strings.Contains(dumpScript, "gzip") || strings.Contains(dumpScript, "rm -f") {
+		t.Errorf("dump script should neither compress nor prune local dumps:\n%s", dumpScript)
+	}
+
+	upload := podSpec.Containers[0]
+	if upload.Image != "aws-cli:2" {
+		t.Errorf("upload image = %s, want aws-cli:2", upload.Image)
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, e := range upload.Env {
+		env[e.Name] = e
+	}
+	if env["S3_PREFIX"].Value != "immich/" {
+		t.Errorf("S3_PREFIX = %s, want immich/", env["S3_PREFIX"].Value)
+	}
+	if env["AWS_ENDPOINT_URL"].Value != "https://minio.example.com" {
+		t.Errorf("AWS_ENDPOINT_URL = %s, want https://minio.example.com", env["AWS_ENDPOINT_URL"].Value)
+	}
+	if env["AWS_SECRET_ACCESS_KEY"].ValueFrom.SecretKeyRef.Key != "secret-key" {
+		t.Error("AWS_SECRET_ACCESS_KEY should come from the referenced secret")
+	}
+}
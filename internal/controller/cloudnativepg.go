@@ -0,0 +1,251 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// CloudNativePGClusterGVK is the GroupVersionKind for CloudNativePG Clusters
+var CloudNativePGClusterGVK = schema.GroupVersionKind{
+	Group:   "postgresql.cnpg.io",
+	Version: "v1",
+	Kind:    "Cluster",
+}
+
+// IsCloudNativePGAPIAvailable checks if the CloudNativePG Cluster API is available in the cluster
+func (r *ImmichReconciler) IsCloudNativePGAPIAvailable() bool {
+	r.cloudNativePGAPICheckMutex.Lock()
+	defer r.cloudNativePGAPICheckMutex.Unlock()
+
+	// Return cached result if already checked
+	if r.cloudNativePGAPIChecked {
+		return r.cloudNativePGAPIAvailable
+	}
+
+	// Check if Cluster API is available
+	if r.DiscoveryClient != nil {
+		resources, err := r.DiscoveryClient.ServerResourcesForGroupVersion(CloudNativePGClusterGVK.GroupVersion().String())
+		if err == nil {
+			for _, resource := range resources.APIResources {
+				if resource.Kind == CloudNativePGClusterGVK.Kind {
+					r.cloudNativePGAPIChecked = true
+					r.cloudNativePGAPIAvailable = true
+					return true
+				}
+			}
+		}
+	}
+
+	r.cloudNativePGAPIChecked = true
+	r.cloudNativePGAPIAvailable = false
+	return false
+}
+
+// getCloudNativePGClusterName returns the name of the CloudNativePG Cluster.
+// CloudNativePG derives the names of its Services (<name>-rw) and Secrets (<name>-app) from it.
+func getCloudNativePGClusterName(immich *mediav1alpha1.Immich) string {
+	return fmt.Sprintf("%s-postgres", immich.Name)
+}
+
+// getCloudNativePGAppSecretName returns the name of the Secret holding the credentials of the Immich database user,
+// generated by CloudNativePG
+func getCloudNativePGAppSecretName(immich *mediav1alpha1.Immich) string {
+	return getCloudNativePGClusterName(immich) + "-app"
+}
+
+// reconcileCloudNativePG creates or updates the CloudNativePG Cluster running the Immich database, using server-side apply.
+// The Cluster has no owner reference, so that deleting the Immich resource does not delete the database,
+// like the data PVC of the built-in PostgreSQL.
+func (r *ImmichReconciler) reconcileCloudNativePG(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+	log.V(1).Info("Reconciling CloudNativePG Cluster")
+
+	if !r.IsCloudNativePGAPIAvailable() {
+		return fmt.Errorf("the CloudNativePG Cluster API (%s) is not available: install the CloudNativePG operator or set spec.postgres.provider=builtin",
+			CloudNativePGClusterGVK.GroupVersion())
+	}
+
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+	labels := r.getLabels(immich, "postgres")
+
+	// The Immich user owns the database but is not a superuser: the vector extensions are created at bootstrap
+	var postInitSQL []interface{}
+	for _, extension := range immich.GetCloudNativePGExtensions() {
+		postInitSQL = append(postInitSQL, fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %q CASCADE;", extension))
+	}
+	var preloadLibraries []interface{}
+	for _, library := range immich.GetCloudNativePGSharedPreloadLibraries() {
+		preloadLibraries = append(preloadLibraries, library)
+	}
+
+	size := immich.GetPostgresSize()
+	storage := map[string]interface{}{
+		"size": size.String(),
+	}
+	if persistence := postgresSpec.Persistence; persistence != nil && persistence.StorageClass != nil {
+		storage["storageClass"] = *persistence.StorageClass
+	}
+
+	spec := map[string]interface{}{
+		"instances": int64(immich.GetCloudNativePGInstances()),
+		"imageName": immich.GetPostgresImage(),
+		"postgresql": map[string]interface{}{
+			"shared_preload_libraries": preloadLibraries,
+		},
+		"bootstrap": map[string]interface{}{
+			"initdb": map[string]interface{}{
+				"database":               immich.GetPostgresDatabase(),
+				"owner":                  immich.GetPostgresUsername(),
+				"postInitApplicationSQL": postInitSQL,
+			},
+		},
+		"storage": storage,
+	}
+	if postgresSpec.ImagePullPolicy != "" {
+		spec["imagePullPolicy"] = string(postgresSpec.ImagePullPolicy)
+	}
+	if len(immich.Spec.ImagePullSecrets) > 0 {
+		var pullSecrets []interface{}
+		for _, secret := range immich.Spec.ImagePullSecrets {
+			pullSecrets = append(pullSecrets, map[string]interface{}{"name": secret.Name})
+		}
+		spec["imagePullSecrets"] = pullSecrets
+	}
+	if len(postgresSpec.Resources.Limits) > 0 || len(postgresSpec.Resources.Requests) > 0 {
+		resources, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&postgresSpec.Resources)
+		if err != nil {
+			return fmt.Errorf("failed to convert PostgreSQL resources: %w", err)
+		}
+		spec["resources"] = resources
+	}
+
+	// Scheduling constraints are set on the Cluster affinity, CloudNativePG does not take a pod affinity
+	affinity := map[string]interface{}{}
+	if len(postgresSpec.NodeSelector) > 0 {
+		nodeSelector := make(map[string]interface{}, len(postgresSpec.NodeSelector))
+		for k, v := range postgresSpec.NodeSelector {
+			nodeSelector[k] = v
+		}
+		affinity["nodeSelector"] = nodeSelector
+	}
+	if len(postgresSpec.Tolerations) > 0 {
+		var tolerations []interface{}
+		for i := range postgresSpec.Tolerations {
+			toleration, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&postgresSpec.Tolerations[i])
+			if err != nil {
+				return fmt.Errorf("failed to convert PostgreSQL tolerations: %w", err)
+			}
+			tolerations = append(tolerations, toleration)
+		}
+		affinity["tolerations"] = tolerations
+	}
+	if len(affinity) > 0 {
+		spec["affinity"] = affinity
+	}
+
+	// CloudNativePG labels the pods of a Cluster with its name
+	if constraints := getTopologySpreadConstraints(postgresSpec.TopologySpreadConstraints,
+		map[string]string{"cnpg.io/cluster": getCloudNativePGClusterName(immich)}); len(constraints) > 0 {
+		var topologySpreadConstraints []interface{}
+		for i := range constraints {
+			constraint, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&constraints[i])
+			if err != nil {
+				return fmt.Errorf("failed to convert PostgreSQL topology spread constraints: %w", err)
+			}
+			topologySpreadConstraints = append(topologySpreadConstraints, constraint)
+		}
+		spec["topologySpreadConstraints"] = topologySpreadConstraints
+	}
+
+	metadataLabels := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		metadataLabels[k] = v
+	}
+
+	// Build the Cluster object as unstructured since we don't want to import CloudNativePG types
+	cluster := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": CloudNativePGClusterGVK.GroupVersion().String(),
+		"kind":       CloudNativePGClusterGVK.Kind,
+		"metadata": map[string]interface{}{
+			"name":      getCloudNativePGClusterName(immich),
+			"namespace": immich.Namespace,
+			"labels":    metadataLabels,
+		},
+		"spec": spec,
+	}}
+
+	return r.apply(ctx, cluster)
+}
+
+// updateCloudNativePGStatus reports the status of the CloudNativePG Cluster.
+// PostgreSQL is ready once CloudNativePG reports the Cluster as Ready.
+func (r *ImmichReconciler) updateCloudNativePGStatus(ctx context.Context, immich *mediav1alpha1.Immich, previous mediav1alpha1.ComponentsStatus, components *mediav1alpha1.ComponentsStatus) error {
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(CloudNativePGClusterGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: getCloudNativePGClusterName(immich), Namespace: immich.Namespace}, cluster); err != nil {
+		if !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return err
+		}
+		immich.Status.PostgresReady = false
+		components.Postgres = getComponentStatus(previous.Postgres, "", 0, 0, false)
+		return nil
+	}
+
+	instances, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "instances")
+	readyInstances, _, _ := unstructured.NestedInt64(cluster.Object, "status", "readyInstances")
+	image, _, _ := unstructured.NestedString(cluster.Object, "spec", "imageName")
+
+	ready := false
+	conditions, _, _ := unstructured.NestedSlice(cluster.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Ready" {
+			ready = condition["status"] == "True"
+		}
+	}
+
+	immich.Status.PostgresReady = ready
+	components.Postgres = getComponentStatus(previous.Postgres, image, int32(instances), int32(readyInstances), ready)
+	return nil
+}
+
+// immichForCloudNativePGCluster maps a CloudNativePG Cluster to the Immich instance it was created for,
+// so that the status follows the Cluster
+func immichForCloudNativePGCluster(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	if labels[labelManagedBy] != "immich-operator" || labels[labelInstance] == "" {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: labels[labelInstance], Namespace: obj.GetNamespace()}},
+	}
+}
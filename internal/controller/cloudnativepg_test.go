@@ -0,0 +1,162 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func newCloudNativePGDiscovery(available bool) *fakediscovery.FakeDiscovery {
+	resources := []*metav1.APIResourceList{}
+	if available {
+		resources = append(resources, &metav1.APIResourceList{
+			GroupVersion: "postgresql.cnpg.io/v1",
+			APIResources: []metav1.APIResource{{Name: "clusters", Kind: "Cluster"}},
+		})
+	}
+	return &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: resources}}
+}
+
+func TestReconcileCloudNativePG(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "ghcr.io/tensorchord/cloudnative-vectorchord:16-0.4.3")
+
+	immich := newTestImmich(withCloudNativePG())
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{
+		Client:          newApplyCapturingClient(t, applied),
+		DiscoveryClient: newCloudNativePGDiscovery(true),
+	}
+
+	if err := r.reconcileCloudNativePG(ctx, immich); err != nil {
+		t.Fatalf("reconcileCloudNativePG() error = %v", err)
+	}
+
+	cluster := applied["Cluster/test-immich-postgres"].(*unstructured.Unstructured)
+	if len(cluster.GetOwnerReferences()) != 0 {
+		t.Error("the Cluster should not be deleted with the Immich resource")
+	}
+	if cluster.GetLabels()[labelInstance] != "test-immich" {
+		t.Errorf("labels = %v", cluster.GetLabels())
+	}
+	instances, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "instances")
+	image, _, _ := unstructured.NestedString(cluster.Object, "spec", "imageName")
+	size, _, _ := unstructured.NestedString(cluster.Object, "spec", "storage", "size")
+	if instances != 3 || image != "ghcr.io/tensorchord/cloudnative-vectorchord:16-0.4.3" || size != "10Gi" {
+		t.Errorf("instances = %d, imageName = %s, storage size = %s", instances, image, size)
+	}
+	libraries, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "postgresql", "shared_preload_libraries")
+	if !reflect.DeepEqual(libraries, []interface{}{"vchord.so"}) {
+		t.Errorf("shared_preload_libraries = %v", libraries)
+	}
+	initdb, _, _ := unstructured.NestedMap(cluster.Object, "spec", "bootstrap", "initdb")
+	if initdb["database"] != "immich" || initdb["owner"] != "immich" {
+		t.Errorf("initdb = %v", initdb)
+	}
+	if sql := initdb["postInitApplicationSQL"].([]interface{}); len(sql) != 2 || sql[0] != `CREATE EXTENSION IF NOT EXISTS "vchord" CASCADE;` {
+		t.Errorf("postInitApplicationSQL = %v", sql)
+	}
+
+	// The server connects with the credentials generated by CloudNativePG, through the read-write Service
+	env := r.getServerEnv(immich)
+	if dbURL := findEnv(env, "DB_URL"); dbURL == nil || dbURL.ValueFrom.SecretKeyRef.Name != "test-immich-postgres-app" ||
+		dbURL.ValueFrom.SecretKeyRef.Key != "uri" {
+		t.Errorf("DB_URL = %+v, want it from the app Secret", dbURL)
+	}
+	if findEnv(env, "DB_HOSTNAME") != nil || findEnv(env, "DB_PASSWORD") != nil {
+		t.Error("DB_HOSTNAME and DB_PASSWORD should not be set with CloudNativePG")
+	}
+	initContainers := r.getServerInitContainers(immich)
+	if host := findEnv(initContainers[0].Env, "PGHOST"); host == nil || host.Value != "test-immich-postgres-rw" ||
+		initContainers[0].Image != "ghcr.io/tensorchord/cloudnative-vectorchord:16-0.4.3" {
+		t.Errorf("wait-for-postgres = %+v, want pg_isready against the read-write Service", initContainers[0])
+	}
+}
+
+func TestReconcileCloudNativePG_APINotAvailable(t *testing.T) {
+	r := &ImmichReconciler{
+		Client:          newApplyCapturingClient(t, map[string]client.Object{}),
+		DiscoveryClient: newCloudNativePGDiscovery(false),
+	}
+	err := r.reconcileCloudNativePG(context.Background(), newTestImmich(withCloudNativePG()))
+	if err == nil || !strings.Contains(err.Error(), "install the CloudNativePG operator") {
+		t.Errorf("reconcileCloudNativePG() error = %v", err)
+	}
+}
+
+func TestUpdateCloudNativePGStatus(t *testing.T) {
+	ctx := context.Background()
+
+	newCluster := func(ready string, readyInstances int64) *unstructured.Unstructured {
+		cluster := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"instances": int64(3),
+				"imageName": "vectorchord:16",
+			},
+			"status": map[string]interface{}{
+				"readyInstances": readyInstances,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": ready},
+				},
+			},
+		}}
+		cluster.SetGroupVersionKind(CloudNativePGClusterGVK)
+		cluster.SetName("test-immich-postgres")
+		cluster.SetNamespace("default")
+		return cluster
+	}
+
+	tests := []struct {
+		name      string
+		cluster   *unstructured.Unstructured
+		wantReady bool
+	}{
+		{name: "cluster ready", cluster: newCluster("True", 3), wantReady: true},
+		{name: "failing over", cluster: newCluster("False", 2), wantReady: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := newTestImmich(withCloudNativePG())
+			r := &ImmichReconciler{
+				Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(tt.cluster).Build(),
+			}
+			components := &mediav1alpha1.ComponentsStatus{}
+			if err := r.updateCloudNativePGStatus(ctx, immich, mediav1alpha1.ComponentsStatus{}, components); err != nil {
+				t.Fatalf("updateCloudNativePGStatus() error = %v", err)
+			}
+			if immich.Status.PostgresReady != tt.wantReady || components.Postgres.Ready != tt.wantReady {
+				t.Errorf("postgresReady = %v, want %v", immich.Status.PostgresReady, tt.wantReady)
+			}
+			if components.Postgres.Replicas != 3 || components.Postgres.Image != "vectorchord:16" {
+				t.Errorf("postgres = %+v", components.Postgres)
+			}
+		})
+	}
+}
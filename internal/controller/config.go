@@ -18,17 +18,31 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
 	"gopkg.in/yaml.v3"
 )
 
+// configHashAnnotation is set on the server pod template with a hash of the effective
+// Immich configuration, so that any configuration change triggers a rollout.
+const configHashAnnotation = "media.rm3l.org/config-hash"
+
 // reconcileImmichConfig creates or updates the Immich configuration ConfigMap or Secret using server-side apply.
 // It builds a base configuration from CR state and merges it with user-provided configuration.
 func (r *ImmichReconciler) reconcileImmichConfig(ctx context.Context, immich *mediav1alpha1.Immich) error {
@@ -37,8 +51,22 @@ func (r *ImmichReconciler) reconcileImmichConfig(ctx context.Context, immich *me
 
 	configName := fmt.Sprintf("%s-immich-config", immich.Name)
 
-	// Build effective configuration by merging base config with user config
-	effectiveConfig := r.buildEffectiveConfigMap(immich)
+	sources, err := r.getConfigSources(ctx, immich)
+	if err != nil {
+		return err
+	}
+
+	// Inject the values of the referenced Secrets. The configuration is then always stored in a Secret.
+	secretValues, err := r.getConfigSecretValues(ctx, immich)
+	if err != nil {
+		return err
+	}
+	if len(secretValues) > 0 {
+		sources = append(sources, configSource{name: mediav1alpha1.ConfigurationSourceSecrets, config: secretValues})
+	}
+
+	effectiveConfig := mergeConfigSources(sources)
+	immich.Status.ConfigurationSources = getConfigurationSources(effectiveConfig, sources)
 
 	// Convert configuration to YAML
 	configData, err := yaml.Marshal(effectiveConfig)
@@ -107,65 +135,375 @@ func (r *ImmichReconciler) reconcileImmichConfig(ctx context.Context, immich *me
 	return r.apply(ctx, configMap)
 }
 
-// buildEffectiveConfigMap builds the effective Immich configuration as a map.
-// This avoids issues with nil struct fields being marshaled as null.
-// User configuration takes precedence over operator-derived settings.
-func (r *ImmichReconciler) buildEffectiveConfigMap(immich *mediav1alpha1.Immich) map[string]interface{} {
-	config := make(map[string]interface{})
+// configSource is a layer of the generated Immich configuration
+type configSource struct {
+	name   string
+	config map[string]interface{}
+}
+
+// getConfigSources returns the layers of the Immich configuration, in increasing order of precedence:
+// the raw configuration, the settings derived by the operator, then the typed configuration.
+// The values of the Secrets referenced by the typed configuration are not part of them, as they are hashed separately.
+func (r *ImmichReconciler) getConfigSources(ctx context.Context, immich *mediav1alpha1.Immich) ([]configSource, error) {
+	var sources []configSource
+
+	rawConfig, err := r.getRawConfigMap(ctx, immich)
+	if err != nil {
+		return nil, err
+	}
+	if rawConfig != nil {
+		sources = append(sources, configSource{name: mediav1alpha1.ConfigurationSourceRaw, config: rawConfig})
+	}
 
-	// First, apply operator-derived ML settings
-	r.applyMLConfigMap(immich, config)
+	operatorConfig := make(map[string]interface{})
+	r.applyMLConfigMap(immich, operatorConfig)
+	sources = append(sources, configSource{name: mediav1alpha1.ConfigurationSourceOperator, config: operatorConfig})
 
-	// Then, merge user-provided configuration (user takes precedence)
 	immichConfig := ptr.Deref(immich.Spec.Immich, mediav1alpha1.ImmichConfig{})
 	if immichConfig.Configuration != nil {
-		userConfig := r.configSpecToMap(immichConfig.Configuration)
-		config = r.deepMergeMap(config, userConfig)
+		sources = append(sources, configSource{
+			name:   mediav1alpha1.ConfigurationSourceConfiguration,
+			config: r.configSpecToMap(immichConfig.Configuration),
+		})
 	}
 
+	return sources, nil
+}
+
+// buildEffectiveConfigMap builds the effective Immich configuration as a map.
+// This avoids issues with nil struct fields being marshaled as null.
+// The typed configuration takes precedence over operator-derived settings, which take precedence over the raw configuration.
+func (r *ImmichReconciler) buildEffectiveConfigMap(ctx context.Context, immich *mediav1alpha1.Immich) (map[string]interface{}, error) {
+	sources, err := r.getConfigSources(ctx, immich)
+	if err != nil {
+		return nil, err
+	}
+	return mergeConfigSources(sources), nil
+}
+
+// mergeConfigSources deep-merges the configuration sources, each one overriding the previous ones
+func mergeConfigSources(sources []configSource) map[string]interface{} {
+	config := make(map[string]interface{})
+	for _, source := range sources {
+		config = deepMergeMap(config, source.config)
+	}
 	return config
 }
 
+// getRawConfigMap returns the raw configuration passed through to the Immich config file, or nil if there is none
+func (r *ImmichReconciler) getRawConfigMap(ctx context.Context, immich *mediav1alpha1.Immich) (map[string]interface{}, error) {
+	raw := ptr.Deref(immich.Spec.Immich, mediav1alpha1.ImmichConfig{}).RawConfiguration
+	if raw == nil {
+		return nil, nil
+	}
+
+	var data string
+	switch {
+	case raw.Inline != nil:
+		data = string(raw.Inline.Raw)
+	case raw.ConfigMapRef != nil:
+		configMap := &corev1.ConfigMap{}
+		if err := r.Get(ctx, types.NamespacedName{Name: raw.ConfigMapRef.Name, Namespace: immich.Namespace}, configMap); err != nil {
+			return nil, fmt.Errorf("failed to get ConfigMap %s holding the raw Immich configuration: %w", raw.ConfigMapRef.Name, err)
+		}
+		value, ok := configMap.Data[raw.ConfigMapRef.Key]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found in ConfigMap %s holding the raw Immich configuration",
+				raw.ConfigMapRef.Key, raw.ConfigMapRef.Name)
+		}
+		data = value
+	case raw.SecretRef != nil:
+		value, err := r.getConfigSecretValue(ctx, immich, *raw.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		data = value
+	default:
+		return nil, nil
+	}
+
+	// JSON is valid YAML
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		return nil, fmt.Errorf("invalid raw Immich configuration: %w", err)
+	}
+	removeNullValues(config)
+	return config, nil
+}
+
+// getConfigurationSources attributes each key of the effective configuration
+// to the source of highest precedence setting it
+func getConfigurationSources(config map[string]interface{}, sources []configSource) []mediav1alpha1.ConfigurationSourceStatus {
+	keys := make(map[string][]string)
+	for _, path := range getConfigKeyPaths(config, nil) {
+		for i := len(sources) - 1; i >= 0; i-- {
+			if _, ok := getConfigEntry(sources[i].config, path...); ok {
+				keys[sources[i].name] = append(keys[sources[i].name], strings.Join(path, "."))
+				break
+			}
+		}
+	}
+
+	var result []mediav1alpha1.ConfigurationSourceStatus
+	for _, source := range sources {
+		if len(keys[source.name]) > 0 {
+			result = append(result, mediav1alpha1.ConfigurationSourceStatus{Source: source.name, Keys: keys[source.name]})
+		}
+	}
+	return result
+}
+
+// getConfigKeyPaths returns the paths of the leaf keys of a configuration map, sorted
+func getConfigKeyPaths(config map[string]interface{}, prefix []string) [][]string {
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var paths [][]string
+	for _, key := range keys {
+		path := append(append([]string{}, prefix...), key)
+		if child, ok := config[key].(map[string]interface{}); ok && len(child) > 0 {
+			paths = append(paths, getConfigKeyPaths(child, path)...)
+		} else {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
 // applyMLConfigMap applies machine learning configuration based on CR state.
 // Follows the Immich config structure: https://docs.immich.app/install/config-file/
 func (r *ImmichReconciler) applyMLConfigMap(immich *mediav1alpha1.Immich, config map[string]interface{}) {
-	mlSpec := ptr.Deref(immich.Spec.MachineLearning, mediav1alpha1.MachineLearningSpec{})
-
-	// Get the ML URL (built-in service URL, external URL, or empty if disabled)
-	mlURL := immich.GetMachineLearningURL()
-
-	// Determine if ML should be enabled
-	// ML is enabled if: built-in is enabled OR external URL is provided
-	mlEnabled := immich.IsMachineLearningEnabled() || (mlSpec.URL != nil && *mlSpec.URL != "")
+	// Get the URLs of the ML backends in priority order (built-in services, external URLs, or none if disabled)
+	// Immich sends requests to the first URL and fails over to the next ones
+	mlURLs := immich.GetMachineLearningURLs()
 
 	// Build ML config map with only non-empty values
 	// Note: Immich uses "urls" (array) not "url" (string)
 	mlConfig := map[string]interface{}{
-		"enabled": mlEnabled,
+		"enabled": len(mlURLs) > 0,
 	}
-	if mlURL != "" {
-		mlConfig["urls"] = []string{mlURL}
+	if len(mlURLs) > 0 {
+		mlConfig["urls"] = mlURLs
 	}
 
 	config["machineLearning"] = mlConfig
 }
 
-// configSpecToMap converts a ConfigurationSpec to a map, excluding nil fields.
+// configNumberPaths are the settings exposed as strings in the API, as the CRD avoids floating-point numbers,
+// that Immich expects as numbers
+var configNumberPaths = [][]string{
+	{"machineLearning", "duplicateDetection", "maxDistance"},
+	{"machineLearning", "facialRecognition", "minScore"},
+	{"machineLearning", "facialRecognition", "maxDistance"},
+}
+
+// configSpecToMap converts a ConfigurationSpec to a map keyed by the Immich configuration keys, excluding nil fields.
 func (r *ImmichReconciler) configSpecToMap(spec *mediav1alpha1.ConfigurationSpec) map[string]interface{} {
-	// Marshal to YAML then unmarshal to map to get a clean representation
-	// This automatically handles omitempty and excludes nil fields
-	data, err := yaml.Marshal(spec)
+	// Round-trip through JSON, as the API field names follow the Immich configuration keys.
+	// omitempty excludes nil fields.
+	data, err := json.Marshal(spec)
 	if err != nil {
 		return make(map[string]interface{})
 	}
 
 	var result map[string]interface{}
-	if err := yaml.Unmarshal(data, &result); err != nil {
+	if err := json.Unmarshal(data, &result); err != nil {
 		return make(map[string]interface{})
 	}
 
 	// Remove any null values that might have slipped through
 	removeNullValues(result)
 
+	for _, path := range configNumberPaths {
+		if value, ok := getConfigValue(result, path...).(string); ok {
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				setConfigValue(result, number, path...)
+			}
+		}
+	}
+
+	// Deprecated settings Immich no longer knows of
+	if ffmpeg, ok := getConfigValue(result, "ffmpeg").(map[string]interface{}); ok {
+		delete(ffmpeg, "npl")
+	}
+
+	// Secret references are not Immich settings: their values are injected by getConfigSecretValues
+	if smtp, ok := getConfigValue(result, "notifications", "smtp", "transport").(map[string]interface{}); ok {
+		delete(smtp, "passwordSecretRef")
+	}
+	if oauth, ok := getConfigValue(result, "oauth").(map[string]interface{}); ok {
+		delete(oauth, "clientSecretRef")
+	}
+
 	return result
 }
+
+// getConfigSecretValues reads the Secrets referenced by the typed Immich configuration
+// and returns their values at the corresponding paths of the Immich config file.
+func (r *ImmichReconciler) getConfigSecretValues(ctx context.Context, immich *mediav1alpha1.Immich) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	configuration := ptr.Deref(immich.Spec.Immich, mediav1alpha1.ImmichConfig{}).Configuration
+	if configuration == nil {
+		return config, nil
+	}
+
+	if configuration.Notifications != nil && configuration.Notifications.SMTP != nil &&
+		configuration.Notifications.SMTP.Transport != nil && configuration.Notifications.SMTP.Transport.PasswordSecretRef != nil {
+		password, err := r.getConfigSecretValue(ctx, immich, *configuration.Notifications.SMTP.Transport.PasswordSecretRef)
+		if err != nil {
+			return nil, err
+		}
+		setConfigValue(config, password, "notifications", "smtp", "transport", "password")
+	}
+
+	if configuration.OAuth != nil && configuration.OAuth.ClientSecretRef != nil {
+		clientSecret, err := r.getConfigSecretValue(ctx, immich, *configuration.OAuth.ClientSecretRef)
+		if err != nil {
+			return nil, err
+		}
+		setConfigValue(config, clientSecret, "oauth", "clientSecret")
+	}
+
+	return config, nil
+}
+
+// getConfigSecretValue returns the value of a Secret key referenced by the Immich configuration
+func (r *ImmichReconciler) getConfigSecretValue(ctx context.Context, immich *mediav1alpha1.Immich, ref mediav1alpha1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: immich.Namespace}, secret); err != nil {
+		return "", fmt.Errorf("failed to get Secret %s referenced by the Immich configuration: %w", ref.Name, err)
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in Secret %s referenced by the Immich configuration", ref.Key, ref.Name)
+	}
+	return string(value), nil
+}
+
+// getConfigValue returns the value at the given path of a configuration map, or nil if there is none
+func getConfigValue(config map[string]interface{}, path ...string) interface{} {
+	value, _ := getConfigEntry(config, path...)
+	return value
+}
+
+// getConfigEntry returns the value at the given path of a configuration map, and whether the path exists
+func getConfigEntry(config map[string]interface{}, path ...string) (interface{}, bool) {
+	var value interface{} = config
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setConfigValue sets the value at the given path of a configuration map, creating intermediate maps as needed
+func setConfigValue(config map[string]interface{}, value interface{}, path ...string) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := config[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			config[key] = next
+		}
+		config = next
+	}
+	config[path[len(path)-1]] = value
+}
+
+// immichesForConfigConfigMap maps a ConfigMap to the Immich instances whose raw configuration is stored in it
+func (r *ImmichReconciler) immichesForConfigConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.immichesReferencing(ctx, obj, func(immich *mediav1alpha1.Immich) bool {
+		raw := ptr.Deref(immich.Spec.Immich, mediav1alpha1.ImmichConfig{}).RawConfiguration
+		return raw != nil && raw.ConfigMapRef != nil && raw.ConfigMapRef.Name == obj.GetName()
+	})
+}
+
+// immichesReferencing returns a request for each Immich instance in the namespace of obj that references it
+func (r *ImmichReconciler) immichesReferencing(ctx context.Context, obj client.Object, references func(*mediav1alpha1.Immich) bool) []reconcile.Request {
+	immiches := &mediav1alpha1.ImmichList{}
+	if err := r.List(ctx, immiches, client.InNamespace(obj.GetNamespace())); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list Immich instances referencing object", "name", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range immiches.Items {
+		immich := &immiches.Items[i]
+		if references(immich) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: immich.Name, Namespace: immich.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// computeConfigHash returns a hash of the effective Immich configuration and of the
+// Secrets it references. The hash changes whenever the generated config file or any
+// referenced secret value changes.
+func (r *ImmichReconciler) computeConfigHash(ctx context.Context, immich *mediav1alpha1.Immich) (string, error) {
+	effectiveConfig, err := r.buildEffectiveConfigMap(ctx, immich)
+	if err != nil {
+		return "", err
+	}
+	configData, err := yaml.Marshal(effectiveConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal immich configuration: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(immich.GetConfigurationKind()))
+	hash.Write(configData)
+
+	for _, ref := range getConfigSecretRefs(immich) {
+		hash.Write([]byte(ref.Name + "/" + ref.Key + "="))
+
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: immich.Namespace}, secret)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return "", err
+			}
+			// A missing secret is hashed as such, so that its creation triggers a rollout
+			continue
+		}
+		hash.Write(secret.Data[ref.Key])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getConfigSecretRefs returns the Secret references used by the Immich configuration,
+// sorted by name and key so that hashing is deterministic.
+func getConfigSecretRefs(immich *mediav1alpha1.Immich) []mediav1alpha1.SecretKeySelector {
+	immichConfig := ptr.Deref(immich.Spec.Immich, mediav1alpha1.ImmichConfig{})
+
+	var refs []mediav1alpha1.SecretKeySelector
+	if immichConfig.RawConfiguration != nil && immichConfig.RawConfiguration.SecretRef != nil {
+		refs = append(refs, *immichConfig.RawConfiguration.SecretRef)
+	}
+
+	configuration := ptr.Deref(immichConfig.Configuration, mediav1alpha1.ConfigurationSpec{})
+	if configuration.Notifications != nil && configuration.Notifications.SMTP != nil &&
+		configuration.Notifications.SMTP.Transport != nil && configuration.Notifications.SMTP.Transport.PasswordSecretRef != nil {
+		refs = append(refs, *configuration.Notifications.SMTP.Transport.PasswordSecretRef)
+	}
+	if configuration.OAuth != nil && configuration.OAuth.ClientSecretRef != nil {
+		refs = append(refs, *configuration.OAuth.ClientSecretRef)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Name != refs[j].Name {
+			return refs[i].Name < refs[j].Name
+		}
+		return refs[i].Key < refs[j].Key
+	})
+	return refs
+}
@@ -0,0 +1,652 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+	"gopkg.in/yaml.v3"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatalf("failed to add client-go scheme: %v", err)
+	}
+	if err := mediav1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("failed to add media scheme: %v", err)
+	}
+	return s
+}
+
+// immichOption customizes the Immich built by newTestImmich
+type immichOption func(*mediav1alpha1.Immich)
+
+// newTestImmich returns the test-immich resource of the default namespace, with the given options applied in order
+func newTestImmich(opts ...immichOption) *mediav1alpha1.Immich {
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+	}
+	for _, opt := range opts {
+		opt(immich)
+	}
+	return immich
+}
+
+// withCloudNativePG runs the database as a CloudNativePG cluster of 3 instances
+func withCloudNativePG() immichOption {
+	return func(immich *mediav1alpha1.Immich) {
+		immich.Spec.Postgres = &mediav1alpha1.PostgresSpec{
+			Provider:      ptr.To(mediav1alpha1.PostgresProviderCloudNativePG),
+			CloudNativePG: &mediav1alpha1.CloudNativePGSpec{Instances: ptr.To(int32(3))},
+		}
+	}
+}
+
+// withExternalDatabase connects to db.example.com, with the password in the db Secret
+func withExternalDatabase() immichOption {
+	return func(immich *mediav1alpha1.Immich) {
+		immich.Spec.Postgres = &mediav1alpha1.PostgresSpec{
+			Enabled:           ptr.To(false),
+			Host:              ptr.To("db.example.com"),
+			PasswordSecretRef: &mediav1alpha1.SecretKeySelector{Name: "db", Key: "password"},
+		}
+	}
+}
+
+// withValkeySentinel runs Valkey in sentinel mode
+func withValkeySentinel() immichOption {
+	return func(immich *mediav1alpha1.Immich) {
+		immich.Spec.Valkey = &mediav1alpha1.ValkeySpec{Mode: ptr.To(mediav1alpha1.ValkeyModeSentinel)}
+	}
+}
+
+// withSplitWorkers runs the microservices worker apart from the API, on other nodes, with the metrics enabled
+func withSplitWorkers() immichOption {
+	return func(immich *mediav1alpha1.Immich) {
+		immichConfig(immich).Metrics = &mediav1alpha1.MetricsSpec{Enabled: ptr.To(true)}
+		server := serverSpec(immich)
+		server.Replicas = ptr.To(int32(2))
+		server.NodeSelector = map[string]string{"pool": "web"}
+		server.Workers = &mediav1alpha1.ServerWorkersSpec{
+			Mode: ptr.To(mediav1alpha1.ServerWorkersModeSplit),
+			Microservices: &mediav1alpha1.ServerWorkerSpec{
+				Replicas:     ptr.To(int32(1)),
+				NodeSelector: map[string]string{"pool": "batch"},
+				Resources: &corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+				},
+			},
+		}
+	}
+}
+
+// withHardwareTranscoding sets the FFmpeg acceleration of the Immich configuration and the server override
+func withHardwareTranscoding(accel string, override *mediav1alpha1.HardwareTranscodingSpec) immichOption {
+	return func(immich *mediav1alpha1.Immich) {
+		immichConfig(immich).Configuration = &mediav1alpha1.ConfigurationSpec{
+			FFmpeg: &mediav1alpha1.FFmpegConfig{Accel: ptr.To(accel)},
+		}
+		serverSpec(immich).HardwareTranscoding = override
+	}
+}
+
+// withMachineLearningBackends declares a CUDA backend, a remote one and a CPU one, in priority order
+func withMachineLearningBackends() immichOption {
+	return func(immich *mediav1alpha1.Immich) {
+		immich.Spec.MachineLearning = &mediav1alpha1.MachineLearningSpec{
+			Env: []corev1.EnvVar{{Name: "MACHINE_LEARNING_WORKERS", Value: "1"}},
+			Backends: []mediav1alpha1.MachineLearningBackendSpec{
+				{
+					Name:         "gpu",
+					Acceleration: ptr.To(mediav1alpha1.MachineLearningAccelerationCUDA),
+					Replicas:     ptr.To(int32(2)),
+					NodeSelector: map[string]string{"pool": "gpu"},
+				},
+				{Name: "desktop", URL: ptr.To("http://desktop-gpu.lan:3003")},
+				{Name: "cpu"},
+			},
+		}
+	}
+}
+
+// immichConfig returns spec.immich, initializing it when unset
+func immichConfig(immich *mediav1alpha1.Immich) *mediav1alpha1.ImmichConfig {
+	if immich.Spec.Immich == nil {
+		immich.Spec.Immich = &mediav1alpha1.ImmichConfig{}
+	}
+	return immich.Spec.Immich
+}
+
+// serverSpec returns spec.server, initializing it when unset
+func serverSpec(immich *mediav1alpha1.Immich) *mediav1alpha1.ServerSpec {
+	if immich.Spec.Server == nil {
+		immich.Spec.Server = &mediav1alpha1.ServerSpec{}
+	}
+	return immich.Spec.Server
+}
+
+func TestComputeConfigHash(t *testing.T) {
+	ctx := context.Background()
+
+	newImmich := func(trashDays int) *mediav1alpha1.Immich {
+		return &mediav1alpha1.Immich{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+			Spec: mediav1alpha1.ImmichSpec{
+				Immich: &mediav1alpha1.ImmichConfig{
+					Configuration: &mediav1alpha1.ConfigurationSpec{
+						Trash: &mediav1alpha1.TrashConfig{Days: ptr.To(trashDays)},
+						OAuth: &mediav1alpha1.OAuthConfig{
+							Enabled:         ptr.To(true),
+							ClientSecretRef: &mediav1alpha1.SecretKeySelector{Name: "oauth", Key: "client-secret"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	oauthSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "oauth", Namespace: "default"},
+		Data:       map[string][]byte{"client-secret": []byte("s3cr3t")},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(oauthSecret).Build()
+	r := &ImmichReconciler{Client: c}
+
+	hash1, err := r.computeConfigHash(ctx, newImmich(30))
+	if err != nil {
+		t.Fatalf("computeConfigHash() error = %v", err)
+	}
+	if hash1 == "" {
+		t.Fatal("computeConfigHash() returned an empty hash")
+	}
+
+	hash2, err := r.computeConfigHash(ctx, newImmich(30))
+	if err != nil {
+		t.Fatalf("computeConfigHash() error = %v", err)
+	}
+	if hash1 != hash2 {
+		t.Errorf("computeConfigHash() is not stable: %s != %s", hash1, hash2)
+	}
+
+	hash3, err := r.computeConfigHash(ctx, newImmich(60))
+	if err != nil {
+		t.Fatalf("computeConfigHash() error = %v", err)
+	}
+	if hash1 == hash3 {
+		t.Error("computeConfigHash() did not change after a configuration change")
+	}
+
+	// Rotating the referenced secret must change the hash
+	oauthSecret.Data["client-secret"] = []byte("rotated")
+	if err := c.Update(ctx, oauthSecret); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	hash4, err := r.computeConfigHash(ctx, newImmich(30))
+	if err != nil {
+		t.Fatalf("computeConfigHash() error = %v", err)
+	}
+	if hash1 == hash4 {
+		t.Error("computeConfigHash() did not change after a referenced secret change")
+	}
+}
+
+func TestComputeConfigHash_MissingSecret(t *testing.T) {
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Immich: &mediav1alpha1.ImmichConfig{
+				Configuration: &mediav1alpha1.ConfigurationSpec{
+					OAuth: &mediav1alpha1.OAuthConfig{
+						ClientSecretRef: &mediav1alpha1.SecretKeySelector{Name: "missing", Key: "client-secret"},
+					},
+				},
+			},
+		},
+	}
+
+	r := &ImmichReconciler{Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build()}
+	if _, err := r.computeConfigHash(context.Background(), immich); err != nil {
+		t.Errorf("computeConfigHash() with missing secret error = %v, want nil", err)
+	}
+}
+
+func TestReconcileImmichConfig_SecretRefs(t *testing.T) {
+	ctx := context.Background()
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Immich: &mediav1alpha1.ImmichConfig{
+				ConfigurationKind: ptr.To("ConfigMap"),
+				Configuration: &mediav1alpha1.ConfigurationSpec{
+					Notifications: &mediav1alpha1.NotificationsConfig{
+						SMTP: &mediav1alpha1.SMTPConfig{
+							Enabled: ptr.To(true),
+							Transport: &mediav1alpha1.SMTPTransportConfig{
+								Host:              ptr.To("smtp.example.com"),
+								PasswordSecretRef: &mediav1alpha1.SecretKeySelector{Name: "smtp", Key: "password"},
+							},
+						},
+					},
+					OAuth: &mediav1alpha1.OAuthConfig{
+						Enabled:         ptr.To(true),
+						ClientSecretRef: &mediav1alpha1.SecretKeySelector{Name: "oauth", Key: "client-secret"},
+					},
+				},
+			},
+		},
+	}
+
+	secrets := []client.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "smtp", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("smtp-pass")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "oauth", Namespace: "default"},
+			Data:       map[string][]byte{"client-secret": []byte("oauth-secret")},
+		},
+	}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied, secrets...)}
+
+	if err := r.reconcileImmichConfig(ctx, immich); err != nil {
+		t.Fatalf("reconcileImmichConfig() error = %v", err)
+	}
+
+	if _, ok := applied["ConfigMap/test-immich-immich-config"]; ok {
+		t.Fatal("a configuration with secret values must not be stored in a ConfigMap")
+	}
+	obj, ok := applied["Secret/test-immich-immich-config"]
+	if !ok {
+		t.Fatal("configuration Secret should have been applied")
+	}
+
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(obj.(*corev1.Secret).StringData["immich-config.yaml"]), &config); err != nil {
+		t.Fatalf("invalid configuration: %v", err)
+	}
+	if got := getConfigValue(config, "notifications", "smtp", "transport", "password"); got != "smtp-pass" {
+		t.Errorf("notifications.smtp.transport.password = %v, want smtp-pass", got)
+	}
+	if got := getConfigValue(config, "notifications", "smtp", "transport", "host"); got != "smtp.example.com" {
+		t.Errorf("notifications.smtp.transport.host = %v, want smtp.example.com", got)
+	}
+	if got := getConfigValue(config, "oauth", "clientSecret"); got != "oauth-secret" {
+		t.Errorf("oauth.clientSecret = %v, want oauth-secret", got)
+	}
+	for _, path := range [][]string{
+		{"notifications", "smtp", "transport", "passwordSecretRef"},
+		{"oauth", "clientSecretRef"},
+	} {
+		if got := getConfigValue(config, path...); got != nil {
+			t.Errorf("%v should not be rendered, got %v", path, got)
+		}
+	}
+
+	// A missing key fails the reconciliation rather than rendering an incomplete configuration
+	immich.Spec.Immich.Configuration.OAuth.ClientSecretRef.Key = "missing"
+	if err := r.reconcileImmichConfig(ctx, immich); err == nil {
+		t.Error("reconcileImmichConfig() with a missing secret key should fail")
+	}
+
+	// Rotating a referenced Secret reconciles the Immich instances referencing it
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(immich, &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+	}).Build()
+	r = &ImmichReconciler{Client: c}
+	requests := r.immichesForSecret(ctx, secrets[0])
+	if len(requests) != 1 || requests[0].Name != "test-immich" {
+		t.Errorf("requests = %v, want test-immich only", requests)
+	}
+}
+
+func TestImmichesForSecret(t *testing.T) {
+	ctx := context.Background()
+	// The same Secret holds the OAuth client secret and the Valkey password
+	immich := newTestImmich(withValkeySentinel())
+	immich.Spec.Valkey.PasswordSecretRef = &mediav1alpha1.SecretKeySelector{Name: "shared", Key: "valkey"}
+	immich.Spec.Immich = &mediav1alpha1.ImmichConfig{
+		Configuration: &mediav1alpha1.ConfigurationSpec{
+			OAuth: &mediav1alpha1.OAuthConfig{ClientSecretRef: &mediav1alpha1.SecretKeySelector{Name: "shared", Key: "oauth"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(immich, &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+	}).Build()
+	r := &ImmichReconciler{Client: c}
+
+	shared := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "default"}}
+	if requests := r.immichesForSecret(ctx, shared); len(requests) != 1 || requests[0].Name != "test-immich" {
+		t.Errorf("requests = %v, want test-immich once", requests)
+	}
+	unrelated := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"}}
+	if requests := r.immichesForSecret(ctx, unrelated); len(requests) != 0 {
+		t.Errorf("requests = %v, want none", requests)
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// fullConfigurationSpec returns a ConfigurationSpec with every field set to a non-default value
+func fullConfigurationSpec() *mediav1alpha1.ConfigurationSpec {
+	concurrency := func(n int) *mediav1alpha1.JobConcurrency {
+		return &mediav1alpha1.JobConcurrency{Concurrency: ptr.To(n)}
+	}
+	return &mediav1alpha1.ConfigurationSpec{
+		Backup: &mediav1alpha1.BackupConfig{
+			Database: &mediav1alpha1.DatabaseBackupConfig{
+				Enabled:        ptr.To(true),
+				CronExpression: ptr.To("0 1 * * *"),
+				KeepLastAmount: ptr.To(7),
+			},
+		},
+		Trash: &mediav1alpha1.TrashConfig{Enabled: ptr.To(false), Days: ptr.To(60)},
+		StorageTemplate: &mediav1alpha1.StorageTemplateConfig{
+			Enabled:  ptr.To(true),
+			Template: ptr.To("{{y}}/{{MM}}/{{filename}}"),
+		},
+		FFmpeg: &mediav1alpha1.FFmpegConfig{
+			CRF:                 ptr.To(28),
+			Threads:             ptr.To(4),
+			Preset:              ptr.To("medium"),
+			TargetCodec:         ptr.To("hevc"),
+			AcceptedAudioCodecs: []string{"aac", "libopus"},
+			TargetResolution:    ptr.To("1080"),
+			MaxBitrate:          ptr.To("5000k"),
+			Bframes:             ptr.To(3),
+			Refs:                ptr.To(2),
+			GOPSize:             ptr.To(60),
+			NPL:                 ptr.To(100),
+			TemporalAQ:          ptr.To(true),
+			CQMode:              ptr.To("cqp"),
+			TwoPass:             ptr.To(true),
+			PreferredHwDevice:   ptr.To("/dev/dri/renderD128"),
+			TranscodePolicy:     ptr.To("optimal"),
+			ToneMappingMode:     ptr.To("mobius"),
+			Accel:               ptr.To("vaapi"),
+			AccelDecode:         ptr.To(true),
+		},
+		Job: &mediav1alpha1.JobConfig{
+			BackgroundTask:      concurrency(1),
+			SmartSearch:         concurrency(2),
+			MetadataExtraction:  concurrency(3),
+			Search:              concurrency(4),
+			FaceDetection:       concurrency(5),
+			Sidecar:             concurrency(6),
+			Library:             concurrency(7),
+			Migration:           concurrency(8),
+			ThumbnailGeneration: concurrency(9),
+			VideoConversion:     concurrency(10),
+			Notifications:       concurrency(11),
+		},
+		Library: &mediav1alpha1.LibraryConfig{
+			Scan:  &mediav1alpha1.LibraryScanConfig{Enabled: ptr.To(false), CronExpression: ptr.To("0 3 * * *")},
+			Watch: &mediav1alpha1.LibraryWatchConfig{Enabled: ptr.To(true)},
+		},
+		Logging: &mediav1alpha1.LoggingConfig{Enabled: ptr.To(true), Level: ptr.To("debug")},
+		MachineLearning: &mediav1alpha1.MachineLearningConfig{
+			Enabled: ptr.To(true),
+			URLs:    []string{"http://ml.example.com:3003"},
+			Clip:    &mediav1alpha1.ClipConfig{Enabled: ptr.To(true), ModelName: ptr.To("ViT-B-16-SigLIP__webli")},
+			DuplicateDetection: &mediav1alpha1.DuplicateDetectionConfig{
+				Enabled:     ptr.To(false),
+				MaxDistance: ptr.To("0.02"),
+			},
+			FacialRecognition: &mediav1alpha1.FacialRecognitionConfig{
+				Enabled:     ptr.To(true),
+				ModelName:   ptr.To("antelopev2"),
+				MinScore:    ptr.To("0.8"),
+				MaxDistance: ptr.To("0.4"),
+				MinFaces:    ptr.To(5),
+			},
+		},
+		Map: &mediav1alpha1.MapConfig{
+			Enabled:    ptr.To(false),
+			LightStyle: ptr.To("https://tiles.example.com/light.json"),
+			DarkStyle:  ptr.To("https://tiles.example.com/dark.json"),
+		},
+		NewVersionCheck: &mediav1alpha1.NewVersionCheckConfig{Enabled: ptr.To(false)},
+		Notifications: &mediav1alpha1.NotificationsConfig{
+			SMTP: &mediav1alpha1.SMTPConfig{
+				Enabled: ptr.To(true),
+				From:    ptr.To("Immich <photos@example.com>"),
+				ReplyTo: ptr.To("admin@example.com"),
+				Transport: &mediav1alpha1.SMTPTransportConfig{
+					Host:              ptr.To("smtp.example.com"),
+					Port:              ptr.To(465),
+					Username:          ptr.To("photos"),
+					PasswordSecretRef: &mediav1alpha1.SecretKeySelector{Name: "smtp", Key: "password"},
+					IgnoreCert:        ptr.To(true),
+				},
+			},
+		},
+		OAuth: &mediav1alpha1.OAuthConfig{
+			Enabled:               ptr.To(true),
+			IssuerURL:             ptr.To("https://sso.example.com/realms/photos"),
+			ClientID:              ptr.To("immich"),
+			ClientSecretRef:       &mediav1alpha1.SecretKeySelector{Name: "oauth", Key: "client-secret"},
+			Scope:                 ptr.To("openid email profile groups"),
+			StorageLabel:          ptr.To("username"),
+			StorageQuota:          ptr.To("quota"),
+			DefaultStorageQuota:   ptr.To(int64(100)),
+			ButtonText:            ptr.To("Login with SSO"),
+			AutoRegister:          ptr.To(false),
+			AutoLaunch:            ptr.To(true),
+			MobileOverrideEnabled: ptr.To(true),
+			MobileRedirectURI:     ptr.To("https://photos.example.com/api/oauth/mobile-redirect"),
+		},
+		PasswordLogin:    &mediav1alpha1.PasswordLoginConfig{Enabled: ptr.To(false)},
+		ReverseGeocoding: &mediav1alpha1.ReverseGeocodingConfig{Enabled: ptr.To(false)},
+		Server: &mediav1alpha1.ServerConfig{
+			ExternalDomain:   ptr.To("https://photos.example.com"),
+			LoginPageMessage: ptr.To("Welcome"),
+		},
+		Theme: &mediav1alpha1.ThemeConfig{CustomCSS: ptr.To("body { color: red; }")},
+		User:  &mediav1alpha1.UserConfig{DeleteDelay: ptr.To(14)},
+	}
+}
+
+// assertAllFieldsSet fails the test for every nil or empty field of v, recursively,
+// so that fields added to the API are covered by the golden files
+func assertAllFieldsSet(t *testing.T, path string, v reflect.Value) {
+	t.Helper()
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			t.Errorf("%s is not set", path)
+			return
+		}
+		assertAllFieldsSet(t, path, v.Elem())
+	case reflect.Slice:
+		if v.Len() == 0 {
+			t.Errorf("%s is not set", path)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			assertAllFieldsSet(t, path+"."+v.Type().Field(i).Name, v.Field(i))
+		}
+	}
+}
+
+// assertMatchesSchema fails the test for every rendered key that is not part of the Immich configuration
+// schema, or whose value does not have the type of the setting in the schema
+func assertMatchesSchema(t *testing.T, path string, value, schema interface{}) {
+	t.Helper()
+	if reflect.TypeOf(value) != reflect.TypeOf(schema) {
+		t.Errorf("%s is a %T, Immich expects a %T", path, value, schema)
+		return
+	}
+	rendered, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key, child := range rendered {
+		schemaChild, ok := schema.(map[string]interface{})[key]
+		if !ok {
+			t.Errorf("%s.%s is not an Immich setting", path, key)
+			continue
+		}
+		assertMatchesSchema(t, path+"."+key, child, schemaChild)
+	}
+}
+
+func TestConfigSpecToMap_Golden(t *testing.T) {
+	spec := fullConfigurationSpec()
+	assertAllFieldsSet(t, "configuration", reflect.ValueOf(spec))
+
+	rendered := (&ImmichReconciler{}).configSpecToMap(spec)
+
+	data, err := os.ReadFile(filepath.Join("testdata", "immich-config.json"))
+	if err != nil {
+		t.Fatalf("failed to read the Immich configuration schema: %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("invalid Immich configuration schema: %v", err)
+	}
+	assertMatchesSchema(t, "configuration", rendered, schema)
+
+	sections := reflect.TypeOf(*spec)
+	for i := 0; i < sections.NumField(); i++ {
+		key, _, _ := strings.Cut(sections.Field(i).Tag.Get("json"), ",")
+		t.Run(key, func(t *testing.T) {
+			got, err := yaml.Marshal(rendered[key])
+			if err != nil {
+				t.Fatalf("failed to marshal %s: %v", key, err)
+			}
+
+			golden := filepath.Join("testdata", "config", key+".yaml")
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatalf("failed to update %s: %v", golden, err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", golden, err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("rendered %s differs from %s:\n--- got\n%s--- want\n%s", key, golden, got, want)
+			}
+		})
+	}
+}
+
+func TestReconcileImmichConfig_RawConfiguration(t *testing.T) {
+	ctx := context.Background()
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Immich: &mediav1alpha1.ImmichConfig{
+				RawConfiguration: &mediav1alpha1.RawConfigurationSpec{
+					ConfigMapRef: &mediav1alpha1.ConfigMapKeySelector{Name: "immich-raw", Key: "config.yaml"},
+				},
+				Configuration: &mediav1alpha1.ConfigurationSpec{
+					FFmpeg: &mediav1alpha1.FFmpegConfig{CRF: ptr.To(30)},
+				},
+			},
+		},
+	}
+
+	raw := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "immich-raw", Namespace: "default"},
+		Data: map[string]string{"config.yaml": `
+ffmpeg:
+  crf: 23
+  preset: fast
+machineLearning:
+  urls: ["http://elsewhere:3003"]
+server:
+  publicUsers: false
+`},
+	}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied, raw)}
+
+	if err := r.reconcileImmichConfig(ctx, immich); err != nil {
+		t.Fatalf("reconcileImmichConfig() error = %v", err)
+	}
+
+	obj, ok := applied["ConfigMap/test-immich-immich-config"]
+	if !ok {
+		t.Fatal("configuration ConfigMap should have been applied")
+	}
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(obj.(*corev1.ConfigMap).Data["immich-config.yaml"]), &config); err != nil {
+		t.Fatalf("invalid configuration: %v", err)
+	}
+
+	tests := []struct {
+		path []string
+		want interface{}
+	}{
+		{[]string{"server", "publicUsers"}, false},
+		{[]string{"ffmpeg", "preset"}, "fast"},
+		{[]string{"ffmpeg", "crf"}, 30},
+	}
+	for _, tt := range tests {
+		if got := getConfigValue(config, tt.path...); got != tt.want {
+			t.Errorf("%v = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	urls, _ := getConfigValue(config, "machineLearning", "urls").([]interface{})
+	if len(urls) != 1 || urls[0] != "http://test-immich-machine-learning:3003" {
+		t.Errorf("machineLearning.urls = %v, want the built-in machine learning URL", urls)
+	}
+
+	want := []mediav1alpha1.ConfigurationSourceStatus{
+		{Source: mediav1alpha1.ConfigurationSourceRaw, Keys: []string{"ffmpeg.preset", "server.publicUsers"}},
+		{Source: mediav1alpha1.ConfigurationSourceOperator, Keys: []string{"machineLearning.enabled", "machineLearning.urls"}},
+		{Source: mediav1alpha1.ConfigurationSourceConfiguration, Keys: []string{"ffmpeg.crf"}},
+	}
+	if !reflect.DeepEqual(immich.Status.ConfigurationSources, want) {
+		t.Errorf("configurationSources = %+v, want %+v", immich.Status.ConfigurationSources, want)
+	}
+
+	// Changing the raw ConfigMap reconciles the Immich instances using it
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(immich, &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+	}).Build()
+	requests := (&ImmichReconciler{Client: c}).immichesForConfigConfigMap(ctx, raw)
+	if len(requests) != 1 || requests[0].Name != "test-immich" {
+		t.Errorf("requests = %v, want test-immich only", requests)
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+const (
+	// databaseBackupSourceDir is the folder of the library volume where Immich writes its database dumps
+	databaseBackupSourceDir = "/data/backups"
+
+	// databaseBackupCopyDir is the directory where the copy PVC is mounted in the backup copy pods
+	databaseBackupCopyDir = "/backup-copy"
+)
+
+// reconcileBackupCopy creates or updates the CronJob copying Immich database dumps out of the library volume, and its PVC
+func (r *ImmichReconciler) reconcileBackupCopy(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+	log.V(1).Info("Reconciling database backup copy")
+
+	// Create copy PVC (must be created before the CronJob)
+	if immich.ShouldCreateBackupCopyPVC() {
+		if err := r.reconcileBackupCopyPVC(ctx, immich); err != nil {
+			return err
+		}
+	}
+
+	return r.reconcileBackupCopyCronJob(ctx, immich)
+}
+
+// reconcileBackupCopyPVC creates the PVC holding the copies of Immich database dumps if needed.
+// Note: Backup PVCs do NOT have an owner reference, so that dumps survive the Immich CR.
+func (r *ImmichReconciler) reconcileBackupCopyPVC(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+
+	name := immich.GetBackupCopyPVCName()
+	labels := r.getLabels(immich, "backup-copy")
+
+	// Check if PVC already exists - PVCs are mostly immutable
+	existing := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, existing)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	persistence := ptr.Deref(immich.Spec.Immich.BackupCopy.Persistence, mediav1alpha1.BackupPersistenceSpec{})
+
+	size := resource.MustParse("10Gi")
+	if persistence.Size != nil && !persistence.Size.IsZero() {
+		size = *persistence.Size
+	}
+
+	accessModes := persistence.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      accessModes,
+			StorageClassName: persistence.StorageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+
+	log.Info("Creating database backup copy PVC (no owner reference for data safety)", "name", name, "size", size.String())
+	if err := r.Create(ctx, pvc); err != nil {
+		return err
+	}
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonPVCCreated, "Created PersistentVolumeClaim %s", pvc.Name)
+	return nil
+}
+
+// reconcileBackupCopyCronJob creates or updates the backup copy CronJob using server-side apply.
+// The library volume is mounted read-only. When it cannot be mounted from several nodes,
+// the pods are scheduled on the node running the server.
+func (r *ImmichReconciler) reconcileBackupCopyCronJob(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	name := fmt.Sprintf("%s-backup-copy", immich.Name)
+	labels := r.getLabels(immich, "backup-copy")
+
+	copySpec := ptr.Deref(immich.Spec.Immich.BackupCopy, mediav1alpha1.BackupCopySpec{})
+	serverSpec := ptr.Deref(immich.Spec.Server, mediav1alpha1.ServerSpec{})
+
+	image := immich.GetBackupCopyImage()
+	if image == "" {
+		return fmt.Errorf("backup copy image not configured: set spec.immich.backupCopy.image, spec.server.image or %s environment variable", mediav1alpha1.EnvRelatedImageImmich)
+	}
+
+	var affinity *corev1.Affinity
+	if immich.IsServerEnabled() && !isLibrarySharedAcrossNodes(immich) {
+		affinity = &corev1.Affinity{
+			PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+					{
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: r.getSelectorLabels(immich, getServerHTTPWorkload(immich).component),
+						},
+						TopologyKey: corev1.LabelHostname,
+					},
+				},
+			},
+		}
+	}
+
+	cronJob := &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+			Kind:       "CronJob",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   ptr.Deref(copySpec.Schedule, "0 4 * * *"),
+			Suspend:                    copySpec.Suspend,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: ptr.To(int32(3)),
+			FailedJobsHistoryLimit:     ptr.To(int32(1)),
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: ptr.To(int32(2)),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy:    corev1.RestartPolicyNever,
+							ImagePullSecrets: immich.Spec.ImagePullSecrets,
+							SecurityContext:  serverSpec.PodSecurityContext,
+							NodeSelector:     serverSpec.NodeSelector,
+							Tolerations:      serverSpec.Tolerations,
+							Affinity:         affinity,
+							Containers: []corev1.Container{
+								{
+									Name:            "copy",
+									Image:           image,
+									ImagePullPolicy: serverSpec.ImagePullPolicy,
+									Command:         []string{"/bin/sh", "-c", backupCopyScript},
+									Env: []corev1.EnvVar{
+										{Name: "SOURCE_DIR", Value: databaseBackupSourceDir},
+										{Name: "BACKUP_DIR", Value: databaseBackupCopyDir},
+										{Name: "BACKUP_RETENTION", Value: fmt.Sprintf("%d", ptr.Deref(copySpec.Retention, 7))},
+									},
+									Resources:       copySpec.Resources,
+									SecurityContext: serverSpec.SecurityContext,
+									VolumeMounts: []corev1.VolumeMount{
+										{Name: "library", MountPath: "/data", ReadOnly: true},
+										{Name: "backup-copy", MountPath: databaseBackupCopyDir},
+									},
+								},
+							},
+							Volumes: []corev1.Volume{
+								{
+									Name: "library",
+									VolumeSource: corev1.VolumeSource{
+										PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+											ClaimName: immich.GetLibraryPVCName(),
+											ReadOnly:  true,
+										},
+									},
+								},
+								{
+									Name: "backup-copy",
+									VolumeSource: corev1.VolumeSource{
+										PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+											ClaimName: immich.GetBackupCopyPVCName(),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return r.apply(ctx, cronJob)
+}
+
+// isLibrarySharedAcrossNodes returns true if the library PVC can be mounted from several nodes
+func isLibrarySharedAcrossNodes(immich *mediav1alpha1.Immich) bool {
+	accessModes := immich.GetLibraryAccessModes()
+	return slices.Contains(accessModes, corev1.ReadWriteMany) || slices.Contains(accessModes, corev1.ReadOnlyMany)
+}
+
+// backupCopyScript copies the newest Immich dump to BACKUP_DIR, then deletes the copies exceeding the retention count.
+// Immich writes dumps under a temporary name first, so only complete dumps match immich-db-backup-*.sql.gz.
+// The name of the newest dump is written as the termination message, to be reported in the status.
+const backupCopyScript = `set -eu
+latest="$(ls -1t "${SOURCE_DIR}" 2>/dev/null | grep '^immich-db-backup-.*\.sql\.gz$' | head -n 1 || true)"
+if [ -z "${latest}" ]; then
+  echo "No database dump found in ${SOURCE_DIR}"
+  exit 0
+fi
+if [ -f "${BACKUP_DIR}/${latest}" ]; then
+  echo "${latest} already copied"
+else
+  echo "Copying ${latest} to ${BACKUP_DIR}"
+  cp -p "${SOURCE_DIR}/${latest}" "${BACKUP_DIR}/.${latest}.partial"
+  mv "${BACKUP_DIR}/.${latest}.partial" "${BACKUP_DIR}/${latest}"
+fi
+ls -1t "${BACKUP_DIR}" | grep '^immich-db-backup-.*\.sql\.gz$' | tail -n +$((BACKUP_RETENTION + 1)) | while read -r old; do
+  echo "Removing old copy ${old}"
+  rm -f "${BACKUP_DIR}/${old}"
+done
+printf '%s' "${latest}" > /dev/termination-log
+`
+
+// getDatabaseBackupStatus returns the newest dump reported by the backup copy pods,
+// or the previous status if no pod reported one since.
+func (r *ImmichReconciler) getDatabaseBackupStatus(ctx context.Context, immich *mediav1alpha1.Immich) (*mediav1alpha1.DatabaseBackupStatus, error) {
+	pods := &corev1.PodList{}
+	if err := r.apiReader().List(ctx, pods, client.InNamespace(immich.Namespace),
+		client.MatchingLabels(r.getSelectorLabels(immich, "backup-copy"))); err != nil {
+		return nil, err
+	}
+
+	result := immich.Status.DatabaseBackup
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if status.Name != "copy" || terminated == nil || terminated.ExitCode != 0 {
+				continue
+			}
+			latestFile := strings.TrimSpace(terminated.Message)
+			if latestFile == "" {
+				continue
+			}
+			if result != nil && result.LastCopyTime != nil && !result.LastCopyTime.Before(&terminated.FinishedAt) {
+				continue
+			}
+			result = &mediav1alpha1.DatabaseBackupStatus{
+				LatestFile:   latestFile,
+				LastCopyTime: ptr.To(terminated.FinishedAt),
+			}
+		}
+	}
+	return result, nil
+}
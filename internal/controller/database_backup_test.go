@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestReconcileBackupCopy(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "immich-server:v1.132.0")
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Immich: &mediav1alpha1.ImmichConfig{
+				BackupCopy: &mediav1alpha1.BackupCopySpec{
+					Enabled:   ptr.To(true),
+					Retention: ptr.To(int32(3)),
+				},
+			},
+		},
+	}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+
+	if err := r.reconcileBackupCopy(ctx, immich); err != nil {
+		t.Fatalf("reconcileBackupCopy() error = %v", err)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "test-immich-backup-copy", Namespace: "default"}, pvc); err != nil {
+		t.Fatalf("backup copy PVC should have been created: %v", err)
+	}
+	if len(pvc.OwnerReferences) != 0 {
+		t.Error("backup copy PVC should not have an owner reference")
+	}
+
+	obj, ok := applied["CronJob/test-immich-backup-copy"]
+	if !ok {
+		t.Fatal("backup copy CronJob should have been applied")
+	}
+	cronJob := obj.(*batchv1.CronJob)
+	if cronJob.Spec.Schedule != "0 4 * * *" {
+		t.Errorf("schedule = %s, want the default schedule", cronJob.Spec.Schedule)
+	}
+
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	container := podSpec.Containers[0]
+	if container.Image != "immich-server:v1.132.0" {
+		t.Errorf("image = %s, want the server image", container.Image)
+	}
+	mounts := map[string]corev1.VolumeMount{}
+	for _, m := range container.VolumeMounts {
+		mounts[m.Name] = m
+	}
+	if m := mounts["library"]; m.MountPath != "/data" || !m.ReadOnly {
+		t.Errorf("library should be mounted read-only at /data, got %+v", m)
+	}
+	claims := map[string]string{}
+	for _, v := range podSpec.Volumes {
+		claims[v.Name] = v.PersistentVolumeClaim.ClaimName
+	}
+	if claims["library"] != "test-immich-library" || claims["backup-copy"] != "test-immich-backup-copy" {
+		t.Errorf("volumes = %v", claims)
+	}
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if env["SOURCE_DIR"] != "/data/backups" || env["BACKUP_RETENTION"] != "3" {
+		t.Errorf("env = %v", env)
+	}
+
+	// A ReadWriteOnce library can only be mounted on the node running the server
+	affinity := podSpec.Affinity
+	if affinity == nil || affinity.PodAffinity == nil ||
+		affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchLabels[labelComponent] != "server" {
+		t.Errorf("copy pods should be co-located with the server, got %+v", affinity)
+	}
+
+	immich.Spec.Immich.Persistence = &mediav1alpha1.PersistenceSpec{
+		Library: &mediav1alpha1.LibraryPersistenceSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+		},
+	}
+	if err := r.reconcileBackupCopy(ctx, immich); err != nil {
+		t.Fatalf("reconcileBackupCopy() error = %v", err)
+	}
+	cronJob = applied["CronJob/test-immich-backup-copy"].(*batchv1.CronJob)
+	if cronJob.Spec.JobTemplate.Spec.Template.Spec.Affinity != nil {
+		t.Error("copy pods should not be co-located with the server when the library is ReadWriteMany")
+	}
+}
+
+func TestGetDatabaseBackupStatus(t *testing.T) {
+	ctx := context.Background()
+
+	immich := &mediav1alpha1.Immich{ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"}}
+
+	now := time.Now().Truncate(time.Second)
+	newPod := func(name, message string, exitCode int32, finishedAt time.Time) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: getLabels(immich, "backup-copy")},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: "copy",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode:   exitCode,
+						Message:    message,
+						FinishedAt: metav1.NewTime(finishedAt),
+					}},
+				}},
+			},
+		}
+	}
+
+	r := &ImmichReconciler{
+		Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(
+			newPod("copy-1", "immich-db-backup-20250101T020000-v1.132.0-pg14.sql.gz", 0, now.Add(-48*time.Hour)),
+			newPod("copy-2", "immich-db-backup-20250102T020000-v1.132.0-pg14.sql.gz", 0, now.Add(-24*time.Hour)),
+			newPod("copy-3", "", 1, now),
+		).Build(),
+	}
+
+	got, err := r.getDatabaseBackupStatus(ctx, immich)
+	if err != nil {
+		t.Fatalf("getDatabaseBackupStatus() error = %v", err)
+	}
+	if got == nil || got.LatestFile != "immich-db-backup-20250102T020000-v1.132.0-pg14.sql.gz" {
+		t.Fatalf("status = %+v, want the newest successful copy", got)
+	}
+	if !got.LastCopyTime.Time.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("lastCopyTime = %v", got.LastCopyTime)
+	}
+
+	// The last known dump is kept once the pods are garbage collected
+	immich.Status.DatabaseBackup = got
+	r.Client = fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build()
+	if got, err = r.getDatabaseBackupStatus(ctx, immich); err != nil || got != immich.Status.DatabaseBackup {
+		t.Errorf("status = %+v, %v, want the last known dump", got, err)
+	}
+}
@@ -0,0 +1,344 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+const (
+	// ConditionTypeDatabaseReady reports whether the external PostgreSQL database passed the preflight check
+	ConditionTypeDatabaseReady = "DatabaseReady"
+
+	// databasePreflightHashAnnotation records the database settings checked by a preflight Job
+	databasePreflightHashAnnotation = "media.rm3l.org/database-preflight-hash"
+
+	// databasePreflightRetryInterval is the delay before a failed preflight check runs again,
+	// e.g. after the missing extension was installed
+	databasePreflightRetryInterval = time.Minute
+)
+
+// reconcileDatabasePreflight checks that the external PostgreSQL database can run Immich, with a Job connecting
+// with the server credentials. The check runs again whenever the database settings or credentials change,
+// and a minute after it failed. The result is reported in the DatabaseReady condition.
+func (r *ImmichReconciler) reconcileDatabasePreflight(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+	log.V(1).Info("Reconciling database preflight check")
+
+	name := getDatabasePreflightJobName(immich)
+
+	hash, err := r.computeDatabasePreflightHash(ctx, immich)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			setDatabaseReadyCondition(immich, metav1.ConditionFalse, "CredentialsNotFound",
+				fmt.Sprintf("Cannot check the database: %v", err))
+			return nil
+		}
+		return err
+	}
+
+	job := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, job)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		changed := job.Annotations[databasePreflightHashAnnotation] != hash
+		retry := isJobFailed(job) && time.Since(getJobFailureTime(job)) >= databasePreflightRetryInterval
+		if !changed && !retry {
+			return r.updateDatabaseReadyCondition(ctx, immich, job)
+		}
+		// The database settings changed, or the failed check is due to run again.
+		// The Job is created again once it is gone.
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		if changed {
+			setDatabaseReadyCondition(immich, metav1.ConditionUnknown, "Checking", "Checking the PostgreSQL database")
+		}
+		return nil
+	}
+
+	image := immich.GetPostgresImage()
+	if image == "" {
+		return fmt.Errorf("database preflight image not configured: set spec.postgres.image or %s environment variable", mediav1alpha1.EnvRelatedImagePostgres)
+	}
+
+	labels := r.getLabels(immich, "database-preflight")
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+
+	// PostgreSQL certificates, referenced by DB_URL
+	volumes, volumeMounts := getPostgresTLSVolumes(immich)
+
+	job = &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				databasePreflightHashAnnotation: hash,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To(int32(0)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					ImagePullSecrets: immich.Spec.ImagePullSecrets,
+					SecurityContext:  postgresSpec.PodSecurityContext,
+					NodeSelector:     postgresSpec.NodeSelector,
+					Tolerations:      postgresSpec.Tolerations,
+					Containers: []corev1.Container{
+						{
+							Name:            "preflight",
+							Image:           image,
+							ImagePullPolicy: postgresSpec.ImagePullPolicy,
+							Command:         []string{"/bin/sh", "-c", databasePreflightScript},
+							Env:             r.getDatabaseEnv(immich),
+							VolumeMounts:    volumeMounts,
+							SecurityContext: postgresSpec.SecurityContext,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	log.Info("Creating database preflight Job", "name", name)
+	if err := r.Create(ctx, job); err != nil {
+		return err
+	}
+	if !meta.IsStatusConditionFalse(immich.Status.Conditions, ConditionTypeDatabaseReady) {
+		setDatabaseReadyCondition(immich, metav1.ConditionUnknown, "Checking", "Checking the PostgreSQL database")
+	}
+	return nil
+}
+
+// updateDatabaseReadyCondition reports the result of a preflight Job in the DatabaseReady condition
+func (r *ImmichReconciler) updateDatabaseReadyCondition(ctx context.Context, immich *mediav1alpha1.Immich, job *batchv1.Job) error {
+	switch {
+	case job.Status.Succeeded > 0:
+		if meta.IsStatusConditionTrue(immich.Status.Conditions, ConditionTypeDatabaseReady) {
+			// Already reported, the pods may be gone since
+			return nil
+		}
+		result, err := r.getDatabasePreflightResult(ctx, job)
+		if err != nil {
+			return err
+		}
+		message := "PostgreSQL database is ready"
+		if result != "" {
+			message = fmt.Sprintf("PostgreSQL database is ready with extension %s", result)
+		}
+		setDatabaseReadyCondition(immich, metav1.ConditionTrue, "Ready", message)
+	case isJobFailed(job):
+		result, err := r.getDatabasePreflightResult(ctx, job)
+		if err != nil {
+			return err
+		}
+		reason, message, found := strings.Cut(result, ": ")
+		if !found || reason == "" || strings.Contains(reason, " ") {
+			reason, message = "CheckFailed", fmt.Sprintf("The database preflight Job %s failed", job.Name)
+		}
+		condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDatabaseReady)
+		if condition == nil || condition.Reason != reason || condition.Message != message {
+			r.recordEvent(immich, corev1.EventTypeWarning, EventReasonDatabaseNotReady, "%s", message)
+		}
+		setDatabaseReadyCondition(immich, metav1.ConditionFalse, reason, message)
+	case !meta.IsStatusConditionFalse(immich.Status.Conditions, ConditionTypeDatabaseReady):
+		// A failed check keeps being reported while it runs again
+		setDatabaseReadyCondition(immich, metav1.ConditionUnknown, "Checking", "Checking the PostgreSQL database")
+	}
+	return nil
+}
+
+// getDatabasePreflightResult returns the termination message of the preflight Job pod
+func (r *ImmichReconciler) getDatabasePreflightResult(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	if err := r.apiReader().List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil && status.State.Terminated.Message != "" {
+				return strings.TrimSpace(status.State.Terminated.Message), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// computeDatabasePreflightHash returns a hash of the database settings and credentials checked by the preflight Job
+func (r *ImmichReconciler) computeDatabasePreflightHash(ctx context.Context, immich *mediav1alpha1.Immich) (string, error) {
+	env := r.getDatabaseEnv(immich)
+
+	// Changing the credentials in place must run the check again
+	var secretData []map[string][]byte
+	for _, e := range env {
+		if e.ValueFrom == nil || e.ValueFrom.SecretKeyRef == nil {
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: e.ValueFrom.SecretKeyRef.Name, Namespace: immich.Namespace}, secret); err != nil {
+			return "", err
+		}
+		secretData = append(secretData, map[string][]byte{e.Name: secret.Data[e.ValueFrom.SecretKeyRef.Key]})
+	}
+
+	// Rotated certificates must be checked too
+	for _, ref := range getTLSSecretRefs(immich.GetPostgresTLS()) {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: immich.Namespace}, secret); err != nil {
+			return "", err
+		}
+		secretData = append(secretData, map[string][]byte{ref.Name + "/" + ref.Key: secret.Data[ref.Key]})
+	}
+
+	data, err := json.Marshal(struct {
+		Image   string
+		Env     []corev1.EnvVar
+		Secrets []map[string][]byte
+	}{immich.GetPostgresImage(), env, secretData})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// isDatabaseReady returns true if the server can be rolled out against the database.
+// The built-in PostgreSQL is always considered ready, as the server waits for it to start.
+func isDatabaseReady(immich *mediav1alpha1.Immich) bool {
+	return immich.IsPostgresEnabled() || meta.IsStatusConditionTrue(immich.Status.Conditions, ConditionTypeDatabaseReady)
+}
+
+// getJobFailureTime returns the time a Job was marked as failed
+func getJobFailureTime(job *batchv1.Job) time.Time {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return time.Time{}
+}
+
+// getDatabasePreflightJobName returns the name of the database preflight Job
+func getDatabasePreflightJobName(immich *mediav1alpha1.Immich) string {
+	return fmt.Sprintf("%s-database-preflight", immich.Name)
+}
+
+// setDatabaseReadyCondition sets the DatabaseReady condition of the Immich resource
+func setDatabaseReadyCondition(immich *mediav1alpha1.Immich, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeDatabaseReady,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// databasePreflightScript connects to the database with the server settings, then checks that the user owns the
+// database and that a supported vector extension is installed, or can be installed by Immich.
+// The result is written as the termination message: "<extension> <version>" on success, "<Reason>: <message>" otherwise.
+// Supported versions follow the Immich documentation: VectorChord 0.3 to 0.5, pgvecto.rs 0.2 to 0.3, pgvector 0.7 and later.
+const databasePreflightScript = `set -u
+export PGCONNECT_TIMEOUT=10
+if [ -z "${DB_URL:-}" ]; then
+  export PGHOST="${DB_HOSTNAME}" PGPORT="${DB_PORT}" PGDATABASE="${DB_DATABASE_NAME}" PGUSER="${DB_USERNAME}" PGPASSWORD="${DB_PASSWORD}"
+fi
+query() {
+  if [ -n "${DB_URL:-}" ]; then
+    psql "${DB_URL}" -XAtq -v ON_ERROR_STOP=1 -c "$1"
+  else
+    psql -XAtq -v ON_ERROR_STOP=1 -c "$1"
+  fi
+}
+fail() {
+  echo "$2" >&2
+  printf '%s: %s' "$1" "$2" > /dev/termination-log
+  exit 1
+}
+# in_range <version> <min> <max>: min <= version < max
+in_range() {
+  [ "$(query "SELECT string_to_array(substring('$1' from '^[0-9.]*[0-9]'), '.')::int[] >= '{$2}'::int[] AND string_to_array(substring('$1' from '^[0-9.]*[0-9]'), '.')::int[] < '{$3}'::int[]")" = "t" ]
+}
+
+if ! out="$(query 'SELECT 1' 2>&1)"; then
+  fail ConnectionFailed "Cannot connect to PostgreSQL: $(echo "${out}" | head -n 1)"
+fi
+
+user="$(query 'SELECT current_user')"
+database="$(query 'SELECT current_database()')"
+superuser="$(query 'SELECT rolsuper FROM pg_roles WHERE rolname = current_user')"
+owner="$(query 'SELECT d.datdba = r.oid FROM pg_database d, pg_roles r WHERE d.datname = current_database() AND r.rolname = current_user')"
+if [ "${superuser}" != "t" ] && [ "${owner}" != "t" ]; then
+  fail InsufficientPrivileges "User ${user} does not own database ${database}: run ALTER DATABASE \"${database}\" OWNER TO \"${user}\""
+fi
+
+for extension in vchord vectors vector; do
+  line="$(query "SELECT coalesce(installed_version, '-') || ' ' || default_version FROM pg_available_extensions WHERE name = '${extension}'")"
+  [ -n "${line}" ] || continue
+  installed="${line%% *}"
+  version="${line##* }"
+  if [ "${installed}" != "-" ]; then
+    version="${installed}"
+  elif [ "${superuser}" != "t" ]; then
+    fail VectorExtensionNotInstalled "Extension ${extension} ${version} is available but not installed, and user ${user} cannot create it: run CREATE EXTENSION ${extension} CASCADE in database ${database} as a superuser"
+  fi
+  case "${extension}" in
+    vchord) in_range "${version}" 0,3 0,6 ;;
+    vectors) in_range "${version}" 0,2 0,4 ;;
+    vector) in_range "${version}" 0,7 1000 ;;
+  esac || fail VectorExtensionUnsupported "Extension ${extension} ${version} is not supported by Immich: install VectorChord 0.3 to 0.5, pgvecto.rs 0.2 to 0.3 or pgvector 0.7 and later"
+  printf '%s %s' "${extension}" "${version}" > /dev/termination-log
+  echo "Found extension ${extension} ${version}"
+  exit 0
+done
+fail VectorExtensionMissing "No vector extension is available in database ${database}: install VectorChord (recommended), pgvecto.rs or pgvector on the PostgreSQL server"
+`
@@ -0,0 +1,233 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// completePreflightJob marks the preflight Job as finished, with a pod reporting the given result
+func completePreflightJob(t *testing.T, c client.Client, succeeded bool, result string) {
+	t.Helper()
+	ctx := context.Background()
+
+	job := &batchv1.Job{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "test-immich-database-preflight", Namespace: "default"}, job); err != nil {
+		t.Fatalf("preflight Job should exist: %v", err)
+	}
+	exitCode := int32(0)
+	if succeeded {
+		job.Status.Succeeded = 1
+	} else {
+		exitCode = 1
+		job.Status.Failed = 1
+		job.Status.Conditions = []batchv1.JobCondition{{
+			Type:               batchv1.JobFailed,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+		}}
+	}
+	if err := c.Status().Update(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "preflight",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: exitCode,
+					Message:  result,
+				}},
+			}},
+		},
+	}
+	if err := c.Create(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcileDatabasePreflight(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+
+	immich := newTestImmich(withExternalDatabase())
+	r := &ImmichReconciler{
+		Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).
+			WithStatusSubresource(&batchv1.Job{}).
+			WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Data:       map[string][]byte{"password": []byte("secret")},
+			}).Build(),
+	}
+
+	if err := r.reconcileDatabasePreflight(ctx, immich); err != nil {
+		t.Fatalf("reconcileDatabasePreflight() error = %v", err)
+	}
+	condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDatabaseReady)
+	if condition == nil || condition.Status != metav1.ConditionUnknown || isDatabaseReady(immich) {
+		t.Fatalf("condition = %+v, want the check in progress", condition)
+	}
+
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "test-immich-database-preflight", Namespace: "default"}, job); err != nil {
+		t.Fatal(err)
+	}
+	container := job.Spec.Template.Spec.Containers[0]
+	if container.Image != "postgres:16" {
+		t.Errorf("image = %s, want the PostgreSQL image", container.Image)
+	}
+	if host := findEnv(container.Env, "DB_HOSTNAME"); host == nil || host.Value != "db.example.com" {
+		t.Errorf("DB_HOSTNAME = %+v", host)
+	}
+	if password := findEnv(container.Env, "DB_PASSWORD"); password == nil || password.ValueFrom.SecretKeyRef.Name != "db" {
+		t.Errorf("DB_PASSWORD = %+v", password)
+	}
+
+	// A missing extension is reported with its remediation
+	completePreflightJob(t, r.Client, false, "VectorExtensionMissing: No vector extension is available in database immich")
+	if err := r.reconcileDatabasePreflight(ctx, immich); err != nil {
+		t.Fatalf("reconcileDatabasePreflight() error = %v", err)
+	}
+	condition = meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDatabaseReady)
+	if condition.Status != metav1.ConditionFalse || condition.Reason != "VectorExtensionMissing" ||
+		condition.Message != "No vector extension is available in database immich" {
+		t.Errorf("condition = %+v", condition)
+	}
+	if isDatabaseReady(immich) {
+		t.Error("the server should not be rolled out")
+	}
+
+	// Changing the password runs the check again
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "db", Namespace: "default"}, secret); err != nil {
+		t.Fatal(err)
+	}
+	secret.Data["password"] = []byte("rotated")
+	if err := r.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcileDatabasePreflight(ctx, immich); err != nil {
+		t.Fatalf("reconcileDatabasePreflight() error = %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "test-immich-database-preflight", Namespace: "default"}, job); err == nil {
+		t.Fatal("the outdated preflight Job should be deleted")
+	}
+	if err := r.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-immich-database-preflight-abcde", Namespace: "default"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcileDatabasePreflight(ctx, immich); err != nil {
+		t.Fatalf("reconcileDatabasePreflight() error = %v", err)
+	}
+
+	completePreflightJob(t, r.Client, true, "vchord 0.4.3")
+	if err := r.reconcileDatabasePreflight(ctx, immich); err != nil {
+		t.Fatalf("reconcileDatabasePreflight() error = %v", err)
+	}
+	condition = meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDatabaseReady)
+	if condition.Status != metav1.ConditionTrue || condition.Message != "PostgreSQL database is ready with extension vchord 0.4.3" {
+		t.Errorf("condition = %+v", condition)
+	}
+	if !isDatabaseReady(immich) {
+		t.Error("the server should be rolled out")
+	}
+}
+
+func TestReconcileDatabasePreflight_RetryAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+
+	immich := newTestImmich(withExternalDatabase())
+	failedAt := metav1.NewTime(time.Now().Add(-2 * databasePreflightRetryInterval))
+	r := &ImmichReconciler{
+		Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).
+			WithStatusSubresource(&batchv1.Job{}).
+			WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Data:       map[string][]byte{"password": []byte("secret")},
+			}).Build(),
+	}
+
+	if err := r.reconcileDatabasePreflight(ctx, immich); err != nil {
+		t.Fatalf("reconcileDatabasePreflight() error = %v", err)
+	}
+	completePreflightJob(t, r.Client, false, "ConnectionFailed: Cannot connect to PostgreSQL")
+	if err := r.reconcileDatabasePreflight(ctx, immich); err != nil {
+		t.Fatalf("reconcileDatabasePreflight() error = %v", err)
+	}
+
+	job := &batchv1.Job{}
+	key := types.NamespacedName{Name: "test-immich-database-preflight", Namespace: "default"}
+	if err := r.Get(ctx, key, job); err != nil {
+		t.Fatal(err)
+	}
+	job.Status.Conditions[0].LastTransitionTime = failedAt
+	if err := r.Status().Update(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.reconcileDatabasePreflight(ctx, immich); err != nil {
+		t.Fatalf("reconcileDatabasePreflight() error = %v", err)
+	}
+	if err := r.Get(ctx, key, job); err == nil {
+		t.Error("the failed preflight Job should be deleted to run the check again")
+	}
+	if condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDatabaseReady); condition.Reason != "ConnectionFailed" {
+		t.Errorf("condition = %+v, want the failure reported until the next result", condition)
+	}
+}
+
+func TestGetDatabasePreflightResult_APIReader(t *testing.T) {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-immich-database-preflight", Namespace: "default"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-immich-database-preflight-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "ok\n"}},
+		}}},
+	}
+
+	// Pods are read from the API server, not from the cache of the client
+	r := &ImmichReconciler{
+		Client:    fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build(),
+		APIReader: fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(pod).Build(),
+	}
+	result, err := r.getDatabasePreflightResult(context.Background(), job)
+	if err != nil || result != "ok" {
+		t.Errorf("getDatabasePreflightResult() = %q, %v, want the termination message read by the API reader", result, err)
+	}
+}
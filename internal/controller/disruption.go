@@ -0,0 +1,253 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// ConditionTypePodDisruptionBudgetsValid reports whether every requested PodDisruptionBudget could be created
+const ConditionTypePodDisruptionBudgetsValid = "PodDisruptionBudgetsValid"
+
+// disruptionTarget is a workload that may be protected by a PodDisruptionBudget
+type disruptionTarget struct {
+	// name of the workload, and of its PodDisruptionBudget
+	name      string
+	component string
+	// replicas is the configured number of replicas, or the minimum with autoscaling
+	replicas int32
+	// stateful workloads keep data on their pods, a budget blocking all their evictions is refused
+	stateful bool
+	budget   *mediav1alpha1.PodDisruptionBudgetSpec
+}
+
+// getDisruptionTargets returns the workloads deployed by the operator for the current spec.
+// A CloudNativePG database is not listed, as CloudNativePG manages the PodDisruptionBudgets of its Cluster.
+func getDisruptionTargets(immich *mediav1alpha1.Immich) []disruptionTarget {
+	var targets []disruptionTarget
+
+	if immich.IsServerEnabled() {
+		var budget *mediav1alpha1.PodDisruptionBudgetSpec
+		if immich.Spec.Server != nil {
+			budget = immich.Spec.Server.PodDisruptionBudget
+		}
+		for _, workload := range getServerWorkloads(immich) {
+			replicas := ptr.Deref(workload.replicas, 1)
+			if workload.autoscaling.IsEnabled() {
+				replicas = ptr.Deref(workload.autoscaling.MinReplicas, 1)
+			}
+			targets = append(targets, disruptionTarget{
+				name: workload.name, component: workload.component, replicas: replicas, budget: budget,
+			})
+		}
+	}
+
+	if immich.IsMachineLearningEnabled() {
+		var budget *mediav1alpha1.PodDisruptionBudgetSpec
+		if immich.Spec.MachineLearning != nil {
+			budget = immich.Spec.MachineLearning.PodDisruptionBudget
+		}
+		for _, workload := range getMachineLearningWorkloads(immich) {
+			replicas := ptr.Deref(workload.replicas, 1)
+			if workload.autoscaling.IsEnabled() {
+				replicas = ptr.Deref(workload.autoscaling.MinReplicas, 1)
+			}
+			targets = append(targets, disruptionTarget{
+				name: workload.name, component: workload.component, replicas: replicas, budget: budget,
+			})
+		}
+	}
+
+	if immich.IsValkeyEnabled() {
+		var budget *mediav1alpha1.PodDisruptionBudgetSpec
+		if immich.Spec.Valkey != nil {
+			budget = immich.Spec.Valkey.PodDisruptionBudget
+		}
+		targets = append(targets, disruptionTarget{
+			name: fmt.Sprintf("%s-valkey", immich.Name), component: "valkey", replicas: immich.GetValkeyReplicas(),
+			stateful: true, budget: budget,
+		})
+		if immich.IsValkeySentinelEnabled() {
+			targets = append(targets, disruptionTarget{
+				name: getValkeySentinelName(immich), component: "valkey-sentinel", replicas: immich.GetValkeySentinelReplicas(),
+				stateful: true, budget: budget,
+			})
+		}
+	}
+
+	if immich.IsPostgresStatefulSetEnabled() {
+		var budget *mediav1alpha1.PodDisruptionBudgetSpec
+		if immich.Spec.Postgres != nil {
+			budget = immich.Spec.Postgres.PodDisruptionBudget
+		}
+		targets = append(targets, disruptionTarget{
+			name: fmt.Sprintf("%s-postgres", immich.Name), component: "postgres", replicas: 1,
+			stateful: true, budget: budget,
+		})
+	}
+
+	return targets
+}
+
+// isEnabled returns true if the workload should have a PodDisruptionBudget:
+// when requested, or by default when it runs more than one replica
+func (t disruptionTarget) isEnabled() bool {
+	if t.budget != nil && t.budget.Enabled != nil {
+		return *t.budget.Enabled
+	}
+	return t.replicas > 1
+}
+
+// getBudget returns the minAvailable and maxUnavailable of the PodDisruptionBudget, defaulting to maxUnavailable=1
+func (t disruptionTarget) getBudget() (*intstr.IntOrString, *intstr.IntOrString) {
+	budget := ptr.Deref(t.budget, mediav1alpha1.PodDisruptionBudgetSpec{})
+	if budget.MinAvailable == nil && budget.MaxUnavailable == nil {
+		return nil, ptr.To(intstr.FromInt32(1))
+	}
+	return budget.MinAvailable, budget.MaxUnavailable
+}
+
+// blocksAllEvictions returns true if the PodDisruptionBudget never allows a pod of the workload to be evicted,
+// which prevents nodes from being drained. Percentages are rounded up, as the disruption controller does.
+func (t disruptionTarget) blocksAllEvictions() bool {
+	minAvailable, maxUnavailable := t.getBudget()
+	replicas := int(t.replicas)
+	if maxUnavailable != nil {
+		value, err := intstr.GetScaledValueFromIntOrPercent(maxUnavailable, replicas, true)
+		return err == nil && value <= 0
+	}
+	value, err := intstr.GetScaledValueFromIntOrPercent(minAvailable, replicas, true)
+	return err == nil && value >= replicas
+}
+
+// getDesiredPodDisruptionBudgets returns the PodDisruptionBudgets to create, and the workloads whose
+// requested PodDisruptionBudget is refused because it would block all evictions of a stateful workload
+func getDesiredPodDisruptionBudgets(immich *mediav1alpha1.Immich) (desired []disruptionTarget, refused []disruptionTarget) {
+	for _, target := range getDisruptionTargets(immich) {
+		if !target.isEnabled() {
+			continue
+		}
+		if target.stateful && target.blocksAllEvictions() {
+			refused = append(refused, target)
+			continue
+		}
+		desired = append(desired, target)
+	}
+	return desired, refused
+}
+
+// reconcilePodDisruptionBudgets creates or updates the PodDisruptionBudgets of the workloads using server-side apply,
+// and reports the refused ones in the PodDisruptionBudgetsValid condition. PodDisruptionBudgets that are no longer
+// desired, including refused ones, are pruned.
+func (r *ImmichReconciler) reconcilePodDisruptionBudgets(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+	log.V(1).Info("Reconciling PodDisruptionBudgets")
+
+	desired, refused := getDesiredPodDisruptionBudgets(immich)
+
+	for _, target := range desired {
+		minAvailable, maxUnavailable := target.getBudget()
+		pdb := &policyv1.PodDisruptionBudget{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: policyv1.SchemeGroupVersion.String(),
+				Kind:       "PodDisruptionBudget",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      target.name,
+				Namespace: immich.Namespace,
+				Labels:    r.getLabels(immich, target.component),
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion:         immich.APIVersion,
+						Kind:               immich.Kind,
+						Name:               immich.Name,
+						UID:                immich.UID,
+						Controller:         ptr.To(true),
+						BlockOwnerDeletion: ptr.To(true),
+					},
+				},
+			},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable:   minAvailable,
+				MaxUnavailable: maxUnavailable,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: r.getSelectorLabels(immich, target.component),
+				},
+			},
+		}
+		if err := r.apply(ctx, pdb); err != nil {
+			return fmt.Errorf("failed to apply PodDisruptionBudget %s: %w", target.name, err)
+		}
+	}
+
+	switch {
+	case len(refused) > 0:
+		var names []string
+		for _, target := range refused {
+			names = append(names, target.name)
+		}
+		log.Info("Refusing PodDisruptionBudgets blocking all evictions", "workloads", names)
+		meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+			Type:   ConditionTypePodDisruptionBudgetsValid,
+			Status: metav1.ConditionFalse,
+			Reason: "BlocksAllEvictions",
+			Message: fmt.Sprintf("The PodDisruptionBudget of %s would block all evictions, preventing nodes from being drained: "+
+				"allow at least one pod to be unavailable", strings.Join(names, ", ")),
+		})
+		r.recordEvent(immich, corev1.EventTypeWarning, EventReasonPodDisruptionBudgetRefused,
+			"Not creating the PodDisruptionBudget of %s: it would block all evictions", strings.Join(names, ", "))
+	case len(desired) > 0:
+		meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+			Type:    ConditionTypePodDisruptionBudgetsValid,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Reconciled",
+			Message: "All PodDisruptionBudgets are created",
+		})
+	default:
+		meta.RemoveStatusCondition(&immich.Status.Conditions, ConditionTypePodDisruptionBudgetsValid)
+	}
+
+	return nil
+}
+
+// getTopologySpreadConstraints returns the topology spread constraints of a component,
+// selecting the pods of the component when a constraint has no label selector
+func getTopologySpreadConstraints(constraints []corev1.TopologySpreadConstraint, selectorLabels map[string]string) []corev1.TopologySpreadConstraint {
+	if len(constraints) == 0 {
+		return nil
+	}
+	result := make([]corev1.TopologySpreadConstraint, len(constraints))
+	for i := range constraints {
+		constraints[i].DeepCopyInto(&result[i])
+		if result[i].LabelSelector == nil {
+			result[i].LabelSelector = &metav1.LabelSelector{MatchLabels: selectorLabels}
+		}
+	}
+	return result
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestReconcilePodDisruptionBudgets(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		spec          mediav1alpha1.ImmichSpec
+		wantPDBs      []string
+		wantCondition metav1.ConditionStatus
+	}{
+		{
+			name:     "single replicas have no budget by default",
+			spec:     mediav1alpha1.ImmichSpec{},
+			wantPDBs: nil,
+		},
+		{
+			name: "budgets by default for multiple replicas",
+			spec: mediav1alpha1.ImmichSpec{
+				Server: &mediav1alpha1.ServerSpec{Replicas: ptr.To(int32(3))},
+				Valkey: &mediav1alpha1.ValkeySpec{Mode: ptr.To(mediav1alpha1.ValkeyModeSentinel)},
+			},
+			wantPDBs:      []string{"test-immich-server", "test-immich-valkey", "test-immich-valkey-sentinel"},
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "budget allowing an eviction of the single PostgreSQL pod",
+			spec: mediav1alpha1.ImmichSpec{
+				Postgres: &mediav1alpha1.PostgresSpec{
+					PodDisruptionBudget: &mediav1alpha1.PodDisruptionBudgetSpec{Enabled: ptr.To(true)},
+				},
+			},
+			wantPDBs:      []string{"test-immich-postgres"},
+			wantCondition: metav1.ConditionTrue,
+		},
+		{
+			name: "budgets blocking all evictions of single-replica stateful components",
+			spec: mediav1alpha1.ImmichSpec{
+				MachineLearning: &mediav1alpha1.MachineLearningSpec{
+					PodDisruptionBudget: &mediav1alpha1.PodDisruptionBudgetSpec{
+						Enabled:      ptr.To(true),
+						MinAvailable: ptr.To(intstr.FromInt32(1)),
+					},
+				},
+				Valkey: &mediav1alpha1.ValkeySpec{
+					PodDisruptionBudget: &mediav1alpha1.PodDisruptionBudgetSpec{
+						Enabled:      ptr.To(true),
+						MinAvailable: ptr.To(intstr.FromString("50%")),
+					},
+				},
+				Postgres: &mediav1alpha1.PostgresSpec{
+					PodDisruptionBudget: &mediav1alpha1.PodDisruptionBudgetSpec{
+						Enabled:        ptr.To(true),
+						MaxUnavailable: ptr.To(intstr.FromInt32(0)),
+					},
+				},
+			},
+			wantPDBs:      []string{"test-immich-machine-learning"},
+			wantCondition: metav1.ConditionFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := &mediav1alpha1.Immich{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+				Spec:       tt.spec,
+			}
+
+			applied := map[string]client.Object{}
+			r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+			if err := r.reconcilePodDisruptionBudgets(ctx, immich); err != nil {
+				t.Fatalf("reconcilePodDisruptionBudgets() error = %v", err)
+			}
+
+			var pdbs []string
+			for _, obj := range applied {
+				pdbs = append(pdbs, obj.GetName())
+			}
+			sort.Strings(pdbs)
+			if !reflect.DeepEqual(pdbs, tt.wantPDBs) {
+				t.Errorf("PodDisruptionBudgets = %v, want %v", pdbs, tt.wantPDBs)
+			}
+
+			condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypePodDisruptionBudgetsValid)
+			if tt.wantCondition == "" {
+				if condition != nil {
+					t.Errorf("condition = %+v, want none", condition)
+				}
+				return
+			}
+			if condition == nil || condition.Status != tt.wantCondition {
+				t.Errorf("condition = %+v, want %s", condition, tt.wantCondition)
+			}
+		})
+	}
+}
+
+func TestReconcilePodDisruptionBudgets_Budget(t *testing.T) {
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Server: &mediav1alpha1.ServerSpec{Replicas: ptr.To(int32(2))},
+			MachineLearning: &mediav1alpha1.MachineLearningSpec{
+				Replicas: ptr.To(int32(4)),
+				PodDisruptionBudget: &mediav1alpha1.PodDisruptionBudgetSpec{
+					MinAvailable: ptr.To(intstr.FromString("50%")),
+				},
+			},
+		},
+	}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+	if err := r.reconcilePodDisruptionBudgets(context.Background(), immich); err != nil {
+		t.Fatalf("reconcilePodDisruptionBudgets() error = %v", err)
+	}
+
+	server := applied["PodDisruptionBudget/test-immich-server"].(*policyv1.PodDisruptionBudget)
+	if server.Spec.MinAvailable != nil || server.Spec.MaxUnavailable.IntValue() != 1 {
+		t.Errorf("server budget = %+v", server.Spec)
+	}
+	if !reflect.DeepEqual(server.Spec.Selector.MatchLabels, r.getSelectorLabels(immich, "server")) {
+		t.Errorf("selector = %v", server.Spec.Selector.MatchLabels)
+	}
+	ml := applied["PodDisruptionBudget/test-immich-machine-learning"].(*policyv1.PodDisruptionBudget)
+	if ml.Spec.MaxUnavailable != nil || ml.Spec.MinAvailable.String() != "50%" {
+		t.Errorf("machine learning budget = %+v", ml.Spec)
+	}
+}
+
+func TestGetTopologySpreadConstraints(t *testing.T) {
+	selectorLabels := map[string]string{labelComponent: "server"}
+	custom := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "custom"}}
+	constraints := []corev1.TopologySpreadConstraint{
+		{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+		{MaxSkew: 1, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.DoNotSchedule, LabelSelector: custom},
+	}
+
+	got := getTopologySpreadConstraints(constraints, selectorLabels)
+	if !reflect.DeepEqual(got[0].LabelSelector.MatchLabels, selectorLabels) {
+		t.Errorf("labelSelector = %+v, want the component pods", got[0].LabelSelector)
+	}
+	if !reflect.DeepEqual(got[1].LabelSelector, custom) {
+		t.Errorf("labelSelector = %+v, want it unchanged", got[1].LabelSelector)
+	}
+	if constraints[0].LabelSelector != nil {
+		t.Error("the spec should not be modified")
+	}
+	if getTopologySpreadConstraints(nil, selectorLabels) != nil {
+		t.Error("no constraints should be set by default")
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// eventRateLimitInterval is the minimum interval between two identical Events on the same Immich resource,
+// so that periodic reconciliations do not repeat the same failure every few minutes
+const eventRateLimitInterval = 30 * time.Minute
+
+// Event reasons
+const (
+	EventReasonCredentialsGenerated   = "CredentialsGenerated"
+	EventReasonPVCCreated             = "PVCCreated"
+	EventReasonImageNotConfigured     = "ImageNotConfigured"
+	EventReasonReconcileFailed        = "ReconcileFailed"
+	EventReasonPruned                 = "Pruned"
+	EventReasonReady                  = "Ready"
+	EventReasonPostgresUpgradeStarted = "PostgresUpgradeStarted"
+	EventReasonUpgradeStarted         = "UpgradeStarted"
+	EventReasonDatabaseNotReady       = "DatabaseNotReady"
+
+	EventReasonPodDisruptionBudgetRefused       = "PodDisruptionBudgetRefused"
+	EventReasonHardwareTranscodingUnschedulable = "HardwareTranscodingUnschedulable"
+
+	// Outcomes of the Immich and PostgreSQL upgrades, also used as reasons of the upgrading conditions
+	EventReasonSnapshotFailed           = "SnapshotFailed"
+	EventReasonUpgradeFailed            = "UpgradeFailed"
+	EventReasonUpgradeCancelled         = "UpgradeCancelled"
+	EventReasonUpgradeSucceeded         = "UpgradeSucceeded"
+	EventReasonRolledBack               = "RolledBack"
+	EventReasonVersionCheckFailed       = "VersionCheckFailed"
+	EventReasonUpToDate                 = "UpToDate"
+	EventReasonUnsupportedVersionChange = "UnsupportedVersionChange"
+	EventReasonUpgradeDisabled          = "UpgradeDisabled"
+	EventReasonDumpFailed               = "DumpFailed"
+	EventReasonMoveDataFailed           = "MoveDataFailed"
+	EventReasonRestoreFailed            = "RestoreFailed"
+	EventReasonRollbackFailed           = "RollbackFailed"
+)
+
+// recordEvent emits an Event on the Immich resource.
+// Events identical to one emitted on the same resource within eventRateLimitInterval are dropped.
+// Nothing is emitted when no Recorder is configured.
+func (r *ImmichReconciler) recordEvent(immich *mediav1alpha1.Immich, eventType, reason, messageFmt string, args ...any) {
+	if r.Recorder == nil {
+		return
+	}
+
+	message := fmt.Sprintf(messageFmt, args...)
+	key := strings.Join([]string{string(immich.UID), immich.Namespace, immich.Name, eventType, reason, message}, "/")
+	now := time.Now()
+
+	r.eventTimesMutex.Lock()
+	if r.eventTimes == nil {
+		r.eventTimes = map[string]time.Time{}
+	}
+	for k, last := range r.eventTimes {
+		if now.Sub(last) >= eventRateLimitInterval {
+			delete(r.eventTimes, k)
+		}
+	}
+	_, recent := r.eventTimes[key]
+	if !recent {
+		r.eventTimes[key] = now
+	}
+	r.eventTimesMutex.Unlock()
+
+	if recent {
+		return
+	}
+	r.Recorder.Event(immich, eventType, reason, message)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// drainEvents returns the Events emitted so far by a fake recorder
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestRecordEvent_RateLimit(t *testing.T) {
+	immich := &mediav1alpha1.Immich{ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default", UID: "uid-1"}}
+	other := &mediav1alpha1.Immich{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "uid-2"}}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &ImmichReconciler{Recorder: recorder}
+
+	r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile %s: %s", "Valkey", "boom")
+	r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile %s: %s", "Valkey", "boom")
+	r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile %s: %s", "Valkey", "timeout")
+	r.recordEvent(other, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile %s: %s", "Valkey", "boom")
+
+	want := []string{
+		"Warning ReconcileFailed Failed to reconcile Valkey: boom",
+		"Warning ReconcileFailed Failed to reconcile Valkey: timeout",
+		"Warning ReconcileFailed Failed to reconcile Valkey: boom",
+	}
+	got := drainEvents(recorder)
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// Identical Events are emitted again once the interval has elapsed
+	r.eventTimesMutex.Lock()
+	for k := range r.eventTimes {
+		r.eventTimes[k] = time.Now().Add(-eventRateLimitInterval)
+	}
+	r.eventTimesMutex.Unlock()
+
+	r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile %s: %s", "Valkey", "boom")
+	if got := drainEvents(recorder); len(got) != 1 {
+		t.Errorf("events = %v, want the Event emitted again", got)
+	}
+
+	// Without a recorder, nothing is emitted
+	(&ImmichReconciler{}).recordEvent(immich, corev1.EventTypeNormal, EventReasonReady, "ready")
+}
+
+func TestReconcilePostgresCredentials_Event(t *testing.T) {
+	ctx := context.Background()
+
+	immich := &mediav1alpha1.Immich{ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"}}
+	recorder := record.NewFakeRecorder(10)
+	r := &ImmichReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build(),
+		Recorder: recorder,
+	}
+
+	for range 2 {
+		if err := r.reconcilePostgresCredentials(ctx, immich); err != nil {
+			t.Fatalf("reconcilePostgresCredentials() error = %v", err)
+		}
+	}
+
+	got := drainEvents(recorder)
+	want := "Normal CredentialsGenerated Generated PostgreSQL credentials in Secret test-immich-postgres-credentials"
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("events = %v, want [%s]", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// immichServerPort is the port of the Immich server API, exposed by the server Service
+const immichServerPort = 2283
+
+// getServerURL returns the in-cluster URL of the Immich server Service
+func getServerURL(immich *mediav1alpha1.Immich) string {
+	return fmt.Sprintf("http://%s-server.%s.svc:%d", immich.Name, immich.Namespace, immichServerPort)
+}
+
+// serverVersion is the response of the /api/server/version endpoint
+type serverVersion struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+func (v serverVersion) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// getServerVersion pings the Immich server and returns the version it reports (e.g., "v1.120.0")
+func (r *ImmichReconciler) getServerVersion(ctx context.Context, immich *mediav1alpha1.Immich) (string, error) {
+	baseURL := getServerURL(immich)
+
+	var ping struct {
+		Res string `json:"res"`
+	}
+	if err := r.getServerAPI(ctx, baseURL+"/api/server/ping", &ping); err != nil {
+		return "", err
+	}
+	if ping.Res != "pong" {
+		return "", fmt.Errorf("unexpected response to /api/server/ping: %q", ping.Res)
+	}
+
+	var version serverVersion
+	if err := r.getServerAPI(ctx, baseURL+"/api/server/version", &version); err != nil {
+		return "", err
+	}
+	return version.String(), nil
+}
+
+// getServerAPI sends a GET request to the Immich server API and decodes the JSON response into out
+func (r *ImmichReconciler) getServerAPI(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.getHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("GET %s: invalid response: %w", url, err)
+	}
+	return nil
+}
+
+// pingMachineLearning checks that a machine learning service answers its /ping endpoint
+func (r *ImmichReconciler) pingMachineLearning(ctx context.Context, baseURL string) error {
+	url := strings.TrimSuffix(baseURL, "/") + "/ping"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.getHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// getHTTPClient returns the client used to query Immich, defaulting to a client with a short timeout
+func (r *ImmichReconciler) getHTTPClient() *http.Client {
+	if r.HTTPClient == nil {
+		return &http.Client{Timeout: 5 * time.Second}
+	}
+	return r.HTTPClient
+}
@@ -18,22 +18,32 @@ package controller
 
 import (
 	"context"
+	"net/http"
+	"slices"
 	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
 )
@@ -43,9 +53,12 @@ const (
 	immichFinalizer = "media.rm3l.org/finalizer"
 
 	// Condition types
-	ConditionTypeReady       = "Ready"
-	ConditionTypeProgressing = "Progressing"
-	ConditionTypeDegraded    = "Degraded"
+	ConditionTypeReady           = "Ready"
+	ConditionTypeProgressing     = "Progressing"
+	ConditionTypeDegraded        = "Degraded"
+	ConditionTypeConfigRolledOut = "ConfigRolledOut"
+	ConditionTypeUpgrading       = "Upgrading"
+	ConditionTypeImmichUpgrading = "ImmichUpgrading"
 )
 
 // ImmichReconciler reconciles a Immich object
@@ -54,10 +67,35 @@ type ImmichReconciler struct {
 	Scheme          *runtime.Scheme
 	DiscoveryClient discovery.DiscoveryInterface
 
+	// APIReader reads Pods and Nodes from the API server, as caching them would start cluster-wide informers
+	// to read a few label-selected objects. Defaults to the client.
+	APIReader client.Reader
+
+	// HTTPClient is used to query the Immich server API and to ping remote machine learning backends.
+	// Defaults to a client with a short timeout.
+	HTTPClient *http.Client
+
+	// Recorder emits Events on Immich resources. Events are not emitted when nil.
+	Recorder record.EventRecorder
+
+	// Last emission time of each Event, to rate limit identical Events
+	eventTimes      map[string]time.Time
+	eventTimesMutex sync.Mutex
+
 	// Cache for Route API availability check
 	routeAPIAvailable  bool
 	routeAPIChecked    bool
 	routeAPICheckMutex sync.Mutex
+
+	// Cache for ServiceMonitor API availability check
+	serviceMonitorAPIAvailable  bool
+	serviceMonitorAPIChecked    bool
+	serviceMonitorAPICheckMutex sync.Mutex
+
+	// Cache for CloudNativePG Cluster API availability check
+	cloudNativePGAPIAvailable  bool
+	cloudNativePGAPIChecked    bool
+	cloudNativePGAPICheckMutex sync.Mutex
 }
 
 // RouteGVR is the GroupVersionResource for OpenShift Routes
@@ -112,18 +150,62 @@ func (r *ImmichReconciler) IsRouteAPIAvailable() bool {
 	return false
 }
 
+// ServiceMonitorGVK is the GroupVersionKind for Prometheus Operator ServiceMonitors
+var ServiceMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+// IsServiceMonitorAPIAvailable checks if the Prometheus Operator ServiceMonitor API is available in the cluster
+func (r *ImmichReconciler) IsServiceMonitorAPIAvailable() bool {
+	r.serviceMonitorAPICheckMutex.Lock()
+	defer r.serviceMonitorAPICheckMutex.Unlock()
+
+	// Return cached result if already checked
+	if r.serviceMonitorAPIChecked {
+		return r.serviceMonitorAPIAvailable
+	}
+
+	// Check if ServiceMonitor API is available
+	if r.DiscoveryClient != nil {
+		resources, err := r.DiscoveryClient.ServerResourcesForGroupVersion(ServiceMonitorGVK.GroupVersion().String())
+		if err == nil {
+			for _, resource := range resources.APIResources {
+				if resource.Kind == ServiceMonitorGVK.Kind {
+					r.serviceMonitorAPIChecked = true
+					r.serviceMonitorAPIAvailable = true
+					return true
+				}
+			}
+		}
+	}
+
+	r.serviceMonitorAPIChecked = true
+	r.serviceMonitorAPIAvailable = false
+	return false
+}
+
 // +kubebuilder:rbac:groups=media.rm3l.org,resources=immiches,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=media.rm3l.org,resources=immiches/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=media.rm3l.org,resources=immiches/finalizers,verbs=update
+// +kubebuilder:rbac:groups=media.rm3l.org,resources=immichrestores,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=nodes;pods,verbs=get;list
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=clusters,verbs=get;list;watch;create;update;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -182,6 +264,7 @@ func (r *ImmichReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	// Validate required images are set
 	if err := r.validateImages(immich); err != nil {
 		log.Error(err, "Image validation failed")
+		r.recordEvent(immich, corev1.EventTypeWarning, EventReasonImageNotConfigured, "%s", err.Error())
 		meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
 			Type:    ConditionTypeDegraded,
 			Status:  metav1.ConditionTrue,
@@ -202,6 +285,7 @@ func (r *ImmichReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	if immich.ShouldCreateLibraryPVC() {
 		if err := r.reconcileLibraryPVC(ctx, immich); err != nil {
 			log.Error(err, "Failed to reconcile Library PVC")
+			r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile Library PVC: %v", err)
 			reconcileErr = err
 		}
 	}
@@ -209,53 +293,115 @@ func (r *ImmichReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	// 2. Reconcile Immich configuration (ConfigMap/Secret)
 	if err := r.reconcileImmichConfig(ctx, immich); err != nil {
 		log.Error(err, "Failed to reconcile Immich config")
+		r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile Immich config: %v", err)
 		reconcileErr = err
 	}
 
-	// 3. Reconcile PostgreSQL if enabled
-	if immich.IsPostgresEnabled() {
+	// 3. Reconcile PostgreSQL if enabled (StatefulSet or CloudNativePG Cluster), or check the external database
+	if immich.IsCloudNativePGEnabled() {
+		meta.RemoveStatusCondition(&immich.Status.Conditions, ConditionTypeDatabaseReady)
+		if err := r.reconcileCloudNativePG(ctx, immich); err != nil {
+			log.Error(err, "Failed to reconcile CloudNativePG Cluster")
+			r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile CloudNativePG Cluster: %v", err)
+			reconcileErr = err
+		}
+	} else if immich.IsPostgresEnabled() {
+		meta.RemoveStatusCondition(&immich.Status.Conditions, ConditionTypeDatabaseReady)
 		if err := r.reconcilePostgres(ctx, immich); err != nil {
 			log.Error(err, "Failed to reconcile PostgreSQL")
+			r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile PostgreSQL: %v", err)
 			reconcileErr = err
 		}
+	} else if err := r.reconcileExternalPostgres(ctx, immich); err != nil {
+		log.Error(err, "Failed to check the external database")
+		r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to check the external database: %v", err)
+		reconcileErr = err
 	}
 
-	// 4. Reconcile Valkey if enabled
+	// 4. Reconcile Valkey if enabled, or the TLS options of the external Valkey
 	if immich.IsValkeyEnabled() {
 		if err := r.reconcileValkey(ctx, immich); err != nil {
 			log.Error(err, "Failed to reconcile Valkey")
+			r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile Valkey: %v", err)
+			reconcileErr = err
+		}
+	} else if immich.GetValkeyTLS() != nil {
+		if err := r.reconcileValkeyURLSecret(ctx, immich); err != nil {
+			log.Error(err, "Failed to reconcile the Valkey connection")
+			r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile the Valkey connection: %v", err)
 			reconcileErr = err
 		}
 	}
 
-	// 5. Reconcile Machine Learning if enabled
+	// 5. Orchestrate server image changes according to the upgrade policy
+	if err := r.reconcileImmichUpgrade(ctx, immich); err != nil {
+		log.Error(err, "Failed to reconcile Immich upgrade")
+		r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile Immich upgrade: %v", err)
+		reconcileErr = err
+	}
+
+	// 6. Reconcile Machine Learning if enabled
 	if immich.IsMachineLearningEnabled() {
 		if err := r.reconcileMachineLearning(ctx, immich); err != nil {
 			log.Error(err, "Failed to reconcile Machine Learning")
+			r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile Machine Learning: %v", err)
 			reconcileErr = err
 		}
 	}
 
-	// 6. Reconcile Server if enabled
-	if immich.IsServerEnabled() {
+	// 7. Reconcile Server if enabled, once the external database passed the preflight check
+	if immich.IsServerEnabled() && !isDatabaseReady(immich) {
+		log.Info("Waiting for the database preflight check before rolling out the server")
+	} else if immich.IsServerEnabled() {
 		if err := r.reconcileServer(ctx, immich); err != nil {
 			log.Error(err, "Failed to reconcile Server")
+			r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile Server: %v", err)
 			reconcileErr = err
 		}
 	}
 
+	// 8. Copy Immich database dumps out of the library volume if enabled
+	if immich.IsBackupCopyEnabled() {
+		if err := r.reconcileBackupCopy(ctx, immich); err != nil {
+			log.Error(err, "Failed to reconcile database backup copy")
+			r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile database backup copy: %v", err)
+			reconcileErr = err
+		}
+	}
+
+	// 9. Protect the workloads with PodDisruptionBudgets
+	if err := r.reconcilePodDisruptionBudgets(ctx, immich); err != nil {
+		log.Error(err, "Failed to reconcile PodDisruptionBudgets")
+		r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to reconcile PodDisruptionBudgets: %v", err)
+		reconcileErr = err
+	}
+
+	// 10. Prune resources of disabled components or exposures (PVCs and credentials are retained)
+	if err := r.pruneObjects(ctx, immich); err != nil {
+		log.Error(err, "Failed to prune resources")
+		r.recordEvent(immich, corev1.EventTypeWarning, EventReasonReconcileFailed, "Failed to prune resources: %v", err)
+		reconcileErr = err
+	}
+
 	// Update status
 	if err := r.updateStatus(ctx, immich); err != nil {
 		log.Error(err, "Failed to update status")
-		return ctrl.Result{}, err
+		reconcileErr = err
 	}
 
 	if reconcileErr != nil {
+		// Persist the progress made so far, e.g. the phase of an upgrade, before retrying
+		if statusErr := r.Status().Update(ctx, immich); statusErr != nil {
+			log.Error(statusErr, "Failed to update Immich status")
+		}
 		return ctrl.Result{RequeueAfter: 30 * time.Second}, reconcileErr
 	}
 
 	// Set Ready condition based on component status
 	if immich.Status.Ready {
+		if !meta.IsStatusConditionTrue(immich.Status.Conditions, ConditionTypeReady) {
+			r.recordEvent(immich, corev1.EventTypeNormal, EventReasonReady, "All Immich components are ready")
+		}
 		meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
 			Type:    ConditionTypeReady,
 			Status:  metav1.ConditionTrue,
@@ -280,6 +426,14 @@ func (r *ImmichReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	}
 
 	log.V(1).Info("Successfully reconciled Immich")
+	if immich.Status.Upgrade != nil && immich.Status.Upgrade.Phase != mediav1alpha1.UpgradePhaseFailed {
+		// Poll the new server version, which does not generate any event
+		return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
+	}
+	if !isDatabaseReady(immich) {
+		// Run a failed database preflight check again, e.g. once the vector extension is installed
+		return ctrl.Result{RequeueAfter: databasePreflightRetryInterval}, nil
+	}
 	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
 }
 
@@ -291,17 +445,66 @@ func (r *ImmichReconciler) finalizeImmich(ctx context.Context, immich *mediav1al
 	return nil
 }
 
+// apiReader returns the reader of the objects that are not cached
+func (r *ImmichReconciler) apiReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// immichForRestore maps an ImmichRestore to the Immich instance it restores,
+// so that the server is scaled down and back up as the restore progresses
+func immichForRestore(_ context.Context, obj client.Object) []reconcile.Request {
+	restore, ok := obj.(*mediav1alpha1.ImmichRestore)
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: restore.Spec.ImmichRef, Namespace: restore.Namespace}},
+	}
+}
+
+// immichesForSecret maps a Secret to the Immich instances referencing it, once each: rotating a credential of the
+// configuration renders it again, rotating the Valkey password restarts Valkey and the server, and rotating a source
+// of the connection URL Secrets renders them again
+func (r *ImmichReconciler) immichesForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.immichesReferencing(ctx, obj, func(immich *mediav1alpha1.Immich) bool {
+		refs := append(getConfigSecretRefs(immich), getConnectionSecretRefs(immich)...)
+		if ref := getValkeyPasswordSecretRef(immich); ref != nil {
+			refs = append(refs, *ref)
+		}
+		return slices.ContainsFunc(refs, func(ref mediav1alpha1.SecretKeySelector) bool {
+			return ref.Name == obj.GetName()
+		})
+	})
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ImmichReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&mediav1alpha1.Immich{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&appsv1.StatefulSet{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Owns(&batchv1.CronJob{}).
+		Owns(&batchv1.Job{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&corev1.Secret{}).
 		Owns(&corev1.PersistentVolumeClaim{}).
 		Owns(&networkingv1.Ingress{}).
-		Named("immich").
-		Complete(r)
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Watches(&mediav1alpha1.ImmichRestore{}, handler.EnqueueRequestsFromMapFunc(immichForRestore)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.immichesForSecret)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.immichesForConfigConfigMap))
+
+	// CloudNativePG Clusters have no owner reference, they are mapped to their Immich instance by label
+	if r.IsCloudNativePGAPIAvailable() {
+		cluster := &unstructured.Unstructured{}
+		cluster.SetGroupVersionKind(CloudNativePGClusterGVK)
+		b = b.Watches(cluster, handler.EnqueueRequestsFromMapFunc(immichForCloudNativePGCluster))
+	}
+
+	return b.Named("immich").Complete(r)
 }
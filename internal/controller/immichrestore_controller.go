@@ -0,0 +1,424 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+const (
+	// Condition types
+	ConditionTypeServerScaledDown = "ServerScaledDown"
+	ConditionTypeRestored         = "Restored"
+
+	// restoreDir is the directory where the dump is mounted or downloaded in the restore pods
+	restoreDir = "/restore"
+)
+
+// ImmichRestoreReconciler reconciles an ImmichRestore object
+type ImmichRestoreReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=media.rm3l.org,resources=immichrestores,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=media.rm3l.org,resources=immichrestores/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=media.rm3l.org,resources=immichrestores/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile restores the built-in PostgreSQL database of an Immich instance from a dump.
+// The Immich reconciler keeps the server scaled down while a restore targeting it is not finished,
+// so this reconciler waits for the server pods to be gone before running the restore Job.
+func (r *ImmichRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	restore := &mediav1alpha1.ImmichRestore{}
+	if err := r.Get(ctx, req.NamespacedName, restore); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("ImmichRestore resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get ImmichRestore")
+		return ctrl.Result{}, err
+	}
+
+	// A restore is only ever run once
+	if restore.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+
+	if restore.Status.StartTime == nil {
+		restore.Status.StartTime = ptr.To(metav1.Now())
+		restore.Status.Phase = mediav1alpha1.RestorePhasePending
+	}
+
+	result, reconcileErr := r.reconcileRestore(ctx, restore)
+
+	if err := r.Status().Update(ctx, restore); err != nil {
+		log.Error(err, "Failed to update ImmichRestore status")
+		return ctrl.Result{}, err
+	}
+
+	return result, reconcileErr
+}
+
+// reconcileRestore moves the restore forward and records its progress in the status
+func (r *ImmichRestoreReconciler) reconcileRestore(ctx context.Context, restore *mediav1alpha1.ImmichRestore) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	immich := &mediav1alpha1.Immich{}
+	err := r.Get(ctx, types.NamespacedName{Name: restore.Spec.ImmichRef, Namespace: restore.Namespace}, immich)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			setRestoreProgressing(restore, "ImmichNotFound", fmt.Sprintf("Waiting for Immich %q to exist", restore.Spec.ImmichRef))
+			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !immich.IsPostgresEnabled() {
+		failRestore(restore, "ExternalDatabase", "Only the built-in PostgreSQL database can be restored")
+		return ctrl.Result{}, nil
+	}
+	if immich.IsCloudNativePGEnabled() {
+		failRestore(restore, "CloudNativePGDatabase", "Restore the CloudNativePG Cluster with CloudNativePG recovery instead")
+		return ctrl.Result{}, nil
+	}
+
+	job := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: getRestoreJobName(restore), Namespace: restore.Namespace}, job)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	if apierrors.IsNotFound(err) {
+		// Wait for the Immich reconciler to scale the server down
+		restore.Status.Phase = mediav1alpha1.RestorePhaseScalingDown
+		scaledDown, err := isServerScaledDown(ctx, r.Client, immich)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !scaledDown {
+			meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+				Type:    ConditionTypeServerScaledDown,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ScalingDown",
+				Message: "Waiting for the Immich server pods to terminate",
+			})
+			setRestoreProgressing(restore, "ScalingDownServer", "Scaling the Immich server down before the restore")
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+			Type:    ConditionTypeServerScaledDown,
+			Status:  metav1.ConditionTrue,
+			Reason:  "ScaledDown",
+			Message: "The Immich server is scaled down",
+		})
+
+		job, err = r.buildRestoreJob(restore, immich)
+		if err != nil {
+			failRestore(restore, "InvalidRestore", err.Error())
+			return ctrl.Result{}, nil
+		}
+		log.Info("Creating restore Job", "name", job.Name, "immich", immich.Name)
+		if err := r.Create(ctx, job); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	restore.Status.Phase = mediav1alpha1.RestorePhaseRestoring
+
+	switch {
+	case job.Status.Succeeded > 0:
+		restore.Status.Phase = mediav1alpha1.RestorePhaseCompleted
+		restore.Status.CompletionTime = ptr.To(metav1.Now())
+		meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+			Type:    ConditionTypeRestored,
+			Status:  metav1.ConditionTrue,
+			Reason:  "RestoreSucceeded",
+			Message: "The database was restored, the Immich server is being scaled back up",
+		})
+		meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+			Type:    ConditionTypeProgressing,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Completed",
+			Message: "The restore completed",
+		})
+		log.Info("Restore completed", "immich", immich.Name)
+	case isJobFailed(job):
+		failRestore(restore, "RestoreJobFailed", fmt.Sprintf("The restore Job %s failed, see its logs for details", job.Name))
+		log.Info("Restore failed", "immich", immich.Name, "job", job.Name)
+	default:
+		setRestoreProgressing(restore, "Restoring", fmt.Sprintf("The restore Job %s is running", job.Name))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// isServerScaledDown returns true once the server Deployments have no replica left
+func isServerScaledDown(ctx context.Context, c client.Client, immich *mediav1alpha1.Immich) (bool, error) {
+	for _, workload := range getServerWorkloads(immich) {
+		deployment := &appsv1.Deployment{}
+		err := c.Get(ctx, types.NamespacedName{Name: workload.name, Namespace: immich.Namespace}, deployment)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if ptr.Deref(deployment.Spec.Replicas, 1) != 0 || deployment.Status.Replicas != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// buildRestoreJob returns the Job restoring the dump into the built-in PostgreSQL database.
+// With a PVC source, the dump is read from the PVC mounted read-only.
+// With an S3 source, the dump is first downloaded to an emptyDir by an AWS CLI init container.
+func (r *ImmichRestoreReconciler) buildRestoreJob(restore *mediav1alpha1.ImmichRestore, immich *mediav1alpha1.Immich) (*batchv1.Job, error) {
+	labels := getLabels(immich, "restore")
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+	source := restore.Spec.Source
+
+	image := restore.GetRestoreImage(immich)
+	if image == "" {
+		return nil, fmt.Errorf("restore image not configured: set spec.image, the Immich spec.postgres.image or RELATED_IMAGE_postgres environment variable")
+	}
+
+	var dumpFile string
+	var initContainers []corev1.Container
+	var dumpVolume corev1.VolumeSource
+
+	switch {
+	case source.PVC != nil:
+		dumpFile = path.Join(restoreDir, source.PVC.Path)
+		dumpVolume = corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: source.PVC.ClaimName,
+				ReadOnly:  true,
+			},
+		}
+	case source.S3 != nil:
+		s3 := source.S3
+		s3Image := restore.GetS3Image()
+		if s3Image == "" {
+			return nil, fmt.Errorf("restore S3 image not configured: set spec.source.s3.image or %s environment variable", mediav1alpha1.EnvRelatedImageBackupS3)
+		}
+
+		dumpFile = path.Join(restoreDir, path.Base(s3.Key))
+		s3Env := append([]corev1.EnvVar{
+			{Name: "S3_URL", Value: fmt.Sprintf("s3://%s/%s", s3.Bucket, s3.Key)},
+			{Name: "DUMP_FILE", Value: dumpFile},
+		}, getAWSEnv(s3.AccessKeyIDSecretRef, s3.SecretAccessKeySecretRef, s3.Endpoint, s3.Region)...)
+
+		initContainers = []corev1.Container{
+			{
+				Name:            "download",
+				Image:           s3Image,
+				ImagePullPolicy: postgresSpec.ImagePullPolicy,
+				Command:         []string{"/bin/sh", "-c", `set -eu; echo "Downloading ${S3_URL}"; aws s3 cp "${S3_URL}" "${DUMP_FILE}"`},
+				Env:             s3Env,
+				Resources:       restore.Spec.Resources,
+				SecurityContext: postgresSpec.SecurityContext,
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "dump", MountPath: restoreDir},
+				},
+			},
+		}
+		dumpVolume = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	default:
+		return nil, fmt.Errorf("exactly one of spec.source.pvc or spec.source.s3 must be set")
+	}
+
+	env := append(getPostgresClientEnv(immich), corev1.EnvVar{Name: "DUMP_FILE", Value: dumpFile})
+	// PostgreSQL certificates, referenced by the libpq environment
+	tlsVolumes, tlsVolumeMounts := getPostgresTLSVolumes(immich)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getRestoreJobName(restore),
+			Namespace: restore.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         mediav1alpha1.GroupVersion.String(),
+					Kind:               "ImmichRestore",
+					Name:               restore.Name,
+					UID:                restore.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: batchv1.JobSpec{
+			// A partially applied dump must be looked at before retrying
+			BackoffLimit: ptr.To(int32(0)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					ImagePullSecrets: immich.Spec.ImagePullSecrets,
+					SecurityContext:  postgresSpec.PodSecurityContext,
+					NodeSelector:     postgresSpec.NodeSelector,
+					Tolerations:      postgresSpec.Tolerations,
+					InitContainers:   initContainers,
+					Containers: []corev1.Container{
+						{
+							Name:            "restore",
+							Image:           image,
+							ImagePullPolicy: postgresSpec.ImagePullPolicy,
+							Command:         []string{"/bin/bash", "-c", postgresRestoreScript},
+							Env:             env,
+							Resources:       restore.Spec.Resources,
+							SecurityContext: postgresSpec.SecurityContext,
+							VolumeMounts: append([]corev1.VolumeMount{
+								{Name: "dump", MountPath: restoreDir, ReadOnly: source.PVC != nil},
+							}, tlsVolumeMounts...),
+						},
+					},
+					Volumes: append([]corev1.Volume{
+						{Name: "dump", VolumeSource: dumpVolume},
+					}, tlsVolumes...),
+				},
+			},
+		},
+	}, nil
+}
+
+// fixSearchPathSed is the search_path fix of the restore procedure documented by Immich, as an extended sed expression
+const fixSearchPathSed = `s/SELECT pg_catalog\.set_config\('search_path', '', false\);/SELECT pg_catalog.set_config('search_path', 'public, pg_catalog', true);/g`
+
+// skipConnectedRoleSed removes the statements of a cluster dump dropping or creating the role the restore connects as,
+// which cannot be dropped and already exists
+const skipConnectedRoleSed = `/^(DROP ROLE (IF EXISTS )?|CREATE ROLE )\"?${PGUSER}\"?;\$/d`
+
+// postgresRestoreScript waits for PostgreSQL, then feeds the (optionally gzipped) plain SQL dump to psql, stopping on
+// the first error. Dumps of the whole cluster (pg_dumpall) connect to each database themselves.
+const postgresRestoreScript = `set -euo pipefail
+if [ ! -s "${DUMP_FILE}" ]; then
+  echo "The dump ${DUMP_FILE} is missing or empty" >&2
+  exit 1
+fi
+until pg_isready -q ${DB_URL:+-d "${DB_URL}"}; do
+  echo "Waiting for PostgreSQL${PGHOST:+ at ${PGHOST}:${PGPORT}}..."
+  sleep 2
+done
+# The role the restore connects as, which the cluster dumps must not drop nor create
+: "${PGUSER:=$(psql -XAtq ${DB_URL:+-d "${DB_URL}"} -c 'SELECT current_user')}"
+case "${DUMP_FILE}" in
+  *.gz) read_dump="gunzip -c" ;;
+  *) read_dump="cat" ;;
+esac
+# head exits before the whole dump is read, which fails the pipeline
+header="$(${read_dump} "${DUMP_FILE}" 2>/dev/null | head -n 10 || true)"
+if echo "${header}" | grep -q "PostgreSQL database cluster dump"; then
+  echo "Restoring cluster dump ${DUMP_FILE}..."
+  ${read_dump} "${DUMP_FILE}" | sed -E -e "` + fixSearchPathSed + `" -e "` + skipConnectedRoleSed + `" | psql -X -v ON_ERROR_STOP=1 -d "${DB_URL:-postgres}" -c '\connect postgres' -f -
+else
+  echo "Restoring ${DUMP_FILE} into the database${PGDATABASE:+ ${PGDATABASE}}..."
+  ${read_dump} "${DUMP_FILE}" | sed -E "` + fixSearchPathSed + `" | psql -X -v ON_ERROR_STOP=1 ${DB_URL:+-d "${DB_URL}"}
+fi
+echo "Restore completed"
+`
+
+// getRestoreJobName returns the name of the Job running the restore
+func getRestoreJobName(restore *mediav1alpha1.ImmichRestore) string {
+	return fmt.Sprintf("%s-restore", restore.Name)
+}
+
+// isJobFailed returns true if the Job has failed
+func isJobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// setRestoreProgressing records an in-progress step of the restore
+func setRestoreProgressing(restore *mediav1alpha1.ImmichRestore, reason, message string) {
+	meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeProgressing,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// failRestore marks the restore as failed. Failed restores are not retried:
+// a new ImmichRestore must be created once the cause is fixed.
+func failRestore(restore *mediav1alpha1.ImmichRestore, reason, message string) {
+	restore.Status.Phase = mediav1alpha1.RestorePhaseFailed
+	restore.Status.CompletionTime = ptr.To(metav1.Now())
+	meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeRestored,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+	meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeProgressing,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Failed",
+		Message: message,
+	})
+}
+
+// isRestoreInProgress returns true if an unfinished ImmichRestore targets the Immich instance
+func (r *ImmichReconciler) isRestoreInProgress(ctx context.Context, immich *mediav1alpha1.Immich) (bool, error) {
+	restores := &mediav1alpha1.ImmichRestoreList{}
+	if err := r.List(ctx, restores, client.InNamespace(immich.Namespace)); err != nil {
+		return false, err
+	}
+	for _, restore := range restores.Items {
+		if restore.Spec.ImmichRef == immich.Name && !restore.IsFinished() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ImmichRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mediav1alpha1.ImmichRestore{}).
+		Owns(&batchv1.Job{}).
+		Named("immichrestore").
+		Complete(r)
+}
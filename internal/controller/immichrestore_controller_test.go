@@ -0,0 +1,273 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestImmichRestoreReconcile(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:14")
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+	}
+	restore := &mediav1alpha1.ImmichRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichRestoreSpec{
+			ImmichRef: "test-immich",
+			Source: mediav1alpha1.RestoreSource{
+				PVC: &mediav1alpha1.RestorePVCSource{
+					ClaimName: "test-immich-postgres-backup",
+					Path:      "test-immich-20250101020000.sql.gz",
+				},
+			},
+		},
+	}
+	server := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich-server", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To(int32(1))},
+		Status:     appsv1.DeploymentStatus{Replicas: 1},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme(t)).
+		WithObjects(immich, restore, server).
+		WithStatusSubresource(restore, server, &batchv1.Job{}).
+		Build()
+	r := &ImmichRestoreReconciler{Client: c}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restore", Namespace: "default"}}
+	jobKey := types.NamespacedName{Name: "restore-restore", Namespace: "default"}
+
+	reconcile := func() *mediav1alpha1.ImmichRestore {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		got := &mediav1alpha1.ImmichRestore{}
+		if err := c.Get(ctx, req.NamespacedName, got); err != nil {
+			t.Fatalf("failed to get restore: %v", err)
+		}
+		return got
+	}
+
+	// The server is still running: no Job yet
+	got := reconcile()
+	if got.Status.Phase != mediav1alpha1.RestorePhaseScalingDown {
+		t.Errorf("phase = %s, want %s", got.Status.Phase, mediav1alpha1.RestorePhaseScalingDown)
+	}
+	if got.Status.StartTime == nil {
+		t.Error("startTime should be set")
+	}
+	if err := c.Get(ctx, jobKey, &batchv1.Job{}); err == nil {
+		t.Fatal("restore Job should not be created while the server is running")
+	}
+
+	// The Immich reconciler scaled the server down
+	server.Spec.Replicas = ptr.To(int32(0))
+	if err := c.Update(ctx, server); err != nil {
+		t.Fatalf("failed to update server: %v", err)
+	}
+	server.Status.Replicas = 0
+	if err := c.Status().Update(ctx, server); err != nil {
+		t.Fatalf("failed to update server status: %v", err)
+	}
+
+	got = reconcile()
+	if got.Status.Phase != mediav1alpha1.RestorePhaseRestoring {
+		t.Errorf("phase = %s, want %s", got.Status.Phase, mediav1alpha1.RestorePhaseRestoring)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionTypeServerScaledDown) {
+		t.Error("ServerScaledDown condition should be true")
+	}
+
+	job := &batchv1.Job{}
+	if err := c.Get(ctx, jobKey, job); err != nil {
+		t.Fatalf("restore Job should have been created: %v", err)
+	}
+	if len(job.OwnerReferences) != 1 || job.OwnerReferences[0].Kind != "ImmichRestore" {
+		t.Errorf("restore Job should be owned by the ImmichRestore, got %+v", job.OwnerReferences)
+	}
+	podSpec := job.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 0 {
+		t.Errorf("no download container expected for a PVC source, got %d", len(podSpec.InitContainers))
+	}
+	volume := podSpec.Volumes[0].PersistentVolumeClaim
+	if volume == nil || volume.ClaimName != "test-immich-postgres-backup" || !volume.ReadOnly {
+		t.Errorf("dump volume should mount the PVC read-only, got %+v", podSpec.Volumes[0])
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, e := range podSpec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	if env["DUMP_FILE"].Value != "/restore/test-immich-20250101020000.sql.gz" {
+		t.Errorf("DUMP_FILE = %s", env["DUMP_FILE"].Value)
+	}
+	if env["PGHOST"].Value != "test-immich-postgres" {
+		t.Errorf("PGHOST = %s, want test-immich-postgres", env["PGHOST"].Value)
+	}
+	if podSpec.Containers[0].Image != "postgres:14" {
+		t.Errorf("image = %s, want the PostgreSQL image", podSpec.Containers[0].Image)
+	}
+	script := podSpec.Containers[0].Command[2]
+	if podSpec.Containers[0].Command[0] != "/bin/bash" || !strings.Contains(script, "set -euo pipefail") ||
+		strings.Count(script, "psql -X -v ON_ERROR_STOP=1") != 2 || !strings.Contains(script, `[ ! -s "${DUMP_FILE}" ]`) {
+		t.Errorf("restore script should stop on the first error of both dump kinds and on a missing dump:\n%s", script)
+	}
+
+	// The Job succeeded
+	job.Status.Succeeded = 1
+	if err := c.Status().Update(ctx, job); err != nil {
+		t.Fatalf("failed to update job status: %v", err)
+	}
+
+	got = reconcile()
+	if got.Status.Phase != mediav1alpha1.RestorePhaseCompleted {
+		t.Errorf("phase = %s, want %s", got.Status.Phase, mediav1alpha1.RestorePhaseCompleted)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionTypeRestored) {
+		t.Error("Restored condition should be true")
+	}
+	if got.Status.CompletionTime == nil {
+		t.Error("completionTime should be set")
+	}
+}
+
+func TestImmichRestoreReconcile_Failures(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:14")
+	t.Setenv(mediav1alpha1.EnvRelatedImageBackupS3, "")
+
+	s3Source := mediav1alpha1.RestoreSource{
+		S3: &mediav1alpha1.RestoreS3Source{
+			Bucket:                   "backups",
+			Key:                      "immich/test-immich-20250101020000.sql.gz",
+			AccessKeyIDSecretRef:     mediav1alpha1.SecretKeySelector{Name: "s3", Key: "access-key"},
+			SecretAccessKeySecretRef: mediav1alpha1.SecretKeySelector{Name: "s3", Key: "secret-key"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		postgres   *mediav1alpha1.PostgresSpec
+		source     mediav1alpha1.RestoreSource
+		wantReason string
+	}{
+		{
+			name:       "external database",
+			postgres:   &mediav1alpha1.PostgresSpec{Enabled: ptr.To(false)},
+			source:     s3Source,
+			wantReason: "ExternalDatabase",
+		},
+		{
+			name:       "missing S3 image",
+			source:     s3Source,
+			wantReason: "InvalidRestore",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := &mediav1alpha1.Immich{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+				Spec:       mediav1alpha1.ImmichSpec{Postgres: tt.postgres},
+			}
+			restore := &mediav1alpha1.ImmichRestore{
+				ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "default"},
+				Spec:       mediav1alpha1.ImmichRestoreSpec{ImmichRef: "test-immich", Source: tt.source},
+			}
+			c := fake.NewClientBuilder().
+				WithScheme(newTestScheme(t)).
+				WithObjects(immich, restore).
+				WithStatusSubresource(restore).
+				Build()
+			r := &ImmichRestoreReconciler{Client: c}
+
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(restore)}
+			if _, err := r.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			got := &mediav1alpha1.ImmichRestore{}
+			if err := c.Get(ctx, req.NamespacedName, got); err != nil {
+				t.Fatalf("failed to get restore: %v", err)
+			}
+			if got.Status.Phase != mediav1alpha1.RestorePhaseFailed {
+				t.Errorf("phase = %s, want %s", got.Status.Phase, mediav1alpha1.RestorePhaseFailed)
+			}
+			condition := meta.FindStatusCondition(got.Status.Conditions, ConditionTypeRestored)
+			if condition == nil || condition.Reason != tt.wantReason {
+				t.Errorf("Restored condition = %+v, want reason %s", condition, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestIsRestoreInProgress(t *testing.T) {
+	ctx := context.Background()
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+	}
+	newRestore := func(name, immichRef, phase string) *mediav1alpha1.ImmichRestore {
+		return &mediav1alpha1.ImmichRestore{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       mediav1alpha1.ImmichRestoreSpec{ImmichRef: immichRef},
+			Status:     mediav1alpha1.ImmichRestoreStatus{Phase: phase},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		restores []client.Object
+		want     bool
+	}{
+		{"no restore", nil, false},
+		{"restore of another instance", []client.Object{newRestore("other", "other-immich", "")}, false},
+		{"completed restore", []client.Object{newRestore("done", "test-immich", mediav1alpha1.RestorePhaseCompleted)}, false},
+		{"new restore", []client.Object{newRestore("new", "test-immich", "")}, true},
+		{"running restore", []client.Object{newRestore("running", "test-immich", mediav1alpha1.RestorePhaseRestoring)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ImmichReconciler{Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(tt.restores...).Build()}
+			got, err := r.isRestoreInProgress(ctx, immich)
+			if err != nil {
+				t.Fatalf("isRestoreInProgress() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isRestoreInProgress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -30,7 +30,7 @@ const (
 )
 
 // getLabels returns the standard labels for Immich components
-func (r *ImmichReconciler) getLabels(immich *mediav1alpha1.Immich, component string) map[string]string {
+func getLabels(immich *mediav1alpha1.Immich, component string) map[string]string {
 	return map[string]string{
 		labelApp:       "immich",
 		labelInstance:  immich.Name,
@@ -40,6 +40,11 @@ func (r *ImmichReconciler) getLabels(immich *mediav1alpha1.Immich, component str
 	}
 }
 
+// Wrapper method on reconciler to maintain existing API
+func (r *ImmichReconciler) getLabels(immich *mediav1alpha1.Immich, component string) map[string]string {
+	return getLabels(immich, component)
+}
+
 // getSelectorLabels returns the selector labels for Immich components
 func (r *ImmichReconciler) getSelectorLabels(immich *mediav1alpha1.Immich, component string) map[string]string {
 	return map[string]string{
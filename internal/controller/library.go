@@ -76,5 +76,9 @@ func (r *ImmichReconciler) reconcileLibraryPVC(ctx context.Context, immich *medi
 	// protecting user data and allowing reuse on CR recreation.
 
 	log.Info("Creating Library PVC (no owner reference for data safety)", "name", name, "size", size.String())
-	return r.Create(ctx, pvc)
+	if err := r.Create(ctx, pvc); err != nil {
+		return err
+	}
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonPVCCreated, "Created PersistentVolumeClaim %s", pvc.Name)
+	return nil
 }
@@ -18,7 +18,6 @@ package controller
 
 import (
 	"context"
-	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -33,51 +32,64 @@ import (
 	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
 )
 
-// reconcileMachineLearning creates or updates the Machine Learning deployment and service
+// reconcileMachineLearning creates or updates the Deployment, Service and cache PVC of each built-in
+// machine learning backend
 func (r *ImmichReconciler) reconcileMachineLearning(ctx context.Context, immich *mediav1alpha1.Immich) error {
 	log := logf.FromContext(ctx)
 	log.V(1).Info("Reconciling Machine Learning")
 
-	mlSpec := ptr.Deref(immich.Spec.MachineLearning, mediav1alpha1.MachineLearningSpec{})
-	persistence := ptr.Deref(mlSpec.Persistence, mediav1alpha1.MachineLearningPersistenceSpec{})
+	for _, workload := range getMachineLearningWorkloads(immich) {
+		// Create ML PVC first if persistence is enabled (must exist before deployment)
+		if immich.ShouldCreateMLCachePVC() {
+			if err := r.reconcileMLPVC(ctx, immich, workload); err != nil {
+				return err
+			}
+		}
 
-	// Create ML PVC first if persistence is enabled (must exist before deployment)
-	// Default is enabled (nil or true)
-	persistenceEnabled := persistence.Enabled == nil || *persistence.Enabled
-	if persistenceEnabled {
-		if err := r.reconcileMLPVC(ctx, immich); err != nil {
+		// Create ML Deployment
+		if err := r.reconcileMLDeployment(ctx, immich, workload); err != nil {
 			return err
 		}
-	}
 
-	// Create ML Deployment
-	if err := r.reconcileMLDeployment(ctx, immich); err != nil {
-		return err
-	}
+		// Create ML Service
+		if err := r.reconcileMLService(ctx, immich, workload); err != nil {
+			return err
+		}
 
-	// Create ML Service
-	if err := r.reconcileMLService(ctx, immich); err != nil {
-		return err
+		// Create the HorizontalPodAutoscaler if autoscaling is enabled
+		if workload.autoscaling.IsEnabled() {
+			if err := r.reconcileHorizontalPodAutoscaler(ctx, immich, workload.name, workload.component, workload.autoscaling); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
-// reconcileMLDeployment creates or updates the ML Deployment using server-side apply
-func (r *ImmichReconciler) reconcileMLDeployment(ctx context.Context, immich *mediav1alpha1.Immich) error {
-	name := fmt.Sprintf("%s-machine-learning", immich.Name)
-	labels := r.getLabels(immich, "machine-learning")
-	selectorLabels := r.getSelectorLabels(immich, "machine-learning")
+// reconcileMLDeployment creates or updates the Deployment of a machine learning backend using server-side apply
+func (r *ImmichReconciler) reconcileMLDeployment(ctx context.Context, immich *mediav1alpha1.Immich, workload machineLearningWorkload) error {
+	name := workload.name
+	labels := r.getLabels(immich, workload.component)
+	selectorLabels := r.getSelectorLabels(immich, workload.component)
 
 	mlSpec := ptr.Deref(immich.Spec.MachineLearning, mediav1alpha1.MachineLearningSpec{})
-	replicas := ptr.Deref(mlSpec.Replicas, 1)
+	replicas := getDeploymentReplicas(workload.replicas, workload.autoscaling.IsEnabled(), false)
+	acceleration := getAccelerationProfile(workload.acceleration)
+	acceleration.privileged = ptr.Deref(mlSpec.Privileged, false)
+
+	image, err := r.getMachineLearningWorkloadImage(ctx, immich, workload)
+	if err != nil {
+		return err
+	}
 
 	env := []corev1.EnvVar{
 		{Name: "TRANSFORMERS_CACHE", Value: "/cache"},
 		{Name: "HF_XET_CACHE", Value: "/cache/huggingface-xet"},
 		{Name: "MPLCONFIGDIR", Value: "/cache/matplotlib-config"},
 	}
-	env = append(env, mlSpec.Env...)
+	env = append(env, acceleration.env...)
+	env = append(env, workload.env...)
 
 	deployment := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
@@ -100,7 +112,7 @@ func (r *ImmichReconciler) reconcileMLDeployment(ctx context.Context, immich *me
 			},
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: ptr.To(replicas),
+			Replicas: replicas,
 			Strategy: appsv1.DeploymentStrategy{
 				Type: appsv1.RollingUpdateDeploymentStrategyType,
 			},
@@ -113,15 +125,16 @@ func (r *ImmichReconciler) reconcileMLDeployment(ctx context.Context, immich *me
 					Annotations: mlSpec.PodAnnotations,
 				},
 				Spec: corev1.PodSpec{
-					SecurityContext:  mlSpec.PodSecurityContext,
-					ImagePullSecrets: immich.Spec.ImagePullSecrets,
-					NodeSelector:     mlSpec.NodeSelector,
-					Tolerations:      mlSpec.Tolerations,
-					Affinity:         mlSpec.Affinity,
+					SecurityContext:           acceleration.getPodSecurityContext(mlSpec.PodSecurityContext),
+					ImagePullSecrets:          immich.Spec.ImagePullSecrets,
+					NodeSelector:              acceleration.getNodeSelector(workload.nodeSelector),
+					Tolerations:               workload.tolerations,
+					Affinity:                  workload.affinity,
+					TopologySpreadConstraints: getTopologySpreadConstraints(mlSpec.TopologySpreadConstraints, selectorLabels),
 					Containers: []corev1.Container{
 						{
 							Name:            "machine-learning",
-							Image:           immich.GetMachineLearningImage(),
+							Image:           image,
 							ImagePullPolicy: mlSpec.ImagePullPolicy,
 							Env:             env,
 							EnvFrom:         mlSpec.EnvFrom,
@@ -132,8 +145,8 @@ func (r *ImmichReconciler) reconcileMLDeployment(ctx context.Context, immich *me
 									Protocol:      corev1.ProtocolTCP,
 								},
 							},
-							Resources:       mlSpec.Resources,
-							SecurityContext: mlSpec.SecurityContext,
+							Resources:       acceleration.getResources(workload.resources),
+							SecurityContext: acceleration.getSecurityContext(mlSpec.SecurityContext),
 							LivenessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									HTTPGet: &corev1.HTTPGetAction{
@@ -170,10 +183,10 @@ func (r *ImmichReconciler) reconcileMLDeployment(ctx context.Context, immich *me
 								TimeoutSeconds:      1,
 								FailureThreshold:    60,
 							},
-							VolumeMounts: r.getMLVolumeMounts(immich),
+							VolumeMounts: append(r.getMLVolumeMounts(immich), acceleration.getVolumeMounts()...),
 						},
 					},
-					Volumes: r.getMLVolumes(immich),
+					Volumes: append(r.getMLVolumes(workload), acceleration.getVolumes()...),
 				},
 			},
 		},
@@ -191,12 +204,8 @@ func (r *ImmichReconciler) getMLVolumeMounts(_ *mediav1alpha1.Immich) []corev1.V
 	}
 }
 
-func (r *ImmichReconciler) getMLVolumes(immich *mediav1alpha1.Immich) []corev1.Volume {
-	mlSpec := ptr.Deref(immich.Spec.MachineLearning, mediav1alpha1.MachineLearningSpec{})
-	persistence := ptr.Deref(mlSpec.Persistence, mediav1alpha1.MachineLearningPersistenceSpec{})
-
-	// Check if persistence is disabled
-	if persistence.Enabled != nil && !*persistence.Enabled {
+func (r *ImmichReconciler) getMLVolumes(workload machineLearningWorkload) []corev1.Volume {
+	if workload.cacheClaimName == "" {
 		return []corev1.Volume{
 			{
 				Name: "cache",
@@ -207,29 +216,23 @@ func (r *ImmichReconciler) getMLVolumes(immich *mediav1alpha1.Immich) []corev1.V
 		}
 	}
 
-	// Persistence is enabled (default)
-	pvcName := fmt.Sprintf("%s-ml-cache", immich.Name)
-	if persistence.ExistingClaim != nil && *persistence.ExistingClaim != "" {
-		pvcName = *persistence.ExistingClaim
-	}
-
 	return []corev1.Volume{
 		{
 			Name: "cache",
 			VolumeSource: corev1.VolumeSource{
 				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-					ClaimName: pvcName,
+					ClaimName: workload.cacheClaimName,
 				},
 			},
 		},
 	}
 }
 
-// reconcileMLService creates or updates the ML Service using server-side apply
-func (r *ImmichReconciler) reconcileMLService(ctx context.Context, immich *mediav1alpha1.Immich) error {
-	name := fmt.Sprintf("%s-machine-learning", immich.Name)
-	labels := r.getLabels(immich, "machine-learning")
-	selectorLabels := r.getSelectorLabels(immich, "machine-learning")
+// reconcileMLService creates or updates the Service of a machine learning backend using server-side apply
+func (r *ImmichReconciler) reconcileMLService(ctx context.Context, immich *mediav1alpha1.Immich, workload machineLearningWorkload) error {
+	name := workload.name
+	labels := r.getLabels(immich, workload.component)
+	selectorLabels := r.getSelectorLabels(immich, workload.component)
 
 	service := &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
@@ -268,7 +271,7 @@ func (r *ImmichReconciler) reconcileMLService(ctx context.Context, immich *media
 	return r.apply(ctx, service)
 }
 
-func (r *ImmichReconciler) reconcileMLPVC(ctx context.Context, immich *mediav1alpha1.Immich) error {
+func (r *ImmichReconciler) reconcileMLPVC(ctx context.Context, immich *mediav1alpha1.Immich, workload machineLearningWorkload) error {
 	mlSpec := ptr.Deref(immich.Spec.MachineLearning, mediav1alpha1.MachineLearningSpec{})
 	persistence := ptr.Deref(mlSpec.Persistence, mediav1alpha1.MachineLearningPersistenceSpec{})
 
@@ -276,8 +279,8 @@ func (r *ImmichReconciler) reconcileMLPVC(ctx context.Context, immich *mediav1al
 		return nil // Using existing PVC
 	}
 
-	name := fmt.Sprintf("%s-ml-cache", immich.Name)
-	labels := r.getLabels(immich, "machine-learning")
+	name := workload.cacheClaimName
+	labels := r.getLabels(immich, workload.component)
 
 	// Check if PVC already exists - PVCs are mostly immutable
 	existing := &corev1.PersistentVolumeClaim{}
@@ -332,5 +335,9 @@ func (r *ImmichReconciler) reconcileMLPVC(ctx context.Context, immich *mediav1al
 		},
 	}
 
-	return r.Create(ctx, pvc)
+	if err := r.Create(ctx, pvc); err != nil {
+		return err
+	}
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonPVCCreated, "Created PersistentVolumeClaim %s", pvc.Name)
+	return nil
 }
@@ -0,0 +1,183 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// machineLearningWorkload is a Deployment running a built-in machine learning backend, with its settings resolved
+// from the spec
+type machineLearningWorkload struct {
+	name      string
+	component string
+	// backend is the name of the backend in spec.machineLearning.backends, or empty for <name>-machine-learning
+	backend      string
+	image        string
+	acceleration string
+	replicas     *int32
+	autoscaling  *mediav1alpha1.AutoscalingSpec
+	resources    corev1.ResourceRequirements
+	env          []corev1.EnvVar
+	nodeSelector map[string]string
+	tolerations  []corev1.Toleration
+	affinity     *corev1.Affinity
+	// cacheClaimName is the PVC holding the model cache, or empty for an emptyDir
+	cacheClaimName string
+}
+
+// getMachineLearningWorkloads returns the Deployments running the built-in machine learning: the
+// <name>-machine-learning Deployment, or a <name>-machine-learning-<backend> Deployment per built-in backend,
+// in priority order. There is none when the built-in machine learning is disabled.
+func getMachineLearningWorkloads(immich *mediav1alpha1.Immich) []machineLearningWorkload {
+	if !immich.IsMachineLearningEnabled() {
+		return nil
+	}
+	mlSpec := ptr.Deref(immich.Spec.MachineLearning, mediav1alpha1.MachineLearningSpec{})
+
+	cacheClaimName := ""
+	if immich.IsMLPersistenceEnabled() {
+		cacheClaimName = immich.GetMLCachePVCName()
+	}
+
+	if !immich.HasMachineLearningBackends() {
+		return []machineLearningWorkload{{
+			name:           fmt.Sprintf("%s-machine-learning", immich.Name),
+			component:      "machine-learning",
+			image:          immich.GetMachineLearningImage(),
+			acceleration:   immich.GetMachineLearningAcceleration(),
+			replicas:       mlSpec.Replicas,
+			autoscaling:    mlSpec.Autoscaling,
+			resources:      mlSpec.Resources,
+			env:            mlSpec.Env,
+			nodeSelector:   mlSpec.NodeSelector,
+			tolerations:    mlSpec.Tolerations,
+			affinity:       mlSpec.Affinity,
+			cacheClaimName: cacheClaimName,
+		}}
+	}
+
+	var workloads []machineLearningWorkload
+	for _, backend := range mlSpec.Backends {
+		if backend.IsRemote() {
+			continue
+		}
+		image, _ := immich.GetMachineLearningBackendImageVariant(&backend)
+		workload := machineLearningWorkload{
+			name:           fmt.Sprintf("%s-machine-learning-%s", immich.Name, backend.Name),
+			component:      "machine-learning-" + backend.Name,
+			backend:        backend.Name,
+			image:          image,
+			acceleration:   immich.GetMachineLearningBackendAcceleration(&backend),
+			replicas:       mlSpec.Replicas,
+			autoscaling:    mlSpec.Autoscaling,
+			resources:      mlSpec.Resources,
+			env:            append(append([]corev1.EnvVar{}, mlSpec.Env...), backend.Env...),
+			nodeSelector:   mlSpec.NodeSelector,
+			tolerations:    mlSpec.Tolerations,
+			affinity:       mlSpec.Affinity,
+			cacheClaimName: cacheClaimName,
+		}
+		if backend.Replicas != nil {
+			workload.replicas = backend.Replicas
+		}
+		if backend.Autoscaling != nil {
+			workload.autoscaling = backend.Autoscaling
+		}
+		if backend.Resources != nil {
+			workload.resources = *backend.Resources
+		}
+		if backend.NodeSelector != nil {
+			workload.nodeSelector = backend.NodeSelector
+		}
+		if backend.Tolerations != nil {
+			workload.tolerations = backend.Tolerations
+		}
+		if backend.Affinity != nil {
+			workload.affinity = backend.Affinity
+		}
+		// Backends run on different nodes with different models, each one caches them in its own PVC
+		if immich.ShouldCreateMLCachePVC() {
+			workload.cacheClaimName = fmt.Sprintf("%s-%s", immich.GetMLCachePVCName(), backend.Name)
+		}
+		workloads = append(workloads, workload)
+	}
+	return workloads
+}
+
+// updateMachineLearningBackendsStatus reports the state of each machine learning backend rendered into the Immich
+// configuration. The machine learning is ready once all the built-in backends are: remote backends are only reported,
+// Immich fails over to the next backend when one is unreachable.
+func (r *ImmichReconciler) updateMachineLearningBackendsStatus(ctx context.Context, immich *mediav1alpha1.Immich,
+	previous, components *mediav1alpha1.ComponentsStatus) error {
+	previousStatuses := map[string]*mediav1alpha1.ComponentStatus{}
+	for _, backend := range previous.MachineLearningBackends {
+		previousStatuses[backend.Name] = &backend.ComponentStatus
+	}
+
+	immich.Status.MachineLearningReady = true
+	for _, backend := range immich.Spec.MachineLearning.Backends {
+		status := mediav1alpha1.MachineLearningBackendStatus{
+			Name:   backend.Name,
+			URL:    immich.GetMachineLearningBackendURL(&backend),
+			Remote: backend.IsRemote(),
+		}
+
+		if backend.IsRemote() {
+			ready := true
+			if err := r.pingMachineLearning(ctx, status.URL); err != nil {
+				ready = false
+				status.Message = err.Error()
+			}
+			status.ComponentStatus = *getComponentStatus(previousStatuses[backend.Name], "", 0, 0, ready)
+			components.MachineLearningBackends = append(components.MachineLearningBackends, status)
+			continue
+		}
+		if !immich.IsMachineLearningEnabled() {
+			continue
+		}
+
+		deployment := &appsv1.Deployment{}
+		name := fmt.Sprintf("%s-machine-learning-%s", immich.Name, backend.Name)
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, deployment); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			immich.Status.MachineLearningReady = false
+			status.ComponentStatus = *getComponentStatus(previousStatuses[backend.Name], "", 0, 0, false)
+			status.Message = fmt.Sprintf("Deployment %s not found", name)
+		} else {
+			ready := deployment.Status.ReadyReplicas > 0 && deployment.Status.ReadyReplicas == deployment.Status.Replicas
+			immich.Status.MachineLearningReady = immich.Status.MachineLearningReady && ready
+			status.ComponentStatus = *getDeploymentComponentStatus(previousStatuses[backend.Name], deployment, ready)
+			if !ready {
+				status.Message = fmt.Sprintf("%d/%d replicas ready", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
+			}
+		}
+		components.MachineLearningBackends = append(components.MachineLearningBackends, status)
+	}
+	return nil
+}
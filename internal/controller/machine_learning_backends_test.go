@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestReconcileMachineLearning_Backends(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImageMachineLearning, "ghcr.io/immich-app/immich-machine-learning:v1.125.7")
+
+	immich := newTestImmich(withMachineLearningBackends())
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+	if err := r.reconcileMachineLearning(context.Background(), immich); err != nil {
+		t.Fatalf("reconcileMachineLearning() error = %v", err)
+	}
+
+	if _, ok := applied["Deployment/test-immich-machine-learning"]; ok {
+		t.Error("the default machine learning Deployment should not be created with backends")
+	}
+	if _, ok := applied["Deployment/test-immich-machine-learning-desktop"]; ok {
+		t.Error("a remote backend should not be deployed")
+	}
+	for _, name := range []string{"test-immich-machine-learning-gpu", "test-immich-machine-learning-cpu"} {
+		if _, ok := applied["Service/"+name]; !ok {
+			t.Errorf("Service %s not applied", name)
+		}
+	}
+
+	gpu := applied["Deployment/test-immich-machine-learning-gpu"].(*appsv1.Deployment)
+	container := gpu.Spec.Template.Spec.Containers[0]
+	if container.Image != "ghcr.io/immich-app/immich-machine-learning:v1.125.7-cuda" {
+		t.Errorf("gpu image = %q, want the cuda variant", container.Image)
+	}
+	if _, ok := container.Resources.Limits[resourceNvidiaGPU]; !ok {
+		t.Errorf("gpu resources = %v, want a GPU requested", container.Resources)
+	}
+	if ptr.Deref(gpu.Spec.Replicas, 0) != 2 || gpu.Spec.Template.Spec.NodeSelector["pool"] != "gpu" {
+		t.Errorf("gpu replicas = %v, nodeSelector = %v", gpu.Spec.Replicas, gpu.Spec.Template.Spec.NodeSelector)
+	}
+	if env := findEnv(container.Env, "MACHINE_LEARNING_WORKERS"); env == nil {
+		t.Error("the environment of spec.machineLearning should be inherited by the backends")
+	}
+	if claim := gpu.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim; claim == nil || claim.ClaimName != "test-immich-ml-cache-gpu" {
+		t.Errorf("gpu cache = %+v, want its own PVC", gpu.Spec.Template.Spec.Volumes[0])
+	}
+
+	cpu := applied["Deployment/test-immich-machine-learning-cpu"].(*appsv1.Deployment)
+	if image := cpu.Spec.Template.Spec.Containers[0].Image; image != "ghcr.io/immich-app/immich-machine-learning:v1.125.7" {
+		t.Errorf("cpu image = %q", image)
+	}
+	if selector := cpu.Spec.Selector.MatchLabels["app.kubernetes.io/component"]; selector != "machine-learning-cpu" {
+		t.Errorf("cpu selector = %v, want the pods of the backend only", cpu.Spec.Selector.MatchLabels)
+	}
+
+	for _, name := range []string{"test-immich-ml-cache-gpu", "test-immich-ml-cache-cpu"} {
+		if err := r.Get(context.Background(), client.ObjectKey{Name: name, Namespace: "default"}, &corev1.PersistentVolumeClaim{}); err != nil {
+			t.Errorf("PVC %s: %v", name, err)
+		}
+	}
+}
+
+func TestApplyMLConfigMap_Backends(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		wantURLs []string
+	}{
+		{
+			name:    "built-in and remote backends in priority order",
+			enabled: true,
+			wantURLs: []string{
+				"http://test-immich-machine-learning-gpu:3003",
+				"http://desktop-gpu.lan:3003",
+				"http://test-immich-machine-learning-cpu:3003",
+			},
+		},
+		{
+			name:     "remote backends only when the built-in machine learning is disabled",
+			enabled:  false,
+			wantURLs: []string{"http://desktop-gpu.lan:3003"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := newTestImmich(withMachineLearningBackends())
+			immich.Spec.MachineLearning.Enabled = ptr.To(tt.enabled)
+
+			config := map[string]interface{}{}
+			(&ImmichReconciler{}).applyMLConfigMap(immich, config)
+
+			mlConfig := config["machineLearning"].(map[string]interface{})
+			if mlConfig["enabled"] != true || !reflect.DeepEqual(mlConfig["urls"], tt.wantURLs) {
+				t.Errorf("machineLearning = %v, want urls %v", mlConfig, tt.wantURLs)
+			}
+		})
+	}
+}
+
+func TestUpdateMachineLearningBackendsStatus(t *testing.T) {
+	immich := newTestImmich(withMachineLearningBackends())
+	gpu := newComponentDeployment("test-immich-machine-learning-gpu", "ghcr.io/immich-app/immich-machine-learning:v1.125.7-cuda", 2)
+	gpu.Status = appsv1.DeploymentStatus{Replicas: 2, ReadyReplicas: 2}
+	cpu := newComponentDeployment("test-immich-machine-learning-cpu", "ghcr.io/immich-app/immich-machine-learning:v1.125.7", 1)
+	cpu.Status = appsv1.DeploymentStatus{Replicas: 1}
+
+	r := &ImmichReconciler{
+		Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(gpu, cpu).Build(),
+		HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.String() != "http://desktop-gpu.lan:3003/ping" {
+				return nil, fmt.Errorf("unexpected request %s", req.URL)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader("pong"))}, nil
+		})},
+	}
+
+	components := &mediav1alpha1.ComponentsStatus{}
+	if err := r.updateMachineLearningBackendsStatus(context.Background(), immich, &mediav1alpha1.ComponentsStatus{}, components); err != nil {
+		t.Fatalf("updateMachineLearningBackendsStatus() error = %v", err)
+	}
+
+	if immich.Status.MachineLearningReady {
+		t.Error("machine learning should not be ready while the cpu backend is not")
+	}
+	want := map[string]bool{"gpu": true, "desktop": true, "cpu": false}
+	if len(components.MachineLearningBackends) != len(want) {
+		t.Fatalf("backends = %+v, want %d", components.MachineLearningBackends, len(want))
+	}
+	for i, name := range []string{"gpu", "desktop", "cpu"} {
+		status := components.MachineLearningBackends[i]
+		if status.Name != name || status.Ready != want[name] {
+			t.Errorf("backend %d = %+v, want %s ready=%v", i, status, name, want[name])
+		}
+	}
+	if desktop := components.MachineLearningBackends[1]; !desktop.Remote || desktop.URL != "http://desktop-gpu.lan:3003" {
+		t.Errorf("desktop = %+v, want a remote backend", desktop)
+	}
+	if cpuStatus := components.MachineLearningBackends[2]; cpuStatus.Message == "" || cpuStatus.Replicas != 1 {
+		t.Errorf("cpu = %+v, want the replicas of its Deployment and a message", cpuStatus)
+	}
+}
@@ -43,8 +43,14 @@ func (r *ImmichReconciler) reconcilePostgres(ctx context.Context, immich *mediav
 		return err
 	}
 
+	// Handle image changes: major version upgrades keep the StatefulSet on the previous image, or stop it
+	image, replicas, err := r.reconcilePostgresUpgrade(ctx, immich)
+	if err != nil {
+		return err
+	}
+
 	// Create PostgreSQL StatefulSet (with VolumeClaimTemplate for data persistence)
-	if err := r.reconcilePostgresStatefulSet(ctx, immich); err != nil {
+	if err := r.reconcilePostgresStatefulSet(ctx, immich, image, replicas); err != nil {
 		return err
 	}
 
@@ -53,6 +59,13 @@ func (r *ImmichReconciler) reconcilePostgres(ctx context.Context, immich *mediav
 		return err
 	}
 
+	// Create PostgreSQL backup CronJob if backups are enabled
+	if immich.IsPostgresBackupEnabled() {
+		if err := r.reconcilePostgresBackup(ctx, immich); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -110,13 +123,24 @@ func (r *ImmichReconciler) reconcilePostgresCredentials(ctx context.Context, imm
 	// staying consistent with the PostgreSQL PVC data.
 
 	log.Info("Creating PostgreSQL credentials secret (no owner reference for data safety)", "name", secretName)
-	return r.Create(ctx, secret)
+	if err := r.Create(ctx, secret); err != nil {
+		return err
+	}
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonCredentialsGenerated, "Generated PostgreSQL credentials in Secret %s", secretName)
+	return nil
 }
 
 // getPostgresPasswordSecretRef returns the secret reference for PostgreSQL password
 // Returns generated secret name if no explicit credentials are provided
-func (r *ImmichReconciler) getPostgresPasswordSecretRef(immich *mediav1alpha1.Immich) *mediav1alpha1.SecretKeySelector {
+func getPostgresPasswordSecretRef(immich *mediav1alpha1.Immich) *mediav1alpha1.SecretKeySelector {
 	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+	if immich.IsCloudNativePGEnabled() {
+		// Generated by CloudNativePG
+		return &mediav1alpha1.SecretKeySelector{
+			Name: getCloudNativePGAppSecretName(immich),
+			Key:  "password",
+		}
+	}
 	if postgresSpec.PasswordSecretRef != nil {
 		return postgresSpec.PasswordSecretRef
 	}
@@ -127,21 +151,21 @@ func (r *ImmichReconciler) getPostgresPasswordSecretRef(immich *mediav1alpha1.Im
 	}
 }
 
-// reconcilePostgresStatefulSet creates or updates the PostgreSQL StatefulSet using server-side apply
-func (r *ImmichReconciler) reconcilePostgresStatefulSet(ctx context.Context, immich *mediav1alpha1.Immich) error {
+// reconcilePostgresStatefulSet creates or updates the PostgreSQL StatefulSet using server-side apply.
+// The image and replicas are decided by reconcilePostgresUpgrade.
+func (r *ImmichReconciler) reconcilePostgresStatefulSet(ctx context.Context, immich *mediav1alpha1.Immich, image string, replicas int32) error {
 	name := fmt.Sprintf("%s-postgres", immich.Name)
 	labels := r.getLabels(immich, "postgres")
 
 	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
 	persistence := ptr.Deref(postgresSpec.Persistence, mediav1alpha1.PostgresPersistenceSpec{})
 
-	image := immich.GetPostgresImage()
 	if image == "" {
 		return fmt.Errorf("PostgreSQL image not configured: set spec.postgres.image or RELATED_IMAGE_postgres environment variable")
 	}
 
 	// Get password from secret (user-provided or auto-generated)
-	secretRef := r.getPostgresPasswordSecretRef(immich)
+	secretRef := getPostgresPasswordSecretRef(immich)
 	passwordEnvVar := corev1.EnvVar{
 		Name: "POSTGRES_PASSWORD",
 		ValueFrom: &corev1.EnvVarSource{
@@ -237,7 +261,7 @@ func (r *ImmichReconciler) reconcilePostgresStatefulSet(ctx context.Context, imm
 			},
 		},
 		Spec: appsv1.StatefulSetSpec{
-			Replicas: ptr.To(int32(1)),
+			Replicas: ptr.To(replicas),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
@@ -249,12 +273,13 @@ func (r *ImmichReconciler) reconcilePostgresStatefulSet(ctx context.Context, imm
 					Annotations: postgresSpec.PodAnnotations,
 				},
 				Spec: corev1.PodSpec{
-					ImagePullSecrets: immich.Spec.ImagePullSecrets,
-					SecurityContext:  postgresSpec.PodSecurityContext,
-					NodeSelector:     postgresSpec.NodeSelector,
-					Tolerations:      postgresSpec.Tolerations,
-					Affinity:         postgresSpec.Affinity,
-					Volumes:          volumes,
+					ImagePullSecrets:          immich.Spec.ImagePullSecrets,
+					SecurityContext:           postgresSpec.PodSecurityContext,
+					NodeSelector:              postgresSpec.NodeSelector,
+					Tolerations:               postgresSpec.Tolerations,
+					Affinity:                  postgresSpec.Affinity,
+					TopologySpreadConstraints: getTopologySpreadConstraints(postgresSpec.TopologySpreadConstraints, r.getSelectorLabels(immich, "postgres")),
+					Volumes:                   volumes,
 					Containers: []corev1.Container{
 						{
 							Name:            "postgres",
@@ -339,3 +364,19 @@ func (r *ImmichReconciler) reconcilePostgresService(ctx context.Context, immich
 
 	return r.apply(ctx, svc)
 }
+
+// reconcileExternalPostgres renders the DB_URL of the server when the external PostgreSQL connection
+// uses TLS, then checks that the database can run Immich
+func (r *ImmichReconciler) reconcileExternalPostgres(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	if immich.GetPostgresTLS() != nil {
+		if err := r.reconcileDatabaseURLSecret(ctx, immich); err != nil {
+			if apierrors.IsNotFound(err) {
+				setDatabaseReadyCondition(immich, metav1.ConditionFalse, "CredentialsNotFound",
+					fmt.Sprintf("Cannot check the database: %v", err))
+				return nil
+			}
+			return err
+		}
+	}
+	return r.reconcileDatabasePreflight(ctx, immich)
+}
@@ -0,0 +1,820 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+const (
+	// postgresDataDir is the mount path of the PostgreSQL data PVC (PGDATA)
+	postgresDataDir = "/var/lib/postgresql/data"
+
+	// upgradeDir is the mount path of the upgrade PVC in the upgrade Jobs
+	upgradeDir = "/upgrade"
+)
+
+// Deadlines of the upgrade Jobs, including the time to schedule their pod: a Job that cannot run, e.g. waiting for
+// a volume attached to another node, fails instead of holding the upgrade forever
+const (
+	// postgresVersionCheckDeadline bounds the version check, which only reads PG_VERSION
+	postgresVersionCheckDeadline = 10 * time.Minute
+	// upgradeJobDeadline bounds the dumps, restores and copies of the data directory, which grow with the library
+	upgradeJobDeadline = 6 * time.Hour
+)
+
+// Steps of a PostgreSQL upgrade, each run by its own Job named <immich-name>-postgres-upgrade-<step>
+const (
+	upgradeStepVersionCheck = "version-check"
+	upgradeStepDump         = "dump"
+	upgradeStepMoveData     = "move-data"
+	upgradeStepRestore      = "restore"
+	upgradeStepRollback     = "rollback"
+)
+
+var upgradeSteps = []string{
+	upgradeStepVersionCheck, upgradeStepDump, upgradeStepMoveData, upgradeStepRestore, upgradeStepRollback,
+}
+
+// reconcilePostgresUpgrade handles changes of the PostgreSQL image and returns the image and replicas
+// of the PostgreSQL StatefulSet.
+//
+// A Job running the new image first compares the major version of the data directory (PG_VERSION)
+// with the one of the image. Minor version changes are rolled out directly. Major version changes are
+// orchestrated as follows, with the Immich server scaled down:
+//  1. Dumping: the database is dumped with pg_dumpall by the previous version to the upgrade PVC
+//  2. StoppingDatabase: the PostgreSQL StatefulSet is scaled down
+//  3. MovingData: the data directory is copied to the upgrade PVC, then emptied
+//  4. Starting: PostgreSQL starts with the new image, initializing a new data directory
+//  5. Restoring: the dump is restored into the new version
+//
+// Setting spec.postgres.image back to the previous image cancels the upgrade, or, once the data
+// directory was touched, rolls it back by copying the previous data directory back.
+func (r *ImmichReconciler) reconcilePostgresUpgrade(ctx context.Context, immich *mediav1alpha1.Immich) (string, int32, error) {
+	log := logf.FromContext(ctx)
+
+	target := immich.GetPostgresImage()
+	upgrade := immich.Status.PostgresUpgrade
+
+	if upgrade == nil {
+		current := immich.Status.PostgresImage
+		if current == "" {
+			// Adopt the image of a StatefulSet created before the image was tracked in the status
+			var err error
+			if current, err = r.getPostgresStatefulSetImage(ctx, immich); err != nil {
+				return "", 0, err
+			}
+		}
+		if current == "" || current == target {
+			immich.Status.PostgresImage = target
+			return target, 1, nil
+		}
+
+		log.Info("PostgreSQL image changed, checking the major version", "from", current, "to", target)
+		upgrade = &mediav1alpha1.PostgresUpgradeStatus{
+			Phase:     mediav1alpha1.PostgresUpgradePhaseVersionCheck,
+			FromImage: current,
+			ToImage:   target,
+		}
+		immich.Status.PostgresUpgrade = upgrade
+	}
+
+	if upgrade.Phase != mediav1alpha1.PostgresUpgradePhaseRollingBack {
+		switch {
+		case target == upgrade.FromImage:
+			// The data directory is untouched until the data is moved
+			switch upgrade.Phase {
+			case mediav1alpha1.PostgresUpgradePhaseVersionCheck,
+				mediav1alpha1.PostgresUpgradePhaseDumping,
+				mediav1alpha1.PostgresUpgradePhaseStoppingDatabase:
+				return r.finishPostgresUpgrade(ctx, immich, upgrade.FromImage, EventReasonUpgradeCancelled,
+					fmt.Sprintf("The PostgreSQL image change was cancelled, keeping %s", upgrade.FromImage))
+			}
+			log.Info("Rolling back PostgreSQL upgrade", "to", upgrade.FromImage)
+			upgrade.Phase = mediav1alpha1.PostgresUpgradePhaseRollingBack
+		case target != upgrade.ToImage && upgrade.Phase == mediav1alpha1.PostgresUpgradePhaseVersionCheck:
+			// The version check of the previous target is obsolete
+			if err := r.deletePostgresUpgradeJob(ctx, immich, upgradeStepVersionCheck); err != nil {
+				return "", 0, err
+			}
+			upgrade.ToImage = target
+		case target != upgrade.ToImage:
+			setUpgradingCondition(immich, metav1.ConditionTrue, "TargetChanged",
+				fmt.Sprintf("spec.postgres.image changed during the upgrade to %s: set it back to %s to continue the upgrade, or to %s to roll it back",
+					upgrade.ToImage, upgrade.ToImage, upgrade.FromImage))
+			image, replicas := getPostgresUpgradeStatefulSetState(upgrade)
+			return image, replicas, nil
+		}
+	}
+
+	switch upgrade.Phase {
+	case mediav1alpha1.PostgresUpgradePhaseVersionCheck:
+		return r.reconcilePostgresVersionCheck(ctx, immich)
+	case mediav1alpha1.PostgresUpgradePhaseDumping:
+		return r.reconcilePostgresUpgradeDump(ctx, immich)
+	case mediav1alpha1.PostgresUpgradePhaseStoppingDatabase:
+		stopped, err := r.isPostgresStopped(ctx, immich)
+		if err != nil {
+			return "", 0, err
+		}
+		if stopped {
+			upgrade.Phase = mediav1alpha1.PostgresUpgradePhaseMovingData
+		}
+		setUpgradingCondition(immich, metav1.ConditionTrue, "StoppingDatabase", fmt.Sprintf("Stopping PostgreSQL %s", upgrade.FromVersion))
+		return upgrade.FromImage, 0, nil
+	case mediav1alpha1.PostgresUpgradePhaseMovingData:
+		return r.reconcilePostgresUpgradeMoveData(ctx, immich)
+	case mediav1alpha1.PostgresUpgradePhaseStarting:
+		started, err := r.isPostgresRunning(ctx, immich, upgrade.ToImage)
+		if err != nil {
+			return "", 0, err
+		}
+		if started {
+			upgrade.Phase = mediav1alpha1.PostgresUpgradePhaseRestoring
+		}
+		setUpgradingCondition(immich, metav1.ConditionTrue, "StartingDatabase", fmt.Sprintf("Starting PostgreSQL %s", upgrade.ToVersion))
+		return upgrade.ToImage, 1, nil
+	case mediav1alpha1.PostgresUpgradePhaseRestoring:
+		return r.reconcilePostgresUpgradeRestore(ctx, immich)
+	case mediav1alpha1.PostgresUpgradePhaseRollingBack:
+		return r.reconcilePostgresUpgradeRollback(ctx, immich)
+	}
+
+	return "", 0, fmt.Errorf("unknown PostgreSQL upgrade phase %q", upgrade.Phase)
+}
+
+// reconcilePostgresVersionCheck compares the major version of the data directory with the one of the new image
+func (r *ImmichReconciler) reconcilePostgresVersionCheck(ctx context.Context, immich *mediav1alpha1.Immich) (string, int32, error) {
+	log := logf.FromContext(ctx)
+	upgrade := immich.Status.PostgresUpgrade
+
+	job, err := r.ensurePostgresUpgradeJob(ctx, immich, postgresUpgradeJob{
+		step:           upgradeStepVersionCheck,
+		image:          upgrade.ToImage,
+		script:         postgresVersionCheckScript,
+		mountData:      true,
+		dataReadOnly:   true,
+		nearPostgresDB: true,
+		deadline:       postgresVersionCheckDeadline,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch {
+	case isJobFailed(job):
+		r.failPostgresUpgrade(immich, EventReasonVersionCheckFailed,
+			fmt.Sprintf("The version check Job %s failed or did not finish within %s: delete it to retry, or set spec.postgres.image back to %s",
+				job.Name, postgresVersionCheckDeadline, upgrade.FromImage))
+		return upgrade.FromImage, 1, nil
+	case job.Status.Succeeded == 0:
+		setUpgradingCondition(immich, metav1.ConditionTrue, "CheckingVersion", fmt.Sprintf("Checking the PostgreSQL major version of %s", upgrade.ToImage))
+		return upgrade.FromImage, 1, nil
+	}
+
+	fromVersion, toVersion, err := r.getPostgresVersions(ctx, job)
+	if err != nil {
+		// The result is lost (e.g. the pod was deleted): run the check again
+		log.Error(err, "Failed to read the PostgreSQL version check result, running it again")
+		return upgrade.FromImage, 1, r.deletePostgresUpgradeJob(ctx, immich, upgradeStepVersionCheck)
+	}
+	upgrade.FromVersion = fromVersion
+	upgrade.ToVersion = toVersion
+
+	if fromVersion == "" || fromVersion == toVersion {
+		return r.finishPostgresUpgrade(ctx, immich, upgrade.ToImage, EventReasonUpToDate,
+			fmt.Sprintf("PostgreSQL %s runs the data directory as is", upgrade.ToImage))
+	}
+
+	from, fromErr := strconv.Atoi(fromVersion)
+	to, toErr := strconv.Atoi(toVersion)
+	if fromErr != nil || toErr != nil || to < from {
+		r.failPostgresUpgrade(immich, EventReasonUnsupportedVersionChange,
+			fmt.Sprintf("Cannot move the PostgreSQL %s data directory to PostgreSQL %s: set spec.postgres.image back to %s",
+				fromVersion, toVersion, upgrade.FromImage))
+		return upgrade.FromImage, 1, nil
+	}
+
+	if !immich.IsPostgresUpgradeEnabled() {
+		r.failPostgresUpgrade(immich, EventReasonUpgradeDisabled,
+			fmt.Sprintf("Upgrading PostgreSQL from %s to %s requires spec.postgres.upgrade.enabled, still running %s",
+				fromVersion, toVersion, upgrade.FromImage))
+		return upgrade.FromImage, 1, nil
+	}
+
+	log.Info("Upgrading PostgreSQL major version", "from", fromVersion, "to", toVersion)
+	upgrade.Phase = mediav1alpha1.PostgresUpgradePhaseDumping
+	upgrade.BackupPath = fmt.Sprintf("pg%s-to-pg%s-%s", fromVersion, toVersion, time.Now().UTC().Format("20060102150405"))
+	setUpgradingCondition(immich, metav1.ConditionTrue, "Upgrading", fmt.Sprintf("Upgrading PostgreSQL from %s to %s", fromVersion, toVersion))
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonPostgresUpgradeStarted, "Upgrading PostgreSQL from %s to %s", fromVersion, toVersion)
+	return upgrade.FromImage, 1, nil
+}
+
+// reconcilePostgresUpgradeDump dumps the database with the previous PostgreSQL version, once the server is scaled down
+func (r *ImmichReconciler) reconcilePostgresUpgradeDump(ctx context.Context, immich *mediav1alpha1.Immich) (string, int32, error) {
+	upgrade := immich.Status.PostgresUpgrade
+
+	if err := r.reconcilePostgresUpgradePVC(ctx, immich); err != nil {
+		return "", 0, err
+	}
+
+	scaledDown, err := isServerScaledDown(ctx, r.Client, immich)
+	if err != nil {
+		return "", 0, err
+	}
+	if !scaledDown {
+		setUpgradingCondition(immich, metav1.ConditionTrue, "ScalingDownServer", "Scaling the Immich server down before the upgrade")
+		return upgrade.FromImage, 1, nil
+	}
+
+	job, err := r.ensurePostgresUpgradeJob(ctx, immich, postgresUpgradeJob{
+		step:          upgradeStepDump,
+		image:         upgrade.FromImage,
+		script:        postgresUpgradeDumpScript,
+		env:           getPostgresClientEnv(immich),
+		mountUpgrade:  true,
+		upgradeBackup: upgrade.BackupPath,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch {
+	case isJobFailed(job):
+		r.failPostgresUpgrade(immich, EventReasonDumpFailed,
+			fmt.Sprintf("The dump Job %s failed: delete it to retry, or set spec.postgres.image back to %s", job.Name, upgrade.FromImage))
+	case job.Status.Succeeded > 0:
+		upgrade.Phase = mediav1alpha1.PostgresUpgradePhaseStoppingDatabase
+		setUpgradingCondition(immich, metav1.ConditionTrue, "StoppingDatabase", fmt.Sprintf("Stopping PostgreSQL %s", upgrade.FromVersion))
+		return upgrade.FromImage, 0, nil
+	default:
+		setUpgradingCondition(immich, metav1.ConditionTrue, "Dumping", fmt.Sprintf("Dumping the database with PostgreSQL %s", upgrade.FromVersion))
+	}
+	return upgrade.FromImage, 1, nil
+}
+
+// reconcilePostgresUpgradeMoveData copies the previous data directory to the upgrade PVC, then empties it
+func (r *ImmichReconciler) reconcilePostgresUpgradeMoveData(ctx context.Context, immich *mediav1alpha1.Immich) (string, int32, error) {
+	upgrade := immich.Status.PostgresUpgrade
+
+	job, err := r.ensurePostgresUpgradeJob(ctx, immich, postgresUpgradeJob{
+		step:          upgradeStepMoveData,
+		image:         upgrade.FromImage,
+		script:        postgresUpgradeMoveDataScript,
+		env:           []corev1.EnvVar{{Name: "FROM_VERSION", Value: upgrade.FromVersion}},
+		mountData:     true,
+		mountUpgrade:  true,
+		upgradeBackup: upgrade.BackupPath,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch {
+	case isJobFailed(job):
+		r.failPostgresUpgrade(immich, EventReasonMoveDataFailed,
+			fmt.Sprintf("The Job %s moving the data directory failed: delete it to retry, or set spec.postgres.image back to %s to roll back",
+				job.Name, upgrade.FromImage))
+	case job.Status.Succeeded > 0:
+		upgrade.Phase = mediav1alpha1.PostgresUpgradePhaseStarting
+		setUpgradingCondition(immich, metav1.ConditionTrue, "StartingDatabase", fmt.Sprintf("Starting PostgreSQL %s", upgrade.ToVersion))
+		return upgrade.ToImage, 1, nil
+	default:
+		setUpgradingCondition(immich, metav1.ConditionTrue, "MovingData",
+			fmt.Sprintf("Moving the PostgreSQL %s data directory to PVC %s", upgrade.FromVersion, immich.GetPostgresUpgradePVCName()))
+	}
+	return upgrade.FromImage, 0, nil
+}
+
+// reconcilePostgresUpgradeRestore restores the dump into the new PostgreSQL version
+func (r *ImmichReconciler) reconcilePostgresUpgradeRestore(ctx context.Context, immich *mediav1alpha1.Immich) (string, int32, error) {
+	upgrade := immich.Status.PostgresUpgrade
+
+	job, err := r.ensurePostgresUpgradeJob(ctx, immich, postgresUpgradeJob{
+		step:          upgradeStepRestore,
+		image:         upgrade.ToImage,
+		script:        postgresUpgradeRestoreScript,
+		env:           getPostgresClientEnv(immich),
+		mountUpgrade:  true,
+		upgradeBackup: upgrade.BackupPath,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch {
+	case isJobFailed(job):
+		r.failPostgresUpgrade(immich, EventReasonRestoreFailed,
+			fmt.Sprintf("The restore Job %s failed: delete it to retry, or set spec.postgres.image back to %s to roll back",
+				job.Name, upgrade.FromImage))
+	case job.Status.Succeeded > 0:
+		return r.finishPostgresUpgrade(ctx, immich, upgrade.ToImage, EventReasonUpgradeSucceeded,
+			fmt.Sprintf("Upgraded PostgreSQL from %s to %s. The dump and the previous data directory are kept in PVC %s under %s",
+				upgrade.FromVersion, upgrade.ToVersion, immich.GetPostgresUpgradePVCName(), upgrade.BackupPath))
+	default:
+		setUpgradingCondition(immich, metav1.ConditionTrue, "Restoring", fmt.Sprintf("Restoring the dump into PostgreSQL %s", upgrade.ToVersion))
+	}
+	return upgrade.ToImage, 1, nil
+}
+
+// reconcilePostgresUpgradeRollback copies the previous data directory back, once PostgreSQL is stopped
+func (r *ImmichReconciler) reconcilePostgresUpgradeRollback(ctx context.Context, immich *mediav1alpha1.Immich) (string, int32, error) {
+	upgrade := immich.Status.PostgresUpgrade
+	setUpgradingCondition(immich, metav1.ConditionTrue, "RollingBack", fmt.Sprintf("Rolling back to PostgreSQL %s", upgrade.FromVersion))
+
+	stopped, err := r.isPostgresStopped(ctx, immich)
+	if err != nil {
+		return "", 0, err
+	}
+	if !stopped {
+		return upgrade.FromImage, 0, nil
+	}
+
+	// Never run concurrently with the Job moving the data directory
+	moveJob := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: getPostgresUpgradeJobName(immich, upgradeStepMoveData), Namespace: immich.Namespace}, moveJob)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", 0, err
+	}
+	if err == nil && moveJob.Status.Active > 0 {
+		return upgrade.FromImage, 0, nil
+	}
+
+	job, err := r.ensurePostgresUpgradeJob(ctx, immich, postgresUpgradeJob{
+		step:          upgradeStepRollback,
+		image:         upgrade.FromImage,
+		script:        postgresUpgradeRollbackScript,
+		mountData:     true,
+		mountUpgrade:  true,
+		upgradeBackup: upgrade.BackupPath,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch {
+	case isJobFailed(job):
+		r.failPostgresUpgrade(immich, EventReasonRollbackFailed,
+			fmt.Sprintf("The rollback Job %s failed: delete it to retry. The previous data directory is kept in PVC %s under %s",
+				job.Name, immich.GetPostgresUpgradePVCName(), upgrade.BackupPath))
+	case job.Status.Succeeded > 0:
+		return r.finishPostgresUpgrade(ctx, immich, upgrade.FromImage, EventReasonRolledBack,
+			fmt.Sprintf("Rolled back to PostgreSQL %s with its previous data directory", upgrade.FromVersion))
+	}
+	return upgrade.FromImage, 0, nil
+}
+
+// finishPostgresUpgrade records the image PostgreSQL now runs and deletes the upgrade Jobs.
+// The upgrade PVC is kept, so that the dump and the previous data directory remain available.
+func (r *ImmichReconciler) finishPostgresUpgrade(ctx context.Context, immich *mediav1alpha1.Immich, image, reason, message string) (string, int32, error) {
+	logf.FromContext(ctx).Info("PostgreSQL image change finished", "image", image, "reason", reason)
+
+	for _, step := range upgradeSteps {
+		if err := r.deletePostgresUpgradeJob(ctx, immich, step); err != nil {
+			return "", 0, err
+		}
+	}
+
+	immich.Status.PostgresImage = image
+	immich.Status.PostgresUpgrade = nil
+	setUpgradingCondition(immich, metav1.ConditionFalse, reason, message)
+	r.recordEvent(immich, corev1.EventTypeNormal, reason, "%s", message)
+	return image, 1, nil
+}
+
+// failPostgresUpgrade reports a PostgreSQL image change that cannot proceed without user action
+func (r *ImmichReconciler) failPostgresUpgrade(immich *mediav1alpha1.Immich, reason, message string) {
+	setUpgradingCondition(immich, metav1.ConditionFalse, reason, message)
+	r.recordEvent(immich, corev1.EventTypeWarning, reason, "%s", message)
+}
+
+// getPostgresUpgradeStatefulSetState returns the image and replicas of the PostgreSQL StatefulSet in the current upgrade phase
+func getPostgresUpgradeStatefulSetState(upgrade *mediav1alpha1.PostgresUpgradeStatus) (string, int32) {
+	switch upgrade.Phase {
+	case mediav1alpha1.PostgresUpgradePhaseStarting, mediav1alpha1.PostgresUpgradePhaseRestoring:
+		return upgrade.ToImage, 1
+	case mediav1alpha1.PostgresUpgradePhaseStoppingDatabase,
+		mediav1alpha1.PostgresUpgradePhaseMovingData,
+		mediav1alpha1.PostgresUpgradePhaseRollingBack:
+		return upgrade.FromImage, 0
+	}
+	return upgrade.FromImage, 1
+}
+
+// getPostgresStatefulSetImage returns the image of the existing PostgreSQL StatefulSet, or an empty string
+func (r *ImmichReconciler) getPostgresStatefulSetImage(ctx context.Context, immich *mediav1alpha1.Immich) (string, error) {
+	sts := &appsv1.StatefulSet{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-postgres", immich.Name), Namespace: immich.Namespace}, sts)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, container := range sts.Spec.Template.Spec.Containers {
+		if container.Name == "postgres" {
+			return container.Image, nil
+		}
+	}
+	return "", nil
+}
+
+// isPostgresStopped returns true once the PostgreSQL StatefulSet has no replica left
+func (r *ImmichReconciler) isPostgresStopped(ctx context.Context, immich *mediav1alpha1.Immich) (bool, error) {
+	sts := &appsv1.StatefulSet{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-postgres", immich.Name), Namespace: immich.Namespace}, sts)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return ptr.Deref(sts.Spec.Replicas, 1) == 0 && sts.Status.Replicas == 0, nil
+}
+
+// isPostgresRunning returns true once the PostgreSQL StatefulSet runs the image and is ready
+func (r *ImmichReconciler) isPostgresRunning(ctx context.Context, immich *mediav1alpha1.Immich, image string) (bool, error) {
+	current, err := r.getPostgresStatefulSetImage(ctx, immich)
+	if err != nil || current != image {
+		return false, err
+	}
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-postgres", immich.Name), Namespace: immich.Namespace}, sts); err != nil {
+		return false, err
+	}
+	return sts.Status.ObservedGeneration >= sts.Generation &&
+		sts.Status.ReadyReplicas > 0 &&
+		sts.Status.UpdatedReplicas == sts.Status.Replicas, nil
+}
+
+// getPostgresVersions returns the major versions of the data directory and of the new image,
+// written by the version check Job to its termination message
+func (r *ImmichReconciler) getPostgresVersions(ctx context.Context, job *batchv1.Job) (string, string, error) {
+	pods := &corev1.PodList{}
+	if err := r.apiReader().List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+		return "", "", err
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil || terminated.ExitCode != 0 {
+				continue
+			}
+			switch fields := strings.Fields(terminated.Message); len(fields) {
+			case 1:
+				// Empty data directory
+				return "", fields[0], nil
+			case 2:
+				return fields[0], fields[1], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("no result found for the version check Job %s", job.Name)
+}
+
+// reconcilePostgresUpgradePVC creates the PVC holding the dump and the previous data directory if needed.
+// Note: The upgrade PVC does NOT have an owner reference, so that the previous data survives the Immich CR.
+func (r *ImmichReconciler) reconcilePostgresUpgradePVC(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+
+	name := immich.GetPostgresUpgradePVCName()
+
+	existing := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, existing)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+	upgradeSpec := ptr.Deref(postgresSpec.Upgrade, mediav1alpha1.PostgresUpgradeSpec{})
+
+	size := resource.MustParse("10Gi")
+	if postgresSpec.Persistence != nil && postgresSpec.Persistence.Size != nil && !postgresSpec.Persistence.Size.IsZero() {
+		size = *postgresSpec.Persistence.Size
+	}
+	// Room for both the dump and the copy of the data directory
+	size = *resource.NewQuantity(2*size.Value(), resource.BinarySI)
+	if upgradeSpec.Size != nil && !upgradeSpec.Size.IsZero() {
+		size = *upgradeSpec.Size
+	}
+
+	storageClass := upgradeSpec.StorageClass
+	if storageClass == nil && postgresSpec.Persistence != nil {
+		storageClass = postgresSpec.Persistence.StorageClass
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    r.getLabels(immich, "postgres-upgrade"),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: storageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+
+	log.Info("Creating PostgreSQL upgrade PVC (no owner reference for data safety)", "name", name, "size", size.String())
+	if err := r.Create(ctx, pvc); err != nil {
+		return err
+	}
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonPVCCreated, "Created PersistentVolumeClaim %s", pvc.Name)
+	return nil
+}
+
+// postgresUpgradeJob describes a Job running a step of a PostgreSQL upgrade
+type postgresUpgradeJob struct {
+	step   string
+	image  string
+	script string
+	env    []corev1.EnvVar
+
+	// mountData mounts the PostgreSQL data PVC at its usual location
+	mountData    bool
+	dataReadOnly bool
+	// nearPostgresDB runs the Job on the node of the PostgreSQL pod, the only one where a ReadWriteOnce data PVC
+	// still used by PostgreSQL can be mounted
+	nearPostgresDB bool
+
+	// deadline bounds the run of the Job, defaulting to upgradeJobDeadline
+	deadline time.Duration
+
+	// mountUpgrade mounts the upgrade PVC, with UPGRADE_BACKUP_DIR pointing to the directory of this upgrade
+	mountUpgrade  bool
+	upgradeBackup string
+}
+
+// ensurePostgresUpgradeJob returns the Job of an upgrade step, creating it if needed.
+// Jobs are kept until the upgrade finishes, so that a failed step can be inspected and retried by deleting its Job.
+func (r *ImmichReconciler) ensurePostgresUpgradeJob(ctx context.Context, immich *mediav1alpha1.Immich, spec postgresUpgradeJob) (*batchv1.Job, error) {
+	name := getPostgresUpgradeJobName(immich, spec.step)
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, job)
+	if err == nil {
+		return job, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	labels := r.getLabels(immich, "postgres-upgrade")
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+
+	env := append([]corev1.EnvVar{}, spec.env...)
+	var volumeMounts []corev1.VolumeMount
+	var volumes []corev1.Volume
+	if spec.mountData {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: "data", MountPath: postgresDataDir, ReadOnly: spec.dataReadOnly})
+		volumes = append(volumes, corev1.Volume{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: immich.GetPostgresPVCName(),
+					ReadOnly:  spec.dataReadOnly,
+				},
+			},
+		})
+	}
+	if spec.mountUpgrade {
+		env = append(env, corev1.EnvVar{Name: "UPGRADE_BACKUP_DIR", Value: upgradeDir + "/" + spec.upgradeBackup})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: "upgrade", MountPath: upgradeDir})
+		volumes = append(volumes, corev1.Volume{
+			Name: "upgrade",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: immich.GetPostgresUpgradePVCName(),
+				},
+			},
+		})
+	}
+
+	affinity := postgresSpec.Affinity
+	if spec.nearPostgresDB {
+		affinity = &corev1.Affinity{
+			PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+					{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: r.getSelectorLabels(immich, "postgres")},
+						TopologyKey:   corev1.LabelHostname,
+					},
+				},
+			},
+		}
+	}
+	deadline := spec.deadline
+	if deadline == 0 {
+		deadline = upgradeJobDeadline
+	}
+
+	job = &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(0)),
+			ActiveDeadlineSeconds: ptr.To(int64(deadline.Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					ImagePullSecrets: immich.Spec.ImagePullSecrets,
+					SecurityContext:  postgresSpec.PodSecurityContext,
+					NodeSelector:     postgresSpec.NodeSelector,
+					Tolerations:      postgresSpec.Tolerations,
+					Affinity:         affinity,
+					Containers: []corev1.Container{
+						{
+							Name:            spec.step,
+							Image:           spec.image,
+							ImagePullPolicy: postgresSpec.ImagePullPolicy,
+							Command:         []string{"/bin/bash", "-c", spec.script},
+							Env:             env,
+							SecurityContext: postgresSpec.SecurityContext,
+							VolumeMounts:    volumeMounts,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	logf.FromContext(ctx).Info("Creating PostgreSQL upgrade Job", "name", name)
+	if err := r.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// deletePostgresUpgradeJob deletes the Job of an upgrade step, along with its pods
+func (r *ImmichReconciler) deletePostgresUpgradeJob(ctx context.Context, immich *mediav1alpha1.Immich, step string) error {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: getPostgresUpgradeJobName(immich, step), Namespace: immich.Namespace},
+	}
+	if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// getPostgresUpgradeJobName returns the name of the Job running an upgrade step
+func getPostgresUpgradeJobName(immich *mediav1alpha1.Immich, step string) string {
+	return fmt.Sprintf("%s-postgres-upgrade-%s", immich.Name, step)
+}
+
+// setUpgradingCondition sets the Upgrading condition of the Immich resource
+func setUpgradingCondition(immich *mediav1alpha1.Immich, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeUpgrading,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// postgresVersionCheckScript writes "<data directory major version> <image major version>" to the termination message.
+// The data directory version is empty if PostgreSQL was never initialized.
+const postgresVersionCheckScript = `set -eu
+data_version=""
+if [ -f "` + postgresDataDir + `/PG_VERSION" ]; then
+  data_version="$(cat "` + postgresDataDir + `/PG_VERSION")"
+fi
+image_version="$(postgres -V | sed -E 's/[^0-9]*([0-9]+).*/\1/')"
+echo "Data directory: PostgreSQL ${data_version:-none}, image: PostgreSQL ${image_version}"
+printf '%s %s' "${data_version}" "${image_version}" > /dev/termination-log
+`
+
+// countTablesQuery counts the tables of the database psql connects to
+const countTablesQuery = `SELECT count(*) FROM pg_catalog.pg_tables WHERE schemaname NOT IN ('pg_catalog', 'information_schema')`
+
+// postgresUpgradeDumpScript dumps the whole cluster, including roles, to the upgrade PVC, along with the number of
+// tables of the Immich database, which the restore checks
+const postgresUpgradeDumpScript = `set -euo pipefail
+mkdir -p "${UPGRADE_BACKUP_DIR}"
+echo "Dumping PostgreSQL from ${PGHOST}:${PGPORT} to ${UPGRADE_BACKUP_DIR}/dump.sql..."
+pg_dumpall --clean --if-exists -f "${UPGRADE_BACKUP_DIR}/dump.sql.partial"
+psql -XAtq -v ON_ERROR_STOP=1 -c "` + countTablesQuery + `" > "${UPGRADE_BACKUP_DIR}/tables"
+mv "${UPGRADE_BACKUP_DIR}/dump.sql.partial" "${UPGRADE_BACKUP_DIR}/dump.sql"
+echo "Dump completed: $(cat "${UPGRADE_BACKUP_DIR}/tables") tables in database ${PGDATABASE}"
+`
+
+// postgresUpgradeMoveDataScript copies the data directory to the upgrade PVC, then empties it so that the
+// new version initializes a new one. Nothing is touched without a dump to restore. The data directory is
+// only emptied once the copy is complete, and only if it still holds the previous version, so that the
+// script can be retried.
+const postgresUpgradeMoveDataScript = `set -euo pipefail
+data="` + postgresDataDir + `"
+saved="${UPGRADE_BACKUP_DIR}/pgdata"
+if [ ! -s "${UPGRADE_BACKUP_DIR}/dump.sql" ]; then
+  echo "The dump ${UPGRADE_BACKUP_DIR}/dump.sql is missing or empty, keeping the data directory" >&2
+  exit 1
+fi
+if [ -f "${data}/PG_VERSION" ] && [ "$(cat "${data}/PG_VERSION")" = "${FROM_VERSION}" ]; then
+  if [ ! -d "${saved}" ]; then
+    echo "Copying the PostgreSQL ${FROM_VERSION} data directory to ${saved}..."
+    rm -rf "${saved}.partial"
+    mkdir -p "${saved}.partial"
+    cp -a "${data}/." "${saved}.partial/"
+    mv "${saved}.partial" "${saved}"
+  fi
+  echo "Emptying the data directory..."
+  find "${data}" -mindepth 1 -maxdepth 1 -exec rm -rf {} +
+fi
+echo "Data directory moved"
+`
+
+// postgresUpgradeRestoreScript restores the dump into the new PostgreSQL version, stopping on the first error,
+// then checks that the Immich database has as many tables as when it was dumped.
+// The new cluster was initialized with the role the Jobs connect as, which cannot be dropped nor created again,
+// so the dump statements about it are removed. The search_path fix mirrors the restore procedure documented by Immich.
+const postgresUpgradeRestoreScript = `set -euo pipefail
+dump="${UPGRADE_BACKUP_DIR}/dump.sql"
+if [ ! -s "${dump}" ]; then
+  echo "The dump ${dump} is missing or empty" >&2
+  exit 1
+fi
+until pg_isready -q; do
+  echo "Waiting for PostgreSQL at ${PGHOST}:${PGPORT}..."
+  sleep 2
+done
+echo "Restoring ${dump}..."
+psql -X -v ON_ERROR_STOP=1 -d postgres -c "DROP DATABASE IF EXISTS \"${PGDATABASE}\""
+sed -E -e "` + fixSearchPathSed + `" -e "` + skipConnectedRoleSed + `" "${dump}" | psql -X -v ON_ERROR_STOP=1 -d postgres
+tables="$(psql -XAtq -v ON_ERROR_STOP=1 -c "` + countTablesQuery + `")"
+expected="$(cat "${UPGRADE_BACKUP_DIR}/tables" 2>/dev/null || echo 1)"
+if [ "${tables}" -lt "${expected}" ] || [ "${tables}" -eq 0 ]; then
+  echo "Database ${PGDATABASE} has ${tables} tables after the restore, expected ${expected}" >&2
+  exit 1
+fi
+echo "Restore completed: ${tables} tables in database ${PGDATABASE}"
+`
+
+// postgresUpgradeRollbackScript copies the previous data directory back if it was completely copied.
+// Otherwise the data directory was not emptied, and only the partial copy is removed.
+const postgresUpgradeRollbackScript = `set -eu
+data="` + postgresDataDir + `"
+saved="${UPGRADE_BACKUP_DIR}/pgdata"
+if [ -d "${saved}" ]; then
+  echo "Restoring the previous data directory from ${saved}..."
+  find "${data}" -mindepth 1 -maxdepth 1 -exec rm -rf {} +
+  cp -a "${saved}/." "${data}/"
+fi
+rm -rf "${saved}.partial"
+echo "Rollback completed"
+`
@@ -0,0 +1,469 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// upgradeTestEnv drives reconcilePostgresUpgrade against a fake client, simulating Jobs and the StatefulSet
+type upgradeTestEnv struct {
+	t      *testing.T
+	ctx    context.Context
+	c      client.Client
+	r      *ImmichReconciler
+	immich *mediav1alpha1.Immich
+	sts    *appsv1.StatefulSet
+}
+
+func newUpgradeTestEnv(t *testing.T, immich *mediav1alpha1.Immich, image string, objs ...client.Object) *upgradeTestEnv {
+	t.Helper()
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich-postgres", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: ptr.To(int32(1)),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "postgres", Image: image}}},
+			},
+		},
+		Status: appsv1.StatefulSetStatus{Replicas: 1, ReadyReplicas: 1, UpdatedReplicas: 1},
+	}
+	server := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich-server", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To(int32(0))},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme(t)).
+		WithObjects(append(objs, sts, server)...).
+		WithStatusSubresource(sts, &batchv1.Job{}).
+		Build()
+	return &upgradeTestEnv{
+		t:      t,
+		ctx:    context.Background(),
+		c:      c,
+		r:      &ImmichReconciler{Client: c},
+		immich: immich,
+		sts:    sts,
+	}
+}
+
+// reconcile runs reconcilePostgresUpgrade and applies the returned image and replicas to the StatefulSet spec
+func (e *upgradeTestEnv) reconcile(wantImage string, wantReplicas int32) {
+	e.t.Helper()
+	image, replicas, err := e.r.reconcilePostgresUpgrade(e.ctx, e.immich)
+	if err != nil {
+		e.t.Fatalf("reconcilePostgresUpgrade() error = %v", err)
+	}
+	if image != wantImage || replicas != wantReplicas {
+		e.t.Fatalf("reconcilePostgresUpgrade() = (%s, %d), want (%s, %d)", image, replicas, wantImage, wantReplicas)
+	}
+}
+
+func (e *upgradeTestEnv) wantPhase(phase string) {
+	e.t.Helper()
+	upgrade := e.immich.Status.PostgresUpgrade
+	if upgrade == nil || upgrade.Phase != phase {
+		e.t.Fatalf("upgrade = %+v, want phase %s", upgrade, phase)
+	}
+}
+
+func (e *upgradeTestEnv) getJob(step string) *batchv1.Job {
+	e.t.Helper()
+	job := &batchv1.Job{}
+	if err := e.c.Get(e.ctx, types.NamespacedName{Name: "test-immich-postgres-upgrade-" + step, Namespace: "default"}, job); err != nil {
+		e.t.Fatalf("Job %s should exist: %v", step, err)
+	}
+	return job
+}
+
+func (e *upgradeTestEnv) completeJob(step string) *batchv1.Job {
+	e.t.Helper()
+	job := e.getJob(step)
+	job.Status.Succeeded = 1
+	if err := e.c.Status().Update(e.ctx, job); err != nil {
+		e.t.Fatalf("failed to update Job status: %v", err)
+	}
+	return job
+}
+
+// completeVersionCheck simulates the version check pod writing its result
+func (e *upgradeTestEnv) completeVersionCheck(result string) {
+	e.t.Helper()
+	job := e.completeJob(upgradeStepVersionCheck)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{batchv1.JobNameLabel: job.Name},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  upgradeStepVersionCheck,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: result}},
+				},
+			},
+		},
+	}
+	if err := e.c.Create(e.ctx, pod); err != nil {
+		e.t.Fatalf("failed to create pod: %v", err)
+	}
+}
+
+func (e *upgradeTestEnv) updateStatefulSet(image string, replicas int32) {
+	e.t.Helper()
+	e.sts.Spec.Replicas = ptr.To(replicas)
+	e.sts.Spec.Template.Spec.Containers[0].Image = image
+	if err := e.c.Update(e.ctx, e.sts); err != nil {
+		e.t.Fatalf("failed to update StatefulSet: %v", err)
+	}
+	e.sts.Status = appsv1.StatefulSetStatus{Replicas: replicas, ReadyReplicas: replicas, UpdatedReplicas: replicas}
+	if err := e.c.Status().Update(e.ctx, e.sts); err != nil {
+		e.t.Fatalf("failed to update StatefulSet status: %v", err)
+	}
+}
+
+func TestReconcilePostgresUpgrade_MajorVersion(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Postgres: &mediav1alpha1.PostgresSpec{
+				Persistence: &mediav1alpha1.PostgresPersistenceSpec{Size: ptr.To(resource.MustParse("5Gi"))},
+			},
+		},
+		Status: mediav1alpha1.ImmichStatus{PostgresImage: "postgres:14"},
+	}
+	e := newUpgradeTestEnv(t, immich, "postgres:14")
+
+	// The new image is checked while PostgreSQL keeps running the previous one
+	e.reconcile("postgres:14", 1)
+	e.wantPhase(mediav1alpha1.PostgresUpgradePhaseVersionCheck)
+	if immich.IsPostgresUpgradeInProgress() {
+		t.Error("the server should keep running during the version check")
+	}
+	check := e.getJob(upgradeStepVersionCheck)
+	if check.Spec.Template.Spec.Containers[0].Image != "postgres:16" {
+		t.Errorf("version check image = %s, want the new image", check.Spec.Template.Spec.Containers[0].Image)
+	}
+	if !check.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ReadOnly {
+		t.Error("the version check should mount the data PVC read-only")
+	}
+	if affinity := check.Spec.Template.Spec.Affinity; affinity == nil || affinity.PodAffinity == nil ||
+		len(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 1 ||
+		affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey != corev1.LabelHostname {
+		t.Errorf("affinity = %+v, want the version check on the node of the PostgreSQL pod", affinity)
+	}
+	if deadline := ptr.Deref(check.Spec.ActiveDeadlineSeconds, 0); deadline != int64(postgresVersionCheckDeadline.Seconds()) {
+		t.Errorf("version check deadline = %ds, want a Job that cannot be scheduled to fail", deadline)
+	}
+
+	e.completeVersionCheck("14 16")
+	e.reconcile("postgres:14", 1)
+	e.wantPhase(mediav1alpha1.PostgresUpgradePhaseDumping)
+	upgrade := immich.Status.PostgresUpgrade
+	if upgrade.FromVersion != "14" || upgrade.ToVersion != "16" || !strings.HasPrefix(upgrade.BackupPath, "pg14-to-pg16-") {
+		t.Errorf("upgrade = %+v, want versions 14 and 16", upgrade)
+	}
+	if !immich.IsPostgresUpgradeInProgress() {
+		t.Error("the server should be scaled down from the dump on")
+	}
+
+	e.reconcile("postgres:14", 1)
+	dump := e.getJob(upgradeStepDump)
+	if dump.Spec.Template.Spec.Containers[0].Image != "postgres:14" {
+		t.Errorf("dump image = %s, want the previous image", dump.Spec.Template.Spec.Containers[0].Image)
+	}
+	if dump.Spec.ActiveDeadlineSeconds == nil {
+		t.Error("the dump Job should have a deadline")
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := e.c.Get(e.ctx, types.NamespacedName{Name: "test-immich-postgres-upgrade", Namespace: "default"}, pvc); err != nil {
+		t.Fatalf("upgrade PVC should have been created: %v", err)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "10Gi" {
+		t.Errorf("upgrade PVC size = %s, want twice the data PVC size", size.String())
+	}
+
+	e.completeJob(upgradeStepDump)
+	e.reconcile("postgres:14", 0)
+	e.wantPhase(mediav1alpha1.PostgresUpgradePhaseStoppingDatabase)
+
+	// Still stopping
+	e.reconcile("postgres:14", 0)
+	e.wantPhase(mediav1alpha1.PostgresUpgradePhaseStoppingDatabase)
+
+	e.updateStatefulSet("postgres:14", 0)
+	e.reconcile("postgres:14", 0)
+	e.wantPhase(mediav1alpha1.PostgresUpgradePhaseMovingData)
+
+	e.reconcile("postgres:14", 0)
+	env := map[string]corev1.EnvVar{}
+	for _, v := range e.getJob(upgradeStepMoveData).Spec.Template.Spec.Containers[0].Env {
+		env[v.Name] = v
+	}
+	if env["FROM_VERSION"].Value != "14" || env["UPGRADE_BACKUP_DIR"].Value != "/upgrade/"+upgrade.BackupPath {
+		t.Errorf("unexpected move-data environment: %+v", env)
+	}
+
+	e.completeJob(upgradeStepMoveData)
+	e.reconcile("postgres:16", 1)
+	e.wantPhase(mediav1alpha1.PostgresUpgradePhaseStarting)
+
+	// Not started with the new image yet
+	e.reconcile("postgres:16", 1)
+	e.wantPhase(mediav1alpha1.PostgresUpgradePhaseStarting)
+
+	e.updateStatefulSet("postgres:16", 1)
+	e.reconcile("postgres:16", 1)
+	e.wantPhase(mediav1alpha1.PostgresUpgradePhaseRestoring)
+
+	e.reconcile("postgres:16", 1)
+	restore := e.getJob(upgradeStepRestore).Spec.Template.Spec.Containers[0]
+	if restore.Command[0] != "/bin/bash" || restore.Command[2] != postgresUpgradeRestoreScript {
+		t.Errorf("restore command = %v, want the restore script run by bash", restore.Command)
+	}
+	e.completeJob(upgradeStepRestore)
+	e.reconcile("postgres:16", 1)
+
+	if immich.Status.PostgresUpgrade != nil {
+		t.Errorf("upgrade should be finished, got %+v", immich.Status.PostgresUpgrade)
+	}
+	if immich.Status.PostgresImage != "postgres:16" {
+		t.Errorf("postgresImage = %s, want postgres:16", immich.Status.PostgresImage)
+	}
+	condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeUpgrading)
+	if condition == nil || condition.Reason != "UpgradeSucceeded" {
+		t.Errorf("Upgrading condition = %+v, want reason UpgradeSucceeded", condition)
+	}
+	jobs := &batchv1.JobList{}
+	if err := e.c.List(e.ctx, jobs); err != nil {
+		t.Fatalf("failed to list Jobs: %v", err)
+	}
+	if len(jobs.Items) != 0 {
+		t.Errorf("upgrade Jobs should be deleted, got %d", len(jobs.Items))
+	}
+}
+
+func TestReconcilePostgresUpgrade_NoUpgradeNeeded(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       mediav1alpha1.ImmichStatus
+		stsImage     string
+		checkResult  string
+		wantChecking bool
+	}{
+		{
+			name:     "unchanged image",
+			status:   mediav1alpha1.ImmichStatus{PostgresImage: "postgres:16"},
+			stsImage: "postgres:16",
+		},
+		{
+			name:     "image adopted from an existing StatefulSet",
+			stsImage: "postgres:16",
+		},
+		{
+			name:         "minor version change",
+			status:       mediav1alpha1.ImmichStatus{PostgresImage: "postgres:16.1"},
+			stsImage:     "postgres:16.1",
+			checkResult:  "16 16",
+			wantChecking: true,
+		},
+		{
+			name:         "uninitialized data directory",
+			status:       mediav1alpha1.ImmichStatus{PostgresImage: "postgres:14"},
+			stsImage:     "postgres:14",
+			checkResult:  "16",
+			wantChecking: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+			immich := &mediav1alpha1.Immich{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+				Status:     tt.status,
+			}
+			e := newUpgradeTestEnv(t, immich, tt.stsImage)
+
+			if tt.wantChecking {
+				e.reconcile(tt.stsImage, 1)
+				e.wantPhase(mediav1alpha1.PostgresUpgradePhaseVersionCheck)
+				e.completeVersionCheck(tt.checkResult)
+			}
+			e.reconcile("postgres:16", 1)
+
+			if immich.Status.PostgresUpgrade != nil {
+				t.Errorf("no upgrade expected, got %+v", immich.Status.PostgresUpgrade)
+			}
+			if immich.Status.PostgresImage != "postgres:16" {
+				t.Errorf("postgresImage = %s, want postgres:16", immich.Status.PostgresImage)
+			}
+		})
+	}
+}
+
+func TestReconcilePostgresUpgrade_Disabled(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Postgres: &mediav1alpha1.PostgresSpec{
+				Upgrade: &mediav1alpha1.PostgresUpgradeSpec{Enabled: ptr.To(false)},
+			},
+		},
+		Status: mediav1alpha1.ImmichStatus{PostgresImage: "postgres:14"},
+	}
+	e := newUpgradeTestEnv(t, immich, "postgres:14")
+
+	e.reconcile("postgres:14", 1)
+	e.completeVersionCheck("14 16")
+	e.reconcile("postgres:14", 1)
+
+	e.wantPhase(mediav1alpha1.PostgresUpgradePhaseVersionCheck)
+	condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeUpgrading)
+	if condition == nil || condition.Reason != "UpgradeDisabled" {
+		t.Errorf("Upgrading condition = %+v, want reason UpgradeDisabled", condition)
+	}
+}
+
+func TestReconcilePostgresUpgrade_Rollback(t *testing.T) {
+	newImmich := func(phase string) *mediav1alpha1.Immich {
+		return &mediav1alpha1.Immich{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+			Spec:       mediav1alpha1.ImmichSpec{Postgres: &mediav1alpha1.PostgresSpec{Image: ptr.To("postgres:14")}},
+			Status: mediav1alpha1.ImmichStatus{
+				PostgresImage: "postgres:14",
+				PostgresUpgrade: &mediav1alpha1.PostgresUpgradeStatus{
+					Phase:       phase,
+					FromImage:   "postgres:14",
+					ToImage:     "postgres:16",
+					FromVersion: "14",
+					ToVersion:   "16",
+					BackupPath:  "pg14-to-pg16-20250101020000",
+				},
+			},
+		}
+	}
+
+	t.Run("cancelled before the data directory is moved", func(t *testing.T) {
+		immich := newImmich(mediav1alpha1.PostgresUpgradePhaseDumping)
+		e := newUpgradeTestEnv(t, immich, "postgres:14")
+
+		e.reconcile("postgres:14", 1)
+
+		if immich.Status.PostgresUpgrade != nil {
+			t.Errorf("upgrade should be cancelled, got %+v", immich.Status.PostgresUpgrade)
+		}
+		condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeUpgrading)
+		if condition == nil || condition.Reason != "UpgradeCancelled" {
+			t.Errorf("Upgrading condition = %+v, want reason UpgradeCancelled", condition)
+		}
+	})
+
+	t.Run("rolled back once the data directory is moved", func(t *testing.T) {
+		immich := newImmich(mediav1alpha1.PostgresUpgradePhaseRestoring)
+		e := newUpgradeTestEnv(t, immich, "postgres:16")
+
+		// PostgreSQL is stopped before restoring the previous data directory
+		e.reconcile("postgres:14", 0)
+		e.wantPhase(mediav1alpha1.PostgresUpgradePhaseRollingBack)
+		if err := e.c.Get(e.ctx, types.NamespacedName{Name: "test-immich-postgres-upgrade-rollback", Namespace: "default"}, &batchv1.Job{}); err == nil {
+			t.Fatal("rollback Job should not run while PostgreSQL is running")
+		}
+
+		e.updateStatefulSet("postgres:16", 0)
+		e.reconcile("postgres:14", 0)
+		rollback := e.getJob(upgradeStepRollback)
+		if rollback.Spec.Template.Spec.Containers[0].Image != "postgres:14" {
+			t.Errorf("rollback image = %s, want the previous image", rollback.Spec.Template.Spec.Containers[0].Image)
+		}
+
+		e.completeJob(upgradeStepRollback)
+		e.reconcile("postgres:14", 1)
+
+		if immich.Status.PostgresUpgrade != nil {
+			t.Errorf("upgrade should be rolled back, got %+v", immich.Status.PostgresUpgrade)
+		}
+		if immich.Status.PostgresImage != "postgres:14" {
+			t.Errorf("postgresImage = %s, want postgres:14", immich.Status.PostgresImage)
+		}
+		condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeUpgrading)
+		if condition == nil || condition.Reason != "RolledBack" {
+			t.Errorf("Upgrading condition = %+v, want reason RolledBack", condition)
+		}
+	})
+
+	t.Run("held when the target changes mid-upgrade", func(t *testing.T) {
+		immich := newImmich(mediav1alpha1.PostgresUpgradePhaseMovingData)
+		immich.Spec.Postgres.Image = ptr.To("postgres:17")
+		e := newUpgradeTestEnv(t, immich, "postgres:14")
+
+		e.reconcile("postgres:14", 0)
+
+		e.wantPhase(mediav1alpha1.PostgresUpgradePhaseMovingData)
+		condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeUpgrading)
+		if condition == nil || condition.Reason != "TargetChanged" {
+			t.Errorf("Upgrading condition = %+v, want reason TargetChanged", condition)
+		}
+	})
+}
+
+func TestPostgresUpgradeScripts_StopOnErrors(t *testing.T) {
+	for name, script := range map[string]string{
+		"dump":      postgresUpgradeDumpScript,
+		"move-data": postgresUpgradeMoveDataScript,
+		"restore":   postgresUpgradeRestoreScript,
+	} {
+		if !strings.HasPrefix(script, "set -euo pipefail\n") {
+			t.Errorf("%s script should fail on the failure of any command of a pipeline:\n%s", name, script)
+		}
+	}
+
+	dumpCheck := `[ ! -s "${UPGRADE_BACKUP_DIR}/dump.sql" ]`
+	if check, empty := strings.Index(postgresUpgradeMoveDataScript, dumpCheck), strings.Index(postgresUpgradeMoveDataScript, "rm -rf {}"); check < 0 || check > empty {
+		t.Errorf("move-data script should check the dump before emptying the data directory:\n%s", postgresUpgradeMoveDataScript)
+	}
+
+	for _, want := range []string{
+		`[ ! -s "${dump}" ]`,
+		`| psql -X -v ON_ERROR_STOP=1 -d postgres`,
+		countTablesQuery,
+		`[ "${tables}" -lt "${expected}" ]`,
+	} {
+		if !strings.Contains(postgresUpgradeRestoreScript, want) {
+			t.Errorf("restore script should contain %q:\n%s", want, postgresUpgradeRestoreScript)
+		}
+	}
+}
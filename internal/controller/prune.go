@@ -0,0 +1,222 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// desiredObjects maps each prunable kind to the names of the objects that should exist.
+type desiredObjects map[schema.GroupVersionKind]sets.Set[string]
+
+func (d desiredObjects) add(gvk schema.GroupVersionKind, names ...string) {
+	if d[gvk] == nil {
+		d[gvk] = sets.New[string]()
+	}
+	d[gvk].Insert(names...)
+}
+
+// getDesiredObjects returns the owned objects that should exist for the current spec.
+// Every kind listed here is pruned, so a kind without any desired object is still present with an empty set.
+// PersistentVolumeClaims are deliberately not part of this list, so that data is never pruned.
+func (r *ImmichReconciler) getDesiredObjects(immich *mediav1alpha1.Immich) desiredObjects {
+	deploymentGVK := appsv1.SchemeGroupVersion.WithKind("Deployment")
+	statefulSetGVK := appsv1.SchemeGroupVersion.WithKind("StatefulSet")
+	serviceGVK := corev1.SchemeGroupVersion.WithKind("Service")
+	configMapGVK := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	secretGVK := corev1.SchemeGroupVersion.WithKind("Secret")
+	ingressGVK := networkingv1.SchemeGroupVersion.WithKind("Ingress")
+	cronJobGVK := batchv1.SchemeGroupVersion.WithKind("CronJob")
+	hpaGVK := autoscalingv2.SchemeGroupVersion.WithKind("HorizontalPodAutoscaler")
+	pdbGVK := policyv1.SchemeGroupVersion.WithKind("PodDisruptionBudget")
+
+	desired := desiredObjects{}
+	for _, gvk := range []schema.GroupVersionKind{deploymentGVK, statefulSetGVK, serviceGVK, configMapGVK, secretGVK, ingressGVK, cronJobGVK, hpaGVK, pdbGVK} {
+		desired.add(gvk)
+	}
+
+	configName := fmt.Sprintf("%s-immich-config", immich.Name)
+	if immich.GetConfigurationKind() == "Secret" {
+		desired.add(secretGVK, configName)
+	} else {
+		desired.add(configMapGVK, configName)
+	}
+
+	if immich.IsServerEnabled() {
+		name := fmt.Sprintf("%s-server", immich.Name)
+		desired.add(serviceGVK, name)
+		if immich.IsIngressEnabled() {
+			desired.add(ingressGVK, name)
+		}
+		for _, workload := range getServerWorkloads(immich) {
+			desired.add(deploymentGVK, workload.name)
+			if workload.autoscaling.IsEnabled() {
+				desired.add(hpaGVK, workload.name)
+			}
+		}
+		if immich.IsServerWorkersSplit() && immich.IsMetricsEnabled() {
+			desired.add(serviceGVK, getServerMicroservicesMetricsServiceName(immich))
+		}
+	}
+
+	for _, workload := range getMachineLearningWorkloads(immich) {
+		desired.add(deploymentGVK, workload.name)
+		desired.add(serviceGVK, workload.name)
+		if workload.autoscaling.IsEnabled() {
+			desired.add(hpaGVK, workload.name)
+		}
+	}
+
+	if immich.IsValkeySentinelEnabled() {
+		name := fmt.Sprintf("%s-valkey", immich.Name)
+		desired.add(statefulSetGVK, name, getValkeySentinelName(immich))
+		desired.add(serviceGVK, getValkeyHeadlessServiceName(immich), getValkeySentinelName(immich))
+	} else if immich.IsValkeyEnabled() {
+		name := fmt.Sprintf("%s-valkey", immich.Name)
+		desired.add(deploymentGVK, name)
+		desired.add(serviceGVK, name)
+	}
+
+	if usesValkeyURL(immich) {
+		desired.add(secretGVK, getValkeyURLSecretName(immich))
+	}
+
+	if immich.GetPostgresTLS() != nil {
+		desired.add(secretGVK, getDatabaseURLSecretName(immich))
+	}
+
+	if immich.IsPostgresStatefulSetEnabled() {
+		name := fmt.Sprintf("%s-postgres", immich.Name)
+		desired.add(statefulSetGVK, name)
+		desired.add(serviceGVK, name)
+		if immich.IsPostgresBackupEnabled() {
+			desired.add(cronJobGVK, fmt.Sprintf("%s-postgres-backup", immich.Name))
+		}
+	}
+
+	if immich.IsBackupCopyEnabled() {
+		desired.add(cronJobGVK, fmt.Sprintf("%s-backup-copy", immich.Name))
+	}
+
+	pdbs, _ := getDesiredPodDisruptionBudgets(immich)
+	for _, target := range pdbs {
+		desired.add(pdbGVK, target.name)
+	}
+
+	// Optional APIs are only pruned when they are available in the cluster
+	if r.IsRouteAPIAvailable() {
+		desired.add(RouteGVK)
+		if immich.IsServerEnabled() && immich.ShouldCreateRoute(true) {
+			desired.add(RouteGVK, fmt.Sprintf("%s-server", immich.Name))
+		}
+	}
+	if r.IsServiceMonitorAPIAvailable() {
+		desired.add(ServiceMonitorGVK)
+		if immich.IsServerEnabled() && immich.ShouldCreateServiceMonitor(true) {
+			desired.add(ServiceMonitorGVK, fmt.Sprintf("%s-server", immich.Name))
+		}
+	}
+
+	return desired
+}
+
+// pruneObjects deletes objects controlled by the Immich resource that are no longer part of the desired state,
+// for example the Ingress after disabling spec.server.ingress, or the Valkey Deployment after disabling spec.valkey.
+// Only objects carrying the operator labels and a controller reference to this Immich are considered.
+// Data PVCs and credentials Secrets are never pruned: PVCs are not listed at all, and only the
+// generated configuration and connection URL Secrets are eligible among Secrets.
+func (r *ImmichReconciler) pruneObjects(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+
+	selector := client.MatchingLabels{
+		labelInstance:  immich.Name,
+		labelManagedBy: "immich-operator",
+	}
+
+	for gvk, keep := range r.getDesiredObjects(immich) {
+		list := r.newPruneList(gvk)
+		if err := r.List(ctx, list, client.InNamespace(immich.Namespace), selector); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return fmt.Errorf("failed to list %s objects: %w", gvk.Kind, err)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return fmt.Errorf("failed to read %s objects: %w", gvk.Kind, err)
+		}
+
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok || keep.Has(obj.GetName()) || !isControlledBy(obj, immich) {
+				continue
+			}
+			// Credentials Secrets must survive; only the generated config and connection URL Secrets may be pruned
+			if gvk.Kind == "Secret" && obj.GetLabels()[labelComponent] != "config" &&
+				obj.GetName() != getValkeyURLSecretName(immich) && obj.GetName() != getDatabaseURLSecretName(immich) {
+				continue
+			}
+
+			log.Info("Pruning resource no longer in desired state", "kind", gvk.Kind, "name", obj.GetName())
+			if err := r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil &&
+				!apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to prune %s %s: %w", gvk.Kind, obj.GetName(), err)
+			}
+			r.recordEvent(immich, corev1.EventTypeNormal, EventReasonPruned, "Deleted %s %s, no longer in the desired state", gvk.Kind, obj.GetName())
+		}
+	}
+
+	return nil
+}
+
+// newPruneList returns an empty list of the given kind. Kinds of the scheme get a typed list, read from the cache
+// of the informers started by Owns, while the optional APIs get an unstructured list, read from the API server.
+func (r *ImmichReconciler) newPruneList(gvk schema.GroupVersionKind) client.ObjectList {
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	if obj, err := r.Client.Scheme().New(listGVK); err == nil {
+		if list, ok := obj.(client.ObjectList); ok {
+			return list
+		}
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(listGVK)
+	return list
+}
+
+// isControlledBy returns true if obj has a controller reference to the given Immich resource
+func isControlledBy(obj client.Object, immich *mediav1alpha1.Immich) bool {
+	controllerRef := metav1.GetControllerOf(obj)
+	return controllerRef != nil && controllerRef.UID == immich.UID
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestPruneObjects(t *testing.T) {
+	ctx := context.Background()
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default", UID: "immich-uid"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Server: &mediav1alpha1.ServerSpec{
+				Ingress: &mediav1alpha1.IngressSpec{Enabled: ptr.To(false)},
+			},
+			Valkey: &mediav1alpha1.ValkeySpec{Enabled: ptr.To(false)},
+		},
+	}
+
+	r := &ImmichReconciler{}
+	ownerRef := metav1.OwnerReference{
+		APIVersion: mediav1alpha1.GroupVersion.String(),
+		Kind:       "Immich",
+		Name:       immich.Name,
+		UID:        immich.UID,
+		Controller: ptr.To(true),
+	}
+	objectMeta := func(name, component string, owned bool) metav1.ObjectMeta {
+		meta := metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    r.getLabels(immich, component),
+		}
+		if owned {
+			meta.OwnerReferences = []metav1.OwnerReference{ownerRef}
+		}
+		return meta
+	}
+
+	objects := []client.Object{
+		&appsv1.Deployment{ObjectMeta: objectMeta("test-immich-server", "server", true)},
+		&corev1.Service{ObjectMeta: objectMeta("test-immich-server", "server", true)},
+		&networkingv1.Ingress{ObjectMeta: objectMeta("test-immich-server", "server", true)},
+		&appsv1.Deployment{ObjectMeta: objectMeta("test-immich-valkey", "valkey", true)},
+		&corev1.Service{ObjectMeta: objectMeta("test-immich-valkey", "valkey", true)},
+		&corev1.PersistentVolumeClaim{ObjectMeta: objectMeta("test-immich-valkey-data", "valkey", true)},
+		&corev1.ConfigMap{ObjectMeta: objectMeta("test-immich-immich-config", "config", true)},
+		&corev1.Secret{ObjectMeta: objectMeta("test-immich-postgres-credentials", "postgres", false)},
+		// Not controlled by this Immich instance
+		&appsv1.Deployment{ObjectMeta: objectMeta("test-immich-other", "other", false)},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(objects...).Build()
+	r.Client = c
+
+	if err := r.pruneObjects(ctx, immich); err != nil {
+		t.Fatalf("pruneObjects() error = %v", err)
+	}
+
+	tests := []struct {
+		obj     client.Object
+		name    string
+		deleted bool
+	}{
+		{&appsv1.Deployment{}, "test-immich-server", false},
+		{&corev1.Service{}, "test-immich-server", false},
+		{&networkingv1.Ingress{}, "test-immich-server", true},
+		{&appsv1.Deployment{}, "test-immich-valkey", true},
+		{&corev1.Service{}, "test-immich-valkey", true},
+		{&corev1.PersistentVolumeClaim{}, "test-immich-valkey-data", false},
+		{&corev1.ConfigMap{}, "test-immich-immich-config", false},
+		{&corev1.Secret{}, "test-immich-postgres-credentials", false},
+		{&appsv1.Deployment{}, "test-immich-other", false},
+	}
+
+	for _, tt := range tests {
+		err := c.Get(ctx, types.NamespacedName{Name: tt.name, Namespace: immich.Namespace}, tt.obj)
+		if tt.deleted && !apierrors.IsNotFound(err) {
+			t.Errorf("%T %s should have been pruned, got err = %v", tt.obj, tt.name, err)
+		}
+		if !tt.deleted && err != nil {
+			t.Errorf("%T %s should have been kept, got err = %v", tt.obj, tt.name, err)
+		}
+	}
+}
+
+func TestPruneObjects_ConfigurationKindSwitch(t *testing.T) {
+	ctx := context.Background()
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default", UID: "immich-uid"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Immich: &mediav1alpha1.ImmichConfig{ConfigurationKind: ptr.To("Secret")},
+		},
+	}
+
+	r := &ImmichReconciler{}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-immich-immich-config",
+			Namespace: immich.Namespace,
+			Labels:    r.getLabels(immich, "config"),
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: mediav1alpha1.GroupVersion.String(),
+				Kind:       "Immich",
+				Name:       immich.Name,
+				UID:        immich.UID,
+				Controller: ptr.To(true),
+			}},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(configMap).Build()
+	r.Client = c
+
+	if err := r.pruneObjects(ctx, immich); err != nil {
+		t.Fatalf("pruneObjects() error = %v", err)
+	}
+
+	err := c.Get(ctx, client.ObjectKeyFromObject(configMap), &corev1.ConfigMap{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("stale config ConfigMap should have been pruned, got err = %v", err)
+	}
+}
+
+func TestNewPruneList(t *testing.T) {
+	r := &ImmichReconciler{Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build()}
+
+	// Owned kinds are read from the cache, through their typed lists
+	if list, ok := r.newPruneList(appsv1.SchemeGroupVersion.WithKind("Deployment")).(*appsv1.DeploymentList); !ok {
+		t.Errorf("Deployment list = %T, want a typed list", list)
+	}
+	if list, ok := r.newPruneList(RouteGVK).(*unstructured.UnstructuredList); !ok || list.GetKind() != "RouteList" {
+		t.Errorf("Route list = %T, want an unstructured RouteList", list)
+	}
+}
@@ -19,6 +19,8 @@ package controller
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -37,9 +39,29 @@ func (r *ImmichReconciler) reconcileServer(ctx context.Context, immich *mediav1a
 	log := logf.FromContext(ctx)
 	log.V(1).Info("Reconciling Server")
 
-	// Create Server Deployment
-	if err := r.reconcileServerDeployment(ctx, immich); err != nil {
-		return err
+	// Give the pods running the microservices worker access to the transcoding hardware
+	accel, err := r.getFFmpegAccel(ctx, immich)
+	if err != nil {
+		return fmt.Errorf("failed to read ffmpeg.accel: %w", err)
+	}
+	transcoding := getTranscodingProfile(immich, accel)
+
+	// Create the Server Deployments, with a HorizontalPodAutoscaler if autoscaling is enabled
+	for _, workload := range getServerWorkloads(immich) {
+		if workload.runsMicroservices() {
+			workload.transcoding = transcoding
+			if err := r.reconcileHardwareTranscodingCondition(ctx, immich, accel, transcoding, workload.nodeSelector); err != nil {
+				return err
+			}
+		}
+		if err := r.reconcileServerDeployment(ctx, immich, workload); err != nil {
+			return err
+		}
+		if workload.autoscaling.IsEnabled() {
+			if err := r.reconcileHorizontalPodAutoscaler(ctx, immich, workload.name, workload.component, workload.autoscaling); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Create Server Service
@@ -47,6 +69,21 @@ func (r *ImmichReconciler) reconcileServer(ctx context.Context, immich *mediav1a
 		return err
 	}
 
+	// Expose the metrics of the microservices worker, which is not behind the server Service in split mode
+	if immich.IsServerWorkersSplit() && immich.IsMetricsEnabled() {
+		if err := r.reconcileServerMicroservicesMetricsService(ctx, immich); err != nil {
+			return err
+		}
+	}
+
+	// Create ServiceMonitor if metrics are enabled and the Prometheus Operator is installed
+	if immich.ShouldCreateServiceMonitor(r.IsServiceMonitorAPIAvailable()) {
+		log.V(1).Info("Creating ServiceMonitor (ServiceMonitor API available)")
+		if err := r.reconcileServerServiceMonitor(ctx, immich); err != nil {
+			return err
+		}
+	}
+
 	// Check if Route API is available (OpenShift)
 	routeAPIAvailable := r.IsRouteAPIAvailable()
 
@@ -69,44 +106,83 @@ func (r *ImmichReconciler) reconcileServer(ctx context.Context, immich *mediav1a
 	return nil
 }
 
-// reconcileServerDeployment creates or updates the Server Deployment using server-side apply
-func (r *ImmichReconciler) reconcileServerDeployment(ctx context.Context, immich *mediav1alpha1.Immich) error {
-	name := fmt.Sprintf("%s-server", immich.Name)
-	labels := r.getLabels(immich, "server")
-	selectorLabels := r.getSelectorLabels(immich, "server")
+// reconcileServerDeployment creates or updates a Server Deployment using server-side apply.
+// In split mode, IMMICH_WORKERS_INCLUDE restricts each Deployment to its worker.
+func (r *ImmichReconciler) reconcileServerDeployment(ctx context.Context, immich *mediav1alpha1.Immich, workload serverWorkload) error {
+	name := workload.name
+	labels := r.getLabels(immich, workload.component)
+	selectorLabels := r.getSelectorLabels(immich, workload.component)
 
 	serverSpec := ptr.Deref(immich.Spec.Server, mediav1alpha1.ServerSpec{})
 
-	replicas := ptr.Deref(serverSpec.Replicas, 1)
+	// Keep the server scaled down while its database is being restored or upgraded, or while Immich is upgraded
+	restoreInProgress, err := r.isRestoreInProgress(ctx, immich)
+	if err != nil {
+		return fmt.Errorf("failed to list restores: %w", err)
+	}
+	scaledDown := restoreInProgress || immich.IsPostgresUpgradeInProgress() || isServerDownForUpgrade(immich)
+	replicas := getDeploymentReplicas(workload.replicas, workload.autoscaling.IsEnabled(), scaledDown)
 
 	// Build environment variables
 	env := r.getServerEnv(immich)
+	if workload.worker != "" {
+		env = append(env, corev1.EnvVar{Name: "IMMICH_WORKERS_INCLUDE", Value: workload.worker})
+	}
+	env = append(env, workload.transcoding.env...)
 	env = append(env, serverSpec.Env...)
 
 	// Build volume mounts and volumes
-	volumeMounts := r.getServerVolumeMounts(immich)
-	volumes := r.getServerVolumes(immich)
+	volumeMounts := append(r.getServerVolumeMounts(immich), workload.transcoding.getVolumeMounts()...)
+	volumes := append(r.getServerVolumes(immich), workload.transcoding.getVolumes()...)
+
+	// Add config checksum annotation so that configuration changes roll the pods
+	configHash, err := r.computeConfigHash(ctx, immich)
+	if err != nil {
+		return fmt.Errorf("failed to compute configuration hash: %w", err)
+	}
+	immich.Status.ConfigHash = configHash
 
-	// Add config checksum annotation if configuration exists
 	annotations := make(map[string]string)
 	for k, v := range serverSpec.PodAnnotations {
 		annotations[k] = v
 	}
+	annotations[configHashAnnotation] = configHash
 
-	// Build container ports
-	ports := []corev1.ContainerPort{
-		{
+	// Restart the server with the new Valkey password when it is rotated
+	valkeyPasswordHash, err := r.computeValkeyPasswordHash(ctx, immich)
+	if err != nil {
+		return fmt.Errorf("failed to compute Valkey password hash: %w", err)
+	}
+	if valkeyPasswordHash != "" {
+		annotations[valkeyPasswordHashAnnotation] = valkeyPasswordHash
+	}
+
+	// Restart the server when the connection URLs or certificates change
+	connectionHash, err := r.computeConnectionHash(ctx, immich)
+	if err != nil {
+		return fmt.Errorf("failed to compute connection hash: %w", err)
+	}
+	if connectionHash != "" {
+		annotations[connectionHashAnnotation] = connectionHash
+	}
+
+	// Build container ports: each worker exposes its own metrics port
+	var ports []corev1.ContainerPort
+	if workload.servesHTTP() {
+		ports = append(ports, corev1.ContainerPort{
 			Name:          "http",
 			ContainerPort: 2283,
 			Protocol:      corev1.ProtocolTCP,
-		},
+		})
 	}
 
 	if immich.IsMetricsEnabled() {
-		ports = append(ports,
-			corev1.ContainerPort{Name: "metrics-api", ContainerPort: 8081, Protocol: corev1.ProtocolTCP},
-			corev1.ContainerPort{Name: "metrics-ms", ContainerPort: 8082, Protocol: corev1.ProtocolTCP},
-		)
+		if workload.worker != immichWorkerMicroservices {
+			ports = append(ports, corev1.ContainerPort{Name: "metrics-api", ContainerPort: 8081, Protocol: corev1.ProtocolTCP})
+		}
+		if workload.worker != immichWorkerAPI {
+			ports = append(ports, corev1.ContainerPort{Name: "metrics-ms", ContainerPort: 8082, Protocol: corev1.ProtocolTCP})
+		}
 	}
 
 	deployment := &appsv1.Deployment{
@@ -130,7 +206,7 @@ func (r *ImmichReconciler) reconcileServerDeployment(ctx context.Context, immich
 			},
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: ptr.To(replicas),
+			Replicas: replicas,
 			Strategy: appsv1.DeploymentStrategy{
 				Type: appsv1.RollingUpdateDeploymentStrategyType,
 			},
@@ -143,59 +219,28 @@ func (r *ImmichReconciler) reconcileServerDeployment(ctx context.Context, immich
 					Annotations: annotations,
 				},
 				Spec: corev1.PodSpec{
-					SecurityContext:  serverSpec.PodSecurityContext,
-					ImagePullSecrets: immich.Spec.ImagePullSecrets,
-					NodeSelector:     serverSpec.NodeSelector,
-					Tolerations:      serverSpec.Tolerations,
-					Affinity:         serverSpec.Affinity,
-					InitContainers:   r.getServerInitContainers(immich),
+					SecurityContext:           workload.transcoding.getPodSecurityContext(serverSpec.PodSecurityContext),
+					RuntimeClassName:          workload.transcoding.runtimeClassName,
+					ImagePullSecrets:          immich.Spec.ImagePullSecrets,
+					NodeSelector:              workload.transcoding.getNodeSelector(workload.nodeSelector),
+					Tolerations:               serverSpec.Tolerations,
+					Affinity:                  r.getServerAffinity(immich, workload),
+					TopologySpreadConstraints: getTopologySpreadConstraints(serverSpec.TopologySpreadConstraints, selectorLabels),
+					InitContainers:            r.getServerInitContainers(immich),
 					Containers: []corev1.Container{
 						{
 							Name:            "server",
-							Image:           immich.GetServerImage(),
+							Image:           getServerDeploymentImage(immich),
 							ImagePullPolicy: serverSpec.ImagePullPolicy,
 							Env:             env,
 							EnvFrom:         serverSpec.EnvFrom,
 							Ports:           ports,
-							Resources:       serverSpec.Resources,
-							SecurityContext: serverSpec.SecurityContext,
-							LivenessProbe: &corev1.Probe{
-								ProbeHandler: corev1.ProbeHandler{
-									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/api/server/ping",
-										Port: intstr.FromString("http"),
-									},
-								},
-								InitialDelaySeconds: 0,
-								PeriodSeconds:       10,
-								TimeoutSeconds:      1,
-								FailureThreshold:    3,
-							},
-							ReadinessProbe: &corev1.Probe{
-								ProbeHandler: corev1.ProbeHandler{
-									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/api/server/ping",
-										Port: intstr.FromString("http"),
-									},
-								},
-								InitialDelaySeconds: 0,
-								PeriodSeconds:       10,
-								TimeoutSeconds:      1,
-								FailureThreshold:    3,
-							},
-							StartupProbe: &corev1.Probe{
-								ProbeHandler: corev1.ProbeHandler{
-									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/api/server/ping",
-										Port: intstr.FromString("http"),
-									},
-								},
-								InitialDelaySeconds: 0,
-								PeriodSeconds:       10,
-								TimeoutSeconds:      1,
-								FailureThreshold:    30,
-							},
-							VolumeMounts: volumeMounts,
+							Resources:       workload.transcoding.getResources(workload.resources),
+							SecurityContext: workload.transcoding.getSecurityContext(serverSpec.SecurityContext),
+							LivenessProbe:   workload.livenessProbe,
+							ReadinessProbe:  workload.readinessProbe,
+							StartupProbe:    workload.startupProbe,
+							VolumeMounts:    volumeMounts,
 						},
 					},
 					Volumes: volumes,
@@ -211,11 +256,23 @@ func (r *ImmichReconciler) getServerEnv(immich *mediav1alpha1.Immich) []corev1.E
 	env := []corev1.EnvVar{}
 
 	valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
-	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
 
-	// Redis/Valkey connection - uses helper to determine built-in vs external
+	// Redis/Valkey connection - uses helper to determine built-in vs external.
+	// In sentinel mode or over TLS, the connection is passed as ioredis options in REDIS_URL.
 	valkeyHost := immich.GetValkeyHost()
-	if valkeyHost != "" {
+	if usesValkeyURL(immich) {
+		env = append(env, corev1.EnvVar{
+			Name: "REDIS_URL",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: getValkeyURLSecretName(immich),
+					},
+					Key: "url",
+				},
+			},
+		})
+	} else if valkeyHost != "" {
 		env = append(env, corev1.EnvVar{
 			Name:  "REDIS_HOSTNAME",
 			Value: valkeyHost,
@@ -224,16 +281,16 @@ func (r *ImmichReconciler) getServerEnv(immich *mediav1alpha1.Immich) []corev1.E
 			Name:  "REDIS_PORT",
 			Value: fmt.Sprintf("%d", immich.GetValkeyPort()),
 		})
-		// Add password if configured (external Valkey)
-		if !immich.IsValkeyEnabled() && valkeySpec.PasswordSecretRef != nil {
+		// Add password if configured (external Valkey) or generated (built-in Valkey)
+		if secretRef := getValkeyPasswordSecretRef(immich); secretRef != nil {
 			env = append(env, corev1.EnvVar{
 				Name: "REDIS_PASSWORD",
 				ValueFrom: &corev1.EnvVarSource{
 					SecretKeyRef: &corev1.SecretKeySelector{
 						LocalObjectReference: corev1.LocalObjectReference{
-							Name: valkeySpec.PasswordSecretRef.Name,
+							Name: secretRef.Name,
 						},
-						Key: valkeySpec.PasswordSecretRef.Key,
+						Key: secretRef.Key,
 					},
 				},
 			})
@@ -264,8 +321,47 @@ func (r *ImmichReconciler) getServerEnv(immich *mediav1alpha1.Immich) []corev1.E
 		Value: "/config/immich-config.yaml",
 	})
 
-	// Database configuration - uses helper methods to determine built-in vs external
-	if postgresSpec.URLSecretRef != nil {
+	// Database configuration
+	env = append(env, r.getDatabaseEnv(immich)...)
+
+	return env
+}
+
+// getDatabaseEnv returns the environment variables locating the database and its credentials,
+// shared by the server and the database preflight check
+func (r *ImmichReconciler) getDatabaseEnv(immich *mediav1alpha1.Immich) []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+
+	// Uses helper methods to determine built-in vs external.
+	// Over TLS, the URL with the TLS options is rendered in a Secret by the operator.
+	// CloudNativePG generates the URL of the Immich user in its app Secret.
+	if immich.IsCloudNativePGEnabled() {
+		env = append(env, corev1.EnvVar{
+			Name: "DB_URL",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: getCloudNativePGAppSecretName(immich),
+					},
+					Key: "uri",
+				},
+			},
+		})
+	} else if immich.GetPostgresTLS() != nil {
+		env = append(env, corev1.EnvVar{
+			Name: "DB_URL",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: getDatabaseURLSecretName(immich),
+					},
+					Key: "url",
+				},
+			},
+		})
+	} else if postgresSpec.URLSecretRef != nil {
 		env = append(env, corev1.EnvVar{
 			Name: "DB_URL",
 			ValueFrom: &corev1.EnvVarSource{
@@ -297,7 +393,7 @@ func (r *ImmichReconciler) getServerEnv(immich *mediav1alpha1.Immich) []corev1.E
 		})
 
 		// Use secret reference (user-provided or auto-generated for built-in PostgreSQL)
-		secretRef := r.getPostgresPasswordSecretRef(immich)
+		secretRef := getPostgresPasswordSecretRef(immich)
 		env = append(env, corev1.EnvVar{
 			Name: "DB_PASSWORD",
 			ValueFrom: &corev1.EnvVarSource{
@@ -314,72 +410,107 @@ func (r *ImmichReconciler) getServerEnv(immich *mediav1alpha1.Immich) []corev1.E
 	return env
 }
 
-// getServerInitContainers returns init containers that wait for dependencies
+// getServerInitContainers returns init containers that wait for dependencies, connecting like the server:
+// pg_isready and valkey-cli use the resolved host and port, or URL, and the TLS settings of each connection.
+// A wait is skipped when the image providing its client is not configured.
 func (r *ImmichReconciler) getServerInitContainers(immich *mediav1alpha1.Immich) []corev1.Container {
 	initContainers := []corev1.Container{}
 
-	// Get init container image from environment variable
-	initImage := mediav1alpha1.GetImmichInitContainerImage()
-	if initImage == "" {
-		return initContainers // Skip init containers if no image is configured
-	}
-
-	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
-	valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
-
 	// Wait for PostgreSQL
-	postgresHost := fmt.Sprintf("%s-postgres", immich.Name)
-	postgresPort := int32(5432)
-	if !immich.IsPostgresEnabled() && postgresSpec.Host != nil && *postgresSpec.Host != "" {
-		postgresHost = *postgresSpec.Host
-		if postgresSpec.Port != nil && *postgresSpec.Port != 0 {
-			postgresPort = *postgresSpec.Port
-		}
-	}
-
-	initContainers = append(initContainers, corev1.Container{
-		Name:  "wait-for-postgres",
-		Image: initImage,
-		Command: []string{
-			"sh", "-c",
-			fmt.Sprintf(`echo "Waiting for PostgreSQL at %s:%d..."
-until nc -z -w2 %s %d; do
-  echo "PostgreSQL is unavailable - sleeping"
-  sleep 2
-done
-echo "PostgreSQL is up"`, postgresHost, postgresPort, postgresHost, postgresPort),
-		},
-	})
+	if image := immich.GetPostgresImage(); image != "" {
+		postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+		_, volumeMounts := getPostgresTLSVolumes(immich)
+		initContainers = append(initContainers, corev1.Container{
+			Name:            "wait-for-postgres",
+			Image:           image,
+			ImagePullPolicy: postgresSpec.ImagePullPolicy,
+			Command:         []string{"sh", "-c", waitForPostgresScript},
+			Env:             getPostgresClientEnv(immich),
+			VolumeMounts:    volumeMounts,
+			Resources:       postgresSpec.Resources,
+			SecurityContext: postgresSpec.SecurityContext,
+		})
+	}
 
 	// Wait for Valkey/Redis
-	if immich.IsValkeyEnabled() || (valkeySpec.Host != nil && *valkeySpec.Host != "") {
-		valkeyHost := fmt.Sprintf("%s-valkey", immich.Name)
-		valkeyPort := int32(6379)
-		if !immich.IsValkeyEnabled() && valkeySpec.Host != nil && *valkeySpec.Host != "" {
-			valkeyHost = *valkeySpec.Host
-			if valkeySpec.Port != nil && *valkeySpec.Port != 0 {
-				valkeyPort = *valkeySpec.Port
+	valkeyHost := immich.GetValkeyHost()
+	if image := immich.GetValkeyImage(); image != "" && valkeyHost != "" {
+		valkeyPort := immich.GetValkeyPort()
+		if immich.IsValkeySentinelEnabled() {
+			valkeyHost = getValkeySentinelName(immich)
+			valkeyPort = valkeySentinelPort
+		}
+
+		args := []string{"-h", valkeyHost, "-p", fmt.Sprintf("%d", valkeyPort)}
+		var volumeMounts []corev1.VolumeMount
+		if tls := immich.GetValkeyTLS(); tls != nil {
+			args = append(args, "--tls")
+			if tls.GetSSLMode() == mediav1alpha1.SSLModeRequire {
+				args = append(args, "--insecure")
 			}
+			for _, file := range []struct {
+				flag string
+				ref  *mediav1alpha1.SecretKeySelector
+				name string
+			}{
+				{"--cacert", tls.CASecretRef, "ca.crt"},
+				{"--cert", tls.ClientCertSecretRef, "tls.crt"},
+				{"--key", tls.ClientKeySecretRef, "tls.key"},
+			} {
+				if file.ref != nil {
+					args = append(args, file.flag, path.Join(valkeyTLSDir, file.name))
+				}
+			}
+			if getValkeyTLSVolume(immich) != nil {
+				volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: valkeyTLSVolumeName, MountPath: valkeyTLSDir, ReadOnly: true})
+			}
+		}
+
+		var env []corev1.EnvVar
+		if secretRef := getValkeyPasswordSecretRef(immich); secretRef != nil {
+			env = append(env, corev1.EnvVar{
+				Name: "REDISCLI_AUTH",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secretRef.Name},
+						Key:                  secretRef.Key,
+					},
+				},
+			})
 		}
 
+		valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
 		initContainers = append(initContainers, corev1.Container{
-			Name:  "wait-for-valkey",
-			Image: initImage,
+			Name:            "wait-for-valkey",
+			Image:           image,
+			ImagePullPolicy: valkeySpec.ImagePullPolicy,
 			Command: []string{
 				"sh", "-c",
 				fmt.Sprintf(`echo "Waiting for Valkey at %s:%d..."
-until nc -z -w2 %s %d; do
+until [ "$(valkey-cli %s ping 2>/dev/null)" = "PONG" ]; do
   echo "Valkey is unavailable - sleeping"
   sleep 2
 done
-echo "Valkey is up"`, valkeyHost, valkeyPort, valkeyHost, valkeyPort),
+echo "Valkey is up"`, valkeyHost, valkeyPort, strings.Join(args, " ")),
 			},
+			Env:             env,
+			VolumeMounts:    volumeMounts,
+			Resources:       valkeySpec.Resources,
+			SecurityContext: valkeySpec.SecurityContext,
 		})
 	}
 
 	return initContainers
 }
 
+// waitForPostgresScript waits until PostgreSQL accepts connections, with the libpq environment of getPostgresClientEnv
+const waitForPostgresScript = `echo "Waiting for PostgreSQL${PGHOST:+ at ${PGHOST}:${PGPORT}}..."
+until pg_isready -q ${DB_URL:+-d "${DB_URL}"}; do
+  echo "PostgreSQL is unavailable - sleeping"
+  sleep 2
+done
+echo "PostgreSQL is up"`
+
 func (r *ImmichReconciler) getServerVolumeMounts(immich *mediav1alpha1.Immich) []corev1.VolumeMount {
 	mounts := []corev1.VolumeMount{}
 
@@ -402,6 +533,11 @@ func (r *ImmichReconciler) getServerVolumeMounts(immich *mediav1alpha1.Immich) [
 		ReadOnly:  true,
 	})
 
+	// PostgreSQL certificates, referenced by DB_URL
+	if mount := getPostgresTLSVolumeMount(immich); mount != nil {
+		mounts = append(mounts, *mount)
+	}
+
 	return mounts
 }
 
@@ -448,6 +584,16 @@ func (r *ImmichReconciler) getServerVolumes(immich *mediav1alpha1.Immich) []core
 		})
 	}
 
+	// PostgreSQL certificates, referenced by DB_URL
+	if volume := getPostgresTLSVolume(immich); volume != nil {
+		volumes = append(volumes, *volume)
+	}
+
+	// Valkey certificates, checked by the wait-for-valkey init container
+	if volume := getValkeyTLSVolume(immich); volume != nil {
+		volumes = append(volumes, *volume)
+	}
+
 	return volumes
 }
 
@@ -455,7 +601,7 @@ func (r *ImmichReconciler) getServerVolumes(immich *mediav1alpha1.Immich) []core
 func (r *ImmichReconciler) reconcileServerService(ctx context.Context, immich *mediav1alpha1.Immich) error {
 	name := fmt.Sprintf("%s-server", immich.Name)
 	labels := r.getLabels(immich, "server")
-	selectorLabels := r.getSelectorLabels(immich, "server")
+	selectorLabels := r.getSelectorLabels(immich, getServerHTTPWorkload(immich).component)
 
 	ports := []corev1.ServicePort{
 		{
@@ -469,8 +615,12 @@ func (r *ImmichReconciler) reconcileServerService(ctx context.Context, immich *m
 	if immich.IsMetricsEnabled() {
 		ports = append(ports,
 			corev1.ServicePort{Name: "metrics-api", Port: 8081, TargetPort: intstr.FromString("metrics-api"), Protocol: corev1.ProtocolTCP},
-			corev1.ServicePort{Name: "metrics-ms", Port: 8082, TargetPort: intstr.FromString("metrics-ms"), Protocol: corev1.ProtocolTCP},
 		)
+		if !immich.IsServerWorkersSplit() {
+			ports = append(ports,
+				corev1.ServicePort{Name: "metrics-ms", Port: 8082, TargetPort: intstr.FromString("metrics-ms"), Protocol: corev1.ProtocolTCP},
+			)
+		}
 	}
 
 	service := &corev1.Service{
@@ -503,6 +653,42 @@ func (r *ImmichReconciler) reconcileServerService(ctx context.Context, immich *m
 	return r.apply(ctx, service)
 }
 
+// reconcileServerMicroservicesMetricsService creates or updates the Service exposing the metrics of the
+// microservices worker in split mode, using server-side apply. It carries the server labels,
+// so that the server ServiceMonitor scrapes it along with the server Service.
+func (r *ImmichReconciler) reconcileServerMicroservicesMetricsService(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getServerMicroservicesMetricsServiceName(immich),
+			Namespace: immich.Namespace,
+			Labels:    r.getLabels(immich, "server"),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: r.getSelectorLabels(immich, "server-"+immichWorkerMicroservices),
+			Ports: []corev1.ServicePort{
+				{Name: "metrics-ms", Port: 8082, TargetPort: intstr.FromString("metrics-ms"), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+
+	return r.apply(ctx, service)
+}
+
 // reconcileServerIngress creates or updates the Server Ingress using server-side apply
 func (r *ImmichReconciler) reconcileServerIngress(ctx context.Context, immich *mediav1alpha1.Immich) error {
 	name := fmt.Sprintf("%s-server", immich.Name)
@@ -678,3 +864,114 @@ func (r *ImmichReconciler) reconcileServerRoute(ctx context.Context, immich *med
 
 	return r.apply(ctx, unstructuredRoute)
 }
+
+// reconcileServerServiceMonitor creates or updates the Prometheus Operator ServiceMonitor
+// scraping the server metrics ports, using server-side apply
+func (r *ImmichReconciler) reconcileServerServiceMonitor(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+	log.V(1).Info("Reconciling Server ServiceMonitor")
+
+	name := fmt.Sprintf("%s-server", immich.Name)
+	labels := r.getLabels(immich, "server")
+	selectorLabels := r.getSelectorLabels(immich, "server")
+
+	immichConfig := ptr.Deref(immich.Spec.Immich, mediav1alpha1.ImmichConfig{})
+	metrics := ptr.Deref(immichConfig.Metrics, mediav1alpha1.MetricsSpec{})
+	serviceMonitorSpec := ptr.Deref(metrics.ServiceMonitor, mediav1alpha1.ServiceMonitorSpec{})
+
+	// Build one endpoint per metrics port (API and microservices workers)
+	endpoints := make([]interface{}, 0, 2)
+	for _, port := range []string{"metrics-api", "metrics-ms"} {
+		endpoint := map[string]interface{}{
+			"port":   port,
+			"path":   "/metrics",
+			"scheme": "http",
+		}
+		if serviceMonitorSpec.Interval != nil && *serviceMonitorSpec.Interval != "" {
+			endpoint["interval"] = *serviceMonitorSpec.Interval
+		}
+		if serviceMonitorSpec.ScrapeTimeout != nil && *serviceMonitorSpec.ScrapeTimeout != "" {
+			endpoint["scrapeTimeout"] = *serviceMonitorSpec.ScrapeTimeout
+		}
+		if len(serviceMonitorSpec.Relabelings) > 0 {
+			endpoint["relabelings"] = relabelConfigsToUnstructured(serviceMonitorSpec.Relabelings)
+		}
+		if len(serviceMonitorSpec.MetricRelabelings) > 0 {
+			endpoint["metricRelabelings"] = relabelConfigsToUnstructured(serviceMonitorSpec.MetricRelabelings)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	matchLabels := make(map[string]interface{}, len(selectorLabels))
+	for k, v := range selectorLabels {
+		matchLabels[k] = v
+	}
+
+	// Build the ServiceMonitor object as unstructured since we don't want to import Prometheus Operator types
+	serviceMonitor := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": ServiceMonitorGVK.GroupVersion().String(),
+		"kind":       ServiceMonitorGVK.Kind,
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   immich.Namespace,
+			"labels":      r.mergeMaps(labels, serviceMonitorSpec.Labels),
+			"annotations": serviceMonitorSpec.Annotations,
+			"ownerReferences": []map[string]interface{}{
+				{
+					"apiVersion":         immich.APIVersion,
+					"kind":               immich.Kind,
+					"name":               immich.Name,
+					"uid":                string(immich.UID),
+					"controller":         true,
+					"blockOwnerDeletion": true,
+				},
+			},
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": matchLabels,
+			},
+			"namespaceSelector": map[string]interface{}{
+				"matchNames": []interface{}{immich.Namespace},
+			},
+			"endpoints": endpoints,
+		},
+	}}
+
+	return r.apply(ctx, serviceMonitor)
+}
+
+// relabelConfigsToUnstructured converts relabel configs to their unstructured ServiceMonitor representation
+func relabelConfigsToUnstructured(configs []mediav1alpha1.RelabelConfig) []interface{} {
+	result := make([]interface{}, 0, len(configs))
+	for _, c := range configs {
+		relabeling := map[string]interface{}{}
+		if len(c.SourceLabels) > 0 {
+			sourceLabels := make([]interface{}, 0, len(c.SourceLabels))
+			for _, l := range c.SourceLabels {
+				sourceLabels = append(sourceLabels, l)
+			}
+			relabeling["sourceLabels"] = sourceLabels
+		}
+		if c.Separator != nil {
+			relabeling["separator"] = *c.Separator
+		}
+		if c.TargetLabel != nil {
+			relabeling["targetLabel"] = *c.TargetLabel
+		}
+		if c.Regex != nil {
+			relabeling["regex"] = *c.Regex
+		}
+		if c.Modulus != nil {
+			relabeling["modulus"] = *c.Modulus
+		}
+		if c.Replacement != nil {
+			relabeling["replacement"] = *c.Replacement
+		}
+		if c.Action != nil {
+			relabeling["action"] = *c.Action
+		}
+		result = append(result, relabeling)
+	}
+	return result
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestIsServiceMonitorAPIAvailable(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		expected  bool
+	}{
+		{
+			name:      "no monitoring API",
+			resources: []*metav1.APIResourceList{},
+			expected:  false,
+		},
+		{
+			name: "monitoring API with ServiceMonitor",
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "monitoring.coreos.com/v1",
+					APIResources: []metav1.APIResource{{Name: "servicemonitors", Kind: "ServiceMonitor"}},
+				},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ImmichReconciler{
+				DiscoveryClient: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: tt.resources}},
+			}
+			if got := r.IsServiceMonitorAPIAvailable(); got != tt.expected {
+				t.Errorf("IsServiceMonitorAPIAvailable() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestShouldCreateServiceMonitor(t *testing.T) {
+	tests := []struct {
+		name         string
+		metrics      *mediav1alpha1.MetricsSpec
+		apiAvailable bool
+		expected     bool
+	}{
+		{
+			name:         "metrics disabled",
+			metrics:      nil,
+			apiAvailable: true,
+			expected:     false,
+		},
+		{
+			name:         "metrics enabled, API available",
+			metrics:      &mediav1alpha1.MetricsSpec{Enabled: ptr.To(true)},
+			apiAvailable: true,
+			expected:     true,
+		},
+		{
+			name:         "metrics enabled, API not available",
+			metrics:      &mediav1alpha1.MetricsSpec{Enabled: ptr.To(true)},
+			apiAvailable: false,
+			expected:     false,
+		},
+		{
+			name: "metrics enabled, ServiceMonitor explicitly disabled",
+			metrics: &mediav1alpha1.MetricsSpec{
+				Enabled:        ptr.To(true),
+				ServiceMonitor: &mediav1alpha1.ServiceMonitorSpec{Enabled: ptr.To(false)},
+			},
+			apiAvailable: true,
+			expected:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := &mediav1alpha1.Immich{
+				Spec: mediav1alpha1.ImmichSpec{
+					Immich: &mediav1alpha1.ImmichConfig{Metrics: tt.metrics},
+				},
+			}
+			if got := immich.ShouldCreateServiceMonitor(tt.apiAvailable); got != tt.expected {
+				t.Errorf("ShouldCreateServiceMonitor() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRelabelConfigsToUnstructured(t *testing.T) {
+	configs := []mediav1alpha1.RelabelConfig{
+		{
+			SourceLabels: []string{"__meta_kubernetes_pod_node_name"},
+			TargetLabel:  ptr.To("node"),
+			Action:       ptr.To("replace"),
+		},
+		{
+			Regex:  ptr.To("go_.*"),
+			Action: ptr.To("drop"),
+		},
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"sourceLabels": []interface{}{"__meta_kubernetes_pod_node_name"},
+			"targetLabel":  "node",
+			"action":       "replace",
+		},
+		map[string]interface{}{
+			"regex":  "go_.*",
+			"action": "drop",
+		},
+	}
+
+	if got := relabelConfigsToUnstructured(configs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("relabelConfigsToUnstructured() = %v, want %v", got, expected)
+	}
+}
+
+func TestGetServerInitContainers_ContainerSettings(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+	t.Setenv(mediav1alpha1.EnvRelatedImageValkey, "valkey/valkey:8")
+
+	restricted := &corev1.SecurityContext{
+		RunAsNonRoot:             ptr.To(true),
+		AllowPrivilegeEscalation: ptr.To(false),
+	}
+	immich := newTestImmich()
+	immich.Spec.Postgres = &mediav1alpha1.PostgresSpec{
+		ImagePullPolicy: corev1.PullAlways,
+		SecurityContext: restricted,
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+		},
+	}
+	immich.Spec.Valkey = &mediav1alpha1.ValkeySpec{
+		ImagePullPolicy: corev1.PullNever,
+		SecurityContext: restricted,
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+		},
+	}
+
+	r := &ImmichReconciler{}
+	initContainers := r.getServerInitContainers(immich)
+	if len(initContainers) != 2 {
+		t.Fatalf("init containers = %+v, want wait-for-postgres and wait-for-valkey", initContainers)
+	}
+
+	for _, tt := range []struct {
+		container  corev1.Container
+		pullPolicy corev1.PullPolicy
+		memory     string
+	}{
+		{initContainers[0], corev1.PullAlways, "512Mi"},
+		{initContainers[1], corev1.PullNever, "128Mi"},
+	} {
+		if tt.container.ImagePullPolicy != tt.pullPolicy {
+			t.Errorf("%s imagePullPolicy = %q, want %q", tt.container.Name, tt.container.ImagePullPolicy, tt.pullPolicy)
+		}
+		if tt.container.SecurityContext != restricted {
+			t.Errorf("%s securityContext = %+v, want %+v", tt.container.Name, tt.container.SecurityContext, restricted)
+		}
+		if memory := tt.container.Resources.Limits[corev1.ResourceMemory]; memory.String() != tt.memory {
+			t.Errorf("%s memory limit = %s, want %s", tt.container.Name, memory.String(), tt.memory)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// Immich server workers, as passed to IMMICH_WORKERS_INCLUDE
+const (
+	immichWorkerAPI           = "api"
+	immichWorkerMicroservices = "microservices"
+)
+
+// serverWorkload is a Deployment running Immich server workers, with its settings resolved from the spec
+type serverWorkload struct {
+	name      string
+	component string
+	// worker is the single worker run by the Deployment, or empty when it runs both
+	worker         string
+	replicas       *int32
+	autoscaling    *mediav1alpha1.AutoscalingSpec
+	resources      corev1.ResourceRequirements
+	nodeSelector   map[string]string
+	livenessProbe  *corev1.Probe
+	readinessProbe *corev1.Probe
+	startupProbe   *corev1.Probe
+	// transcoding is the device access of the pods running the microservices worker, which transcodes videos
+	transcoding accelerationProfile
+}
+
+// runsMicroservices returns true if the workload runs the microservices worker, which runs the background jobs
+func (w serverWorkload) runsMicroservices() bool {
+	return w.worker != immichWorkerAPI
+}
+
+// servesHTTP returns true if the workload runs the api worker, which serves the web UI and the API
+func (w serverWorkload) servesHTTP() bool {
+	return w.worker != immichWorkerMicroservices
+}
+
+// getServerWorkloads returns the Deployments running the server: the <name>-server Deployment running both workers,
+// or, in split mode, the <name>-server-api and <name>-server-microservices Deployments.
+// The Deployment serving HTTP always comes first.
+func getServerWorkloads(immich *mediav1alpha1.Immich) []serverWorkload {
+	serverSpec := ptr.Deref(immich.Spec.Server, mediav1alpha1.ServerSpec{})
+
+	if !immich.IsServerWorkersSplit() {
+		return []serverWorkload{{
+			name:           fmt.Sprintf("%s-server", immich.Name),
+			component:      "server",
+			replicas:       serverSpec.Replicas,
+			autoscaling:    serverSpec.Autoscaling,
+			resources:      serverSpec.Resources,
+			nodeSelector:   serverSpec.NodeSelector,
+			livenessProbe:  getServerPingProbe(3),
+			readinessProbe: getServerPingProbe(3),
+			startupProbe:   getServerPingProbe(30),
+		}}
+	}
+
+	newWorkload := func(worker string, workerSpec *mediav1alpha1.ServerWorkerSpec) serverWorkload {
+		spec := ptr.Deref(workerSpec, mediav1alpha1.ServerWorkerSpec{})
+		workload := serverWorkload{
+			name:           fmt.Sprintf("%s-server-%s", immich.Name, worker),
+			component:      "server-" + worker,
+			worker:         worker,
+			replicas:       serverSpec.Replicas,
+			autoscaling:    serverSpec.Autoscaling,
+			resources:      serverSpec.Resources,
+			nodeSelector:   serverSpec.NodeSelector,
+			livenessProbe:  spec.LivenessProbe,
+			readinessProbe: spec.ReadinessProbe,
+			startupProbe:   spec.StartupProbe,
+		}
+		if spec.Replicas != nil {
+			workload.replicas = spec.Replicas
+		}
+		if spec.Autoscaling != nil {
+			workload.autoscaling = spec.Autoscaling
+		}
+		if spec.Resources != nil {
+			workload.resources = *spec.Resources
+		}
+		if spec.NodeSelector != nil {
+			workload.nodeSelector = spec.NodeSelector
+		}
+		return workload
+	}
+
+	workers := ptr.Deref(serverSpec.Workers, mediav1alpha1.ServerWorkersSpec{})
+	api := newWorkload(immichWorkerAPI, workers.API)
+	if api.livenessProbe == nil {
+		api.livenessProbe = getServerPingProbe(3)
+	}
+	if api.readinessProbe == nil {
+		api.readinessProbe = getServerPingProbe(3)
+	}
+	if api.startupProbe == nil {
+		api.startupProbe = getServerPingProbe(30)
+	}
+
+	// The microservices worker serves no HTTP, it has no probe unless one is configured
+	microservices := newWorkload(immichWorkerMicroservices, workers.Microservices)
+
+	return []serverWorkload{api, microservices}
+}
+
+// getServerHTTPWorkload returns the Deployment serving the web UI and the API, behind the server Service
+func getServerHTTPWorkload(immich *mediav1alpha1.Immich) serverWorkload {
+	return getServerWorkloads(immich)[0]
+}
+
+// getServerPingProbe returns a probe on the /api/server/ping endpoint of the server
+func getServerPingProbe(failureThreshold int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "/api/server/ping",
+				Port: intstr.FromString("http"),
+			},
+		},
+		InitialDelaySeconds: 0,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      1,
+		FailureThreshold:    failureThreshold,
+	}
+}
+
+// getServerMicroservicesMetricsServiceName returns the name of the Service exposing the metrics
+// of the microservices worker in split mode
+func getServerMicroservicesMetricsServiceName(immich *mediav1alpha1.Immich) string {
+	return fmt.Sprintf("%s-server-microservices", immich.Name)
+}
+
+// getServerAffinity returns the affinity of a server Deployment. When the library volume cannot be mounted from
+// several nodes, the microservices worker must run on the node of the api worker, which also mounts it.
+func (r *ImmichReconciler) getServerAffinity(immich *mediav1alpha1.Immich, workload serverWorkload) *corev1.Affinity {
+	var affinity *corev1.Affinity
+	if immich.Spec.Server != nil {
+		affinity = immich.Spec.Server.Affinity
+	}
+	if workload.worker != immichWorkerMicroservices || isLibrarySharedAcrossNodes(immich) {
+		return affinity
+	}
+
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	} else {
+		affinity = affinity.DeepCopy()
+	}
+	if affinity.PodAffinity == nil {
+		affinity.PodAffinity = &corev1.PodAffinity{}
+	}
+	affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+		affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+		corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: r.getSelectorLabels(immich, getServerHTTPWorkload(immich).component),
+			},
+			TopologyKey: corev1.LabelHostname,
+		},
+	)
+	return affinity
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func getContainerPorts(deployment *appsv1.Deployment) []string {
+	var ports []string
+	for _, port := range deployment.Spec.Template.Spec.Containers[0].Ports {
+		ports = append(ports, port.Name)
+	}
+	return ports
+}
+
+func TestReconcileServer_CombinedWorkers(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "ghcr.io/immich-app/immich-server:v1.125.7")
+
+	immich := newTestImmich(withSplitWorkers())
+	immich.Spec.Server.Workers.Mode = ptr.To(mediav1alpha1.ServerWorkersModeCombined)
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+	if err := r.reconcileServer(context.Background(), immich); err != nil {
+		t.Fatalf("reconcileServer() error = %v", err)
+	}
+
+	deployment, ok := applied["Deployment/test-immich-server"].(*appsv1.Deployment)
+	if !ok {
+		t.Fatalf("server Deployment not applied, got %v", applied)
+	}
+	if env := findEnv(deployment.Spec.Template.Spec.Containers[0].Env, "IMMICH_WORKERS_INCLUDE"); env != nil {
+		t.Errorf("IMMICH_WORKERS_INCLUDE = %q, want it unset", env.Value)
+	}
+	if ports := getContainerPorts(deployment); !reflect.DeepEqual(ports, []string{"http", "metrics-api", "metrics-ms"}) {
+		t.Errorf("ports = %v", ports)
+	}
+	for _, key := range []string{"Deployment/test-immich-server-api", "Deployment/test-immich-server-microservices",
+		"Service/test-immich-server-microservices"} {
+		if _, ok := applied[key]; ok {
+			t.Errorf("%s should not be applied in combined mode", key)
+		}
+	}
+}
+
+func TestReconcileServer_SplitWorkers(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "ghcr.io/immich-app/immich-server:v1.125.7")
+
+	immich := newTestImmich(withSplitWorkers())
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+	if err := r.reconcileServer(context.Background(), immich); err != nil {
+		t.Fatalf("reconcileServer() error = %v", err)
+	}
+
+	if _, ok := applied["Deployment/test-immich-server"]; ok {
+		t.Error("the combined server Deployment should not be applied in split mode")
+	}
+
+	api := applied["Deployment/test-immich-server-api"].(*appsv1.Deployment)
+	apiContainer := api.Spec.Template.Spec.Containers[0]
+	if env := findEnv(apiContainer.Env, "IMMICH_WORKERS_INCLUDE"); env == nil || env.Value != "api" {
+		t.Errorf("api IMMICH_WORKERS_INCLUDE = %+v, want api", env)
+	}
+	if ports := getContainerPorts(api); !reflect.DeepEqual(ports, []string{"http", "metrics-api"}) {
+		t.Errorf("api ports = %v", ports)
+	}
+	if apiContainer.ReadinessProbe == nil || apiContainer.ReadinessProbe.HTTPGet.Path != "/api/server/ping" {
+		t.Errorf("api readiness probe = %+v", apiContainer.ReadinessProbe)
+	}
+	if *api.Spec.Replicas != 2 || api.Spec.Template.Spec.NodeSelector["pool"] != "web" {
+		t.Errorf("api replicas = %d, nodeSelector = %v, want the server settings", *api.Spec.Replicas, api.Spec.Template.Spec.NodeSelector)
+	}
+
+	microservices := applied["Deployment/test-immich-server-microservices"].(*appsv1.Deployment)
+	msContainer := microservices.Spec.Template.Spec.Containers[0]
+	if env := findEnv(msContainer.Env, "IMMICH_WORKERS_INCLUDE"); env == nil || env.Value != "microservices" {
+		t.Errorf("microservices IMMICH_WORKERS_INCLUDE = %+v, want microservices", env)
+	}
+	if ports := getContainerPorts(microservices); !reflect.DeepEqual(ports, []string{"metrics-ms"}) {
+		t.Errorf("microservices ports = %v", ports)
+	}
+	if msContainer.LivenessProbe != nil || msContainer.ReadinessProbe != nil || msContainer.StartupProbe != nil {
+		t.Error("the microservices worker serves no HTTP and should have no default probe")
+	}
+	if *microservices.Spec.Replicas != 1 || microservices.Spec.Template.Spec.NodeSelector["pool"] != "batch" ||
+		!msContainer.Resources.Limits.Memory().Equal(resource.MustParse("4Gi")) {
+		t.Errorf("microservices replicas = %d, nodeSelector = %v, resources = %+v",
+			*microservices.Spec.Replicas, microservices.Spec.Template.Spec.NodeSelector, msContainer.Resources)
+	}
+
+	// The library volume is ReadWriteOnce by default: the microservices pods must run next to the api pods
+	affinity := microservices.Spec.Template.Spec.Affinity
+	if affinity == nil || affinity.PodAffinity == nil || len(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 1 ||
+		!reflect.DeepEqual(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchLabels,
+			r.getSelectorLabels(immich, "server-api")) {
+		t.Errorf("microservices affinity = %+v", affinity)
+	}
+	if api.Spec.Template.Spec.Affinity != nil {
+		t.Errorf("api affinity = %+v, want none", api.Spec.Template.Spec.Affinity)
+	}
+
+	service := applied["Service/test-immich-server"].(*corev1.Service)
+	if !reflect.DeepEqual(service.Spec.Selector, r.getSelectorLabels(immich, "server-api")) {
+		t.Errorf("server Service selector = %v, want the api pods", service.Spec.Selector)
+	}
+	for _, port := range service.Spec.Ports {
+		if port.Name == "metrics-ms" {
+			t.Error("the server Service should not expose the microservices metrics in split mode")
+		}
+	}
+
+	metrics := applied["Service/test-immich-server-microservices"].(*corev1.Service)
+	if !reflect.DeepEqual(metrics.Spec.Selector, r.getSelectorLabels(immich, "server-microservices")) {
+		t.Errorf("microservices metrics Service selector = %v", metrics.Spec.Selector)
+	}
+	if metrics.Labels[labelComponent] != "server" {
+		t.Errorf("microservices metrics Service component = %q, want it selected by the server ServiceMonitor", metrics.Labels[labelComponent])
+	}
+}
+
+func TestGetDesiredObjects_SplitWorkers(t *testing.T) {
+	immich := newTestImmich(withSplitWorkers())
+	r := &ImmichReconciler{}
+
+	desired := r.getDesiredObjects(immich)
+	deployments := desired[appsv1.SchemeGroupVersion.WithKind("Deployment")]
+	if deployments.Has("test-immich-server") {
+		t.Error("the combined server Deployment should be pruned in split mode")
+	}
+	if !deployments.Has("test-immich-server-api") || !deployments.Has("test-immich-server-microservices") {
+		t.Errorf("Deployments = %v, want both workers", deployments.UnsortedList())
+	}
+	services := desired[corev1.SchemeGroupVersion.WithKind("Service")]
+	if !services.Has("test-immich-server") || !services.Has("test-immich-server-microservices") {
+		t.Errorf("Services = %v", services.UnsortedList())
+	}
+}
@@ -21,35 +21,77 @@ import (
 	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
 )
 
 // updateStatus updates the status of the Immich resource
 func (r *ImmichReconciler) updateStatus(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+
+	previous := ptr.Deref(immich.Status.Components, mediav1alpha1.ComponentsStatus{})
+	components := &mediav1alpha1.ComponentsStatus{}
+
 	// Check Server status
 	if immich.IsServerEnabled() {
-		deployment := &appsv1.Deployment{}
-		name := fmt.Sprintf("%s-server", immich.Name)
-		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, deployment); err != nil {
-			if !apierrors.IsNotFound(err) {
-				return err
+		// In split mode, the server is ready once both the api and microservices workers are
+		immich.Status.ServerReady = true
+		var deployments []*appsv1.Deployment
+		for _, workload := range getServerWorkloads(immich) {
+			previousStatus, status := previous.Server, &components.Server
+			if workload.worker == immichWorkerMicroservices {
+				previousStatus, status = previous.ServerMicroservices, &components.ServerMicroservices
 			}
-			immich.Status.ServerReady = false
-		} else {
-			immich.Status.ServerReady = deployment.Status.ReadyReplicas > 0 &&
+
+			deployment := &appsv1.Deployment{}
+			if err := r.Get(ctx, types.NamespacedName{Name: workload.name, Namespace: immich.Namespace}, deployment); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+				immich.Status.ServerReady = false
+				*status = getComponentStatus(previousStatus, "", 0, 0, false)
+				continue
+			}
+			ready := deployment.Status.ReadyReplicas > 0 &&
 				deployment.Status.ReadyReplicas == deployment.Status.Replicas
+			immich.Status.ServerReady = immich.Status.ServerReady && ready
+			*status = getDeploymentComponentStatus(previousStatus, deployment, ready)
+			deployments = append(deployments, deployment)
+		}
+		if len(deployments) > 0 {
+			setConfigRolloutCondition(immich, deployments...)
+		}
+
+		// Report the version actually serving users, keeping the last known one while the server is down
+		if immich.Status.ServerReady {
+			if version, err := r.getServerVersion(ctx, immich); err != nil {
+				log.V(1).Info("Failed to get the Immich version from the server", "error", err.Error())
+			} else {
+				immich.Status.Version = version
+			}
 		}
 	} else {
 		immich.Status.ServerReady = true
+		immich.Status.Version = ""
+		meta.RemoveStatusCondition(&immich.Status.Conditions, ConditionTypeConfigRolledOut)
 	}
 
 	// Check ML status
-	if immich.IsMachineLearningEnabled() {
+	if immich.HasMachineLearningBackends() {
+		if err := r.updateMachineLearningBackendsStatus(ctx, immich, &previous, components); err != nil {
+			return err
+		}
+	} else if immich.IsMachineLearningEnabled() {
 		deployment := &appsv1.Deployment{}
 		name := fmt.Sprintf("%s-machine-learning", immich.Name)
 		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, deployment); err != nil {
@@ -57,16 +99,22 @@ func (r *ImmichReconciler) updateStatus(ctx context.Context, immich *mediav1alph
 				return err
 			}
 			immich.Status.MachineLearningReady = false
+			components.MachineLearning = getComponentStatus(previous.MachineLearning, "", 0, 0, false)
 		} else {
 			immich.Status.MachineLearningReady = deployment.Status.ReadyReplicas > 0 &&
 				deployment.Status.ReadyReplicas == deployment.Status.Replicas
+			components.MachineLearning = getDeploymentComponentStatus(previous.MachineLearning, deployment, immich.Status.MachineLearningReady)
 		}
 	} else {
 		immich.Status.MachineLearningReady = true
 	}
 
 	// Check Valkey status
-	if immich.IsValkeyEnabled() {
+	if immich.IsValkeySentinelEnabled() {
+		if err := r.updateValkeySentinelStatus(ctx, immich, previous, components); err != nil {
+			return err
+		}
+	} else if immich.IsValkeyEnabled() {
 		deployment := &appsv1.Deployment{}
 		name := fmt.Sprintf("%s-valkey", immich.Name)
 		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, deployment); err != nil {
@@ -74,16 +122,22 @@ func (r *ImmichReconciler) updateStatus(ctx context.Context, immich *mediav1alph
 				return err
 			}
 			immich.Status.ValkeyReady = false
+			components.Valkey = getComponentStatus(previous.Valkey, "", 0, 0, false)
 		} else {
 			immich.Status.ValkeyReady = deployment.Status.ReadyReplicas > 0 &&
 				deployment.Status.ReadyReplicas == deployment.Status.Replicas
+			components.Valkey = getDeploymentComponentStatus(previous.Valkey, deployment, immich.Status.ValkeyReady)
 		}
 	} else {
 		immich.Status.ValkeyReady = true
 	}
 
 	// Check PostgreSQL status
-	if immich.IsPostgresEnabled() {
+	if immich.IsCloudNativePGEnabled() {
+		if err := r.updateCloudNativePGStatus(ctx, immich, previous, components); err != nil {
+			return err
+		}
+	} else if immich.IsPostgresEnabled() {
 		sts := &appsv1.StatefulSet{}
 		name := fmt.Sprintf("%s-postgres", immich.Name)
 		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, sts); err != nil {
@@ -91,14 +145,41 @@ func (r *ImmichReconciler) updateStatus(ctx context.Context, immich *mediav1alph
 				return err
 			}
 			immich.Status.PostgresReady = false
+			components.Postgres = getComponentStatus(previous.Postgres, "", 0, 0, false)
 		} else {
 			immich.Status.PostgresReady = sts.Status.ReadyReplicas > 0 &&
 				sts.Status.ReadyReplicas == sts.Status.Replicas
+			components.Postgres = getComponentStatus(previous.Postgres, getContainerImage(sts.Spec.Template.Spec),
+				ptr.Deref(sts.Spec.Replicas, 1), sts.Status.ReadyReplicas, immich.Status.PostgresReady)
 		}
 	} else {
 		immich.Status.PostgresReady = true
 	}
 
+	immich.Status.Components = components
+
+	// Report the last successful PostgreSQL backup
+	if immich.IsPostgresBackupEnabled() {
+		cronJob := &batchv1.CronJob{}
+		name := fmt.Sprintf("%s-postgres-backup", immich.Name)
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, cronJob); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+		} else if cronJob.Status.LastSuccessfulTime != nil {
+			immich.Status.LastSuccessfulBackupTime = cronJob.Status.LastSuccessfulTime
+		}
+	}
+
+	// Report the newest Immich database dump copied out of the library volume
+	if immich.IsBackupCopyEnabled() {
+		databaseBackup, err := r.getDatabaseBackupStatus(ctx, immich)
+		if err != nil {
+			return err
+		}
+		immich.Status.DatabaseBackup = databaseBackup
+	}
+
 	// Overall ready status
 	immich.Status.Ready = immich.Status.ServerReady &&
 		immich.Status.MachineLearningReady &&
@@ -114,6 +195,83 @@ func (r *ImmichReconciler) updateStatus(ctx context.Context, immich *mediav1alph
 	return nil
 }
 
+// getDeploymentComponentStatus returns the status of a component deployed as a Deployment
+func getDeploymentComponentStatus(previous *mediav1alpha1.ComponentStatus, deployment *appsv1.Deployment, ready bool) *mediav1alpha1.ComponentStatus {
+	return getComponentStatus(previous, getContainerImage(deployment.Spec.Template.Spec),
+		ptr.Deref(deployment.Spec.Replicas, 1), deployment.Status.ReadyReplicas, ready)
+}
+
+// getComponentStatus returns the status of a component, keeping the last transition time while its readiness is unchanged
+func getComponentStatus(previous *mediav1alpha1.ComponentStatus, image string, replicas, readyReplicas int32, ready bool) *mediav1alpha1.ComponentStatus {
+	status := &mediav1alpha1.ComponentStatus{
+		Image:         image,
+		Ready:         ready,
+		Replicas:      replicas,
+		ReadyReplicas: readyReplicas,
+	}
+	if previous != nil && previous.Ready == ready && previous.LastTransitionTime != nil {
+		status.LastTransitionTime = previous.LastTransitionTime
+	} else {
+		now := metav1.Now()
+		status.LastTransitionTime = &now
+	}
+	return status
+}
+
+// getContainerImage returns the image of the main (first) container of a pod spec
+func getContainerImage(podSpec corev1.PodSpec) string {
+	if len(podSpec.Containers) == 0 {
+		return ""
+	}
+	return podSpec.Containers[0].Image
+}
+
+// setConfigRolloutCondition reports whether the server pods run the current configuration.
+// The rollout is complete once every server Deployment carries the current config hash and all of
+// its replicas have been updated.
+func setConfigRolloutCondition(immich *mediav1alpha1.Immich, deployments ...*appsv1.Deployment) {
+	if immich.Status.ConfigHash == "" {
+		return
+	}
+
+	shortHash := immich.Status.ConfigHash
+	if len(shortHash) > 12 {
+		shortHash = shortHash[:12]
+	}
+
+	var deployment *appsv1.Deployment
+	var desiredReplicas int32
+	for _, d := range deployments {
+		desiredReplicas = ptr.Deref(d.Spec.Replicas, 1)
+		rolledOut := d.Spec.Template.Annotations[configHashAnnotation] == immich.Status.ConfigHash &&
+			d.Status.ObservedGeneration >= d.Generation &&
+			d.Status.UpdatedReplicas == desiredReplicas &&
+			d.Status.Replicas == d.Status.UpdatedReplicas
+		if !rolledOut {
+			deployment = d
+			break
+		}
+	}
+
+	if deployment == nil {
+		meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+			Type:    ConditionTypeConfigRolledOut,
+			Status:  metav1.ConditionTrue,
+			Reason:  "RolloutComplete",
+			Message: fmt.Sprintf("Server pods are running configuration %s", shortHash),
+		})
+		return
+	}
+
+	meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+		Type:   ConditionTypeConfigRolledOut,
+		Status: metav1.ConditionFalse,
+		Reason: "RollingOut",
+		Message: fmt.Sprintf("Rolling out configuration %s to server pods (%d/%d updated)",
+			shortHash, deployment.Status.UpdatedReplicas, desiredReplicas),
+	})
+}
+
 // updateURLStatus updates the URL in the Immich status from Route or Ingress
 func (r *ImmichReconciler) updateURLStatus(ctx context.Context, immich *mediav1alpha1.Immich) error {
 	name := fmt.Sprintf("%s-server", immich.Name)
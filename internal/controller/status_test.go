@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestUpdateStatus_Components(t *testing.T) {
+	ctx := context.Background()
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Valkey: &mediav1alpha1.ValkeySpec{Enabled: ptr.To(false)},
+		},
+	}
+	lastTransition := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	immich.Status.Components = &mediav1alpha1.ComponentsStatus{
+		Server:   &mediav1alpha1.ComponentStatus{Ready: true, LastTransitionTime: &lastTransition},
+		Postgres: &mediav1alpha1.ComponentStatus{Ready: true, LastTransitionTime: &lastTransition},
+	}
+
+	server := newComponentDeployment("test-immich-server", "immich-server:v1.120.0", 2)
+	ml := newComponentDeployment("test-immich-machine-learning", "immich-machine-learning:v1.120.0", 1)
+	ml.Status.ReadyReplicas = 0
+	postgres := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich-postgres", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: ptr.To(int32(1)),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "postgres", Image: "postgres:14"}}},
+			},
+		},
+		Status: appsv1.StatefulSetStatus{Replicas: 1},
+	}
+
+	version := "v1.120.0"
+	r := &ImmichReconciler{
+		Client:     fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(server, ml, postgres).Build(),
+		HTTPClient: newServerAPIClient(t, &version),
+	}
+
+	if err := r.updateStatus(ctx, immich); err != nil {
+		t.Fatalf("updateStatus() error = %v", err)
+	}
+
+	if immich.Status.Version != "v1.120.0" {
+		t.Errorf("version = %s, want v1.120.0", immich.Status.Version)
+	}
+
+	components := immich.Status.Components
+	if components.Valkey != nil {
+		t.Errorf("disabled Valkey should not be reported, got %+v", components.Valkey)
+	}
+
+	got := components.Server
+	if got.Image != "immich-server:v1.120.0" || !got.Ready || got.Replicas != 2 || got.ReadyReplicas != 2 {
+		t.Errorf("server = %+v", got)
+	}
+	if !got.LastTransitionTime.Equal(&lastTransition) {
+		t.Errorf("server lastTransitionTime = %v, want it unchanged", got.LastTransitionTime)
+	}
+
+	got = components.MachineLearning
+	if got.Image != "immich-machine-learning:v1.120.0" || got.Ready || got.Replicas != 1 || got.ReadyReplicas != 0 {
+		t.Errorf("machine learning = %+v", got)
+	}
+	if got.LastTransitionTime == nil {
+		t.Error("machine learning lastTransitionTime should be set")
+	}
+
+	got = components.Postgres
+	if got.Image != "postgres:14" || got.Ready {
+		t.Errorf("postgres = %+v", got)
+	}
+	if got.LastTransitionTime.Equal(&lastTransition) {
+		t.Error("postgres lastTransitionTime should change when it stops being ready")
+	}
+
+	// The last known version is kept while the server cannot be reached
+	version = ""
+	if err := r.updateStatus(ctx, immich); err != nil {
+		t.Fatalf("updateStatus() error = %v", err)
+	}
+	if immich.Status.Version != "v1.120.0" {
+		t.Errorf("version = %s, want the last known version", immich.Status.Version)
+	}
+}
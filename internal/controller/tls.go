@@ -0,0 +1,291 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+const (
+	// postgresTLSDir is where the PostgreSQL TLS certificates are mounted in the server and preflight pods
+	postgresTLSDir = "/etc/immich/tls/postgres"
+
+	// postgresTLSVolumeName is the name of the volume holding the PostgreSQL TLS certificates
+	postgresTLSVolumeName = "postgres-tls"
+
+	// valkeyTLSDir is where the Valkey TLS certificates are mounted in the wait-for-valkey init container
+	valkeyTLSDir = "/etc/immich/tls/valkey"
+
+	// valkeyTLSVolumeName is the name of the volume holding the Valkey TLS certificates
+	valkeyTLSVolumeName = "valkey-tls"
+
+	// connectionHashAnnotation records the connection settings and certificates the server pods were started with
+	connectionHashAnnotation = "media.rm3l.org/connection-hash"
+)
+
+// getTLSSecretRefs returns the Secret keys referenced by a TLS configuration
+func getTLSSecretRefs(tls *mediav1alpha1.TLSSpec) []mediav1alpha1.SecretKeySelector {
+	var refs []mediav1alpha1.SecretKeySelector
+	if tls == nil {
+		return refs
+	}
+	for _, ref := range []*mediav1alpha1.SecretKeySelector{tls.CASecretRef, tls.ClientCertSecretRef, tls.ClientKeySecretRef} {
+		if ref != nil {
+			refs = append(refs, *ref)
+		}
+	}
+	return refs
+}
+
+// getTLSVolume returns a volume projecting the CA and client certificates of a TLS configuration
+// as ca.crt, tls.crt and tls.key, or nil if the connection does not use certificates
+func getTLSVolume(name string, tls *mediav1alpha1.TLSSpec) *corev1.Volume {
+	if len(getTLSSecretRefs(tls)) == 0 {
+		return nil
+	}
+
+	var sources []corev1.VolumeProjection
+	project := func(ref *mediav1alpha1.SecretKeySelector, file string, mode *int32) {
+		if ref == nil {
+			return
+		}
+		sources = append(sources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+				Items: []corev1.KeyToPath{
+					{Key: ref.Key, Path: file, Mode: mode},
+				},
+			},
+		})
+	}
+	project(tls.CASecretRef, "ca.crt", nil)
+	project(tls.ClientCertSecretRef, "tls.crt", nil)
+	// libpq refuses private keys readable by others
+	project(tls.ClientKeySecretRef, "tls.key", ptr.To(int32(0640)))
+
+	return &corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{Sources: sources},
+		},
+	}
+}
+
+// getPostgresTLSVolume returns the volume projecting the PostgreSQL CA and client certificates,
+// or nil if the connection does not use certificates
+func getPostgresTLSVolume(immich *mediav1alpha1.Immich) *corev1.Volume {
+	return getTLSVolume(postgresTLSVolumeName, immich.GetPostgresTLS())
+}
+
+// getPostgresTLSVolumeMount returns the mount of the PostgreSQL TLS volume, or nil if the connection does not use certificates
+func getPostgresTLSVolumeMount(immich *mediav1alpha1.Immich) *corev1.VolumeMount {
+	if len(getTLSSecretRefs(immich.GetPostgresTLS())) == 0 {
+		return nil
+	}
+	return &corev1.VolumeMount{
+		Name:      postgresTLSVolumeName,
+		MountPath: postgresTLSDir,
+		ReadOnly:  true,
+	}
+}
+
+// getValkeyTLSVolume returns the volume projecting the Valkey CA and client certificates, checked by the
+// wait-for-valkey init container, or nil if the connection does not use certificates.
+// The server reads them from REDIS_URL.
+func getValkeyTLSVolume(immich *mediav1alpha1.Immich) *corev1.Volume {
+	return getTLSVolume(valkeyTLSVolumeName, immich.GetValkeyTLS())
+}
+
+// getPostgresTLSVolumes returns the volume holding the PostgreSQL certificates and its mount, to add to the pods
+// connecting to the database, or nil if the connection does not use certificates
+func getPostgresTLSVolumes(immich *mediav1alpha1.Immich) ([]corev1.Volume, []corev1.VolumeMount) {
+	volume := getPostgresTLSVolume(immich)
+	if volume == nil {
+		return nil, nil
+	}
+	return []corev1.Volume{*volume}, []corev1.VolumeMount{*getPostgresTLSVolumeMount(immich)}
+}
+
+// getPostgresTLSEnv returns the libpq environment variables encrypting the connection with the certificates
+// mounted by getPostgresTLSVolumes, or nil if the connection is not encrypted
+func getPostgresTLSEnv(immich *mediav1alpha1.Immich) []corev1.EnvVar {
+	tls := immich.GetPostgresTLS()
+	if tls == nil {
+		return nil
+	}
+
+	env := []corev1.EnvVar{{Name: "PGSSLMODE", Value: tls.GetSSLMode()}}
+	for _, file := range []struct {
+		name string
+		ref  *mediav1alpha1.SecretKeySelector
+		file string
+	}{
+		{"PGSSLROOTCERT", tls.CASecretRef, "ca.crt"},
+		{"PGSSLCERT", tls.ClientCertSecretRef, "tls.crt"},
+		{"PGSSLKEY", tls.ClientKeySecretRef, "tls.key"},
+	} {
+		if file.ref != nil {
+			env = append(env, corev1.EnvVar{Name: file.name, Value: path.Join(postgresTLSDir, file.file)})
+		}
+	}
+	return env
+}
+
+// getDatabaseURLSecretName returns the name of the Secret holding the DB_URL of the server with TLS options
+func getDatabaseURLSecretName(immich *mediav1alpha1.Immich) string {
+	return fmt.Sprintf("%s-database-url", immich.Name)
+}
+
+// reconcileDatabaseURLSecret creates or updates the Secret holding the DB_URL of the server, with the sslmode
+// and the paths of the mounted certificates as query parameters. The URL starts from spec.postgres.urlSecretRef
+// if set, otherwise from the host, port, database, username and password.
+func (r *ImmichReconciler) reconcileDatabaseURLSecret(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	tls := immich.GetPostgresTLS()
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+
+	var dbURL *url.URL
+	if postgresSpec.URLSecretRef != nil {
+		value, err := r.getSecretValue(ctx, immich, *postgresSpec.URLSecretRef)
+		if err != nil {
+			return fmt.Errorf("failed to read the database URL: %w", err)
+		}
+		if dbURL, err = url.Parse(value); err != nil {
+			// The URL is not part of the error, as it holds the password
+			return fmt.Errorf("invalid database URL in Secret %s", postgresSpec.URLSecretRef.Name)
+		}
+	} else {
+		password, err := r.getSecretValue(ctx, immich, *getPostgresPasswordSecretRef(immich))
+		if err != nil {
+			return fmt.Errorf("failed to read the database password: %w", err)
+		}
+		dbURL = &url.URL{
+			Scheme: "postgresql",
+			User:   url.UserPassword(immich.GetPostgresUsername(), password),
+			Host:   fmt.Sprintf("%s:%d", immich.GetPostgresHost(), immich.GetPostgresPort()),
+			Path:   "/" + immich.GetPostgresDatabase(),
+		}
+	}
+
+	query := dbURL.Query()
+	query.Set("sslmode", tls.GetSSLMode())
+	for _, file := range []struct {
+		param string
+		ref   *mediav1alpha1.SecretKeySelector
+		name  string
+	}{
+		{"sslrootcert", tls.CASecretRef, "ca.crt"},
+		{"sslcert", tls.ClientCertSecretRef, "tls.crt"},
+		{"sslkey", tls.ClientKeySecretRef, "tls.key"},
+	} {
+		query.Del(file.param)
+		if file.ref != nil {
+			query.Set(file.param, path.Join(postgresTLSDir, file.name))
+		}
+	}
+	dbURL.RawQuery = query.Encode()
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getDatabaseURLSecretName(immich),
+			Namespace: immich.Namespace,
+			Labels:    r.getLabels(immich, "postgres"),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Data: map[string][]byte{
+			"url": []byte(dbURL.String()),
+		},
+	}
+
+	return r.apply(ctx, secret)
+}
+
+// computeConnectionHash returns a hash of the connection URL Secrets and of the PostgreSQL certificates,
+// or an empty string if the server connects without them. Immich reads them at startup only,
+// so the server pods are restarted when they change.
+func (r *ImmichReconciler) computeConnectionHash(ctx context.Context, immich *mediav1alpha1.Immich) (string, error) {
+	var refs []mediav1alpha1.SecretKeySelector
+	if immich.GetPostgresTLS() != nil {
+		refs = append(refs, mediav1alpha1.SecretKeySelector{Name: getDatabaseURLSecretName(immich), Key: "url"})
+		refs = append(refs, getTLSSecretRefs(immich.GetPostgresTLS())...)
+	}
+	if usesValkeyURL(immich) {
+		refs = append(refs, mediav1alpha1.SecretKeySelector{Name: getValkeyURLSecretName(immich), Key: "url"})
+	}
+	if len(refs) == 0 {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	for _, ref := range refs {
+		hash.Write([]byte(ref.Name + "/" + ref.Key + "="))
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: immich.Namespace}, secret)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return "", err
+			}
+			// A missing secret is hashed as such, so that its creation triggers a rollout
+			continue
+		}
+		hash.Write(secret.Data[ref.Key])
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getConnectionSecretRefs returns the Secrets the connection URL Secrets are rendered from:
+// the PostgreSQL credentials and certificates when TLS is enabled, and the Valkey certificates
+func getConnectionSecretRefs(immich *mediav1alpha1.Immich) []mediav1alpha1.SecretKeySelector {
+	var refs []mediav1alpha1.SecretKeySelector
+	if tls := immich.GetPostgresTLS(); tls != nil {
+		refs = append(refs, getTLSSecretRefs(tls)...)
+		postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+		if postgresSpec.URLSecretRef != nil {
+			refs = append(refs, *postgresSpec.URLSecretRef)
+		} else {
+			refs = append(refs, *getPostgresPasswordSecretRef(immich))
+		}
+	}
+	return append(refs, getTLSSecretRefs(immich.GetValkeyTLS())...)
+}
@@ -0,0 +1,382 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// newPostgresTLSSecrets returns the database password and the certificates referenced by newPostgresTLS
+func newPostgresTLSSecrets() []client.Object {
+	return []client.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("p@ss/word")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"},
+			Data: map[string][]byte{
+				"ca.crt":  []byte("CA"),
+				"tls.crt": []byte("CERT"),
+				"tls.key": []byte("KEY"),
+			},
+		},
+	}
+}
+
+func newPostgresTLS() *mediav1alpha1.TLSSpec {
+	return &mediav1alpha1.TLSSpec{
+		CASecretRef:         &mediav1alpha1.SecretKeySelector{Name: "tls", Key: "ca.crt"},
+		ClientCertSecretRef: &mediav1alpha1.SecretKeySelector{Name: "tls", Key: "tls.crt"},
+		ClientKeySecretRef:  &mediav1alpha1.SecretKeySelector{Name: "tls", Key: "tls.key"},
+	}
+}
+
+func TestReconcileExternalPostgres_TLS(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+
+	immich := newTestImmich(withExternalDatabase())
+	immich.Spec.Postgres.TLS = newPostgresTLS()
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied, newPostgresTLSSecrets()...)}
+
+	if err := r.reconcileExternalPostgres(ctx, immich); err != nil {
+		t.Fatalf("reconcileExternalPostgres() error = %v", err)
+	}
+
+	secret := applied["Secret/test-immich-database-url"].(*corev1.Secret)
+	dbURL, err := url.Parse(string(secret.Data["url"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password, _ := dbURL.User.Password(); dbURL.Host != "db.example.com:5432" || dbURL.Path != "/immich" || password != "p@ss/word" {
+		t.Errorf("url = %s", dbURL.Redacted())
+	}
+	query := dbURL.Query()
+	if query.Get("sslmode") != "verify-full" || query.Get("sslrootcert") != "/etc/immich/tls/postgres/ca.crt" ||
+		query.Get("sslcert") != "/etc/immich/tls/postgres/tls.crt" || query.Get("sslkey") != "/etc/immich/tls/postgres/tls.key" {
+		t.Errorf("query = %v", query)
+	}
+
+	// The preflight Job checks the database with the same URL and certificates
+	secret.ResourceVersion = ""
+	if err := r.Create(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcileDatabasePreflight(ctx, immich); err != nil {
+		t.Fatalf("reconcileDatabasePreflight() error = %v", err)
+	}
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "test-immich-database-preflight", Namespace: "default"}, job); err != nil {
+		t.Fatal(err)
+	}
+	podSpec := job.Spec.Template.Spec
+	if env := findEnv(podSpec.Containers[0].Env, "DB_URL"); env == nil || env.ValueFrom.SecretKeyRef.Name != "test-immich-database-url" {
+		t.Errorf("DB_URL = %+v", env)
+	}
+	if len(podSpec.Volumes) != 1 || len(podSpec.Volumes[0].Projected.Sources) != 3 ||
+		podSpec.Containers[0].VolumeMounts[0].MountPath != postgresTLSDir {
+		t.Errorf("volumes = %+v, mounts = %+v", podSpec.Volumes, podSpec.Containers[0].VolumeMounts)
+	}
+
+	// The server connects with the same URL and certificates
+	if env := findEnv(r.getServerEnv(immich), "DB_HOSTNAME"); env != nil {
+		t.Error("DB_HOSTNAME should not be set over TLS")
+	}
+	volumes := r.getServerVolumes(immich)
+	if volumes[len(volumes)-1].Name != postgresTLSVolumeName {
+		t.Errorf("volumes = %+v, want the certificates", volumes)
+	}
+}
+
+func TestReconcileExternalPostgres_TLSFromURLSecret(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+
+	immich := newTestImmich(withExternalDatabase())
+	immich.Spec.Postgres.PasswordSecretRef = nil
+	immich.Spec.Postgres.URLSecretRef = &mediav1alpha1.SecretKeySelector{Name: "db-url", Key: "url"}
+	immich.Spec.Postgres.TLS = &mediav1alpha1.TLSSpec{SSLMode: ptr.To(mediav1alpha1.SSLModeRequire)}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied, append(newPostgresTLSSecrets(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-url", Namespace: "default"},
+		Data:       map[string][]byte{"url": []byte("postgresql://immich:secret@db:5432/photos?sslmode=disable&application_name=immich")},
+	})...)}
+
+	if err := r.reconcileExternalPostgres(ctx, immich); err != nil {
+		t.Fatalf("reconcileExternalPostgres() error = %v", err)
+	}
+
+	secret := applied["Secret/test-immich-database-url"].(*corev1.Secret)
+	want := "postgresql://immich:secret@db:5432/photos?application_name=immich&sslmode=require"
+	if got := string(secret.Data["url"]); got != want {
+		t.Errorf("url = %s, want %s", got, want)
+	}
+	if volume := getPostgresTLSVolume(immich); volume != nil {
+		t.Errorf("no certificate should be mounted, got %+v", volume)
+	}
+}
+
+func TestGetPostgresClientEnv_TLS(t *testing.T) {
+	tests := []struct {
+		name       string
+		urlRef     *mediav1alpha1.SecretKeySelector
+		tls        *mediav1alpha1.TLSSpec
+		wantURL    string
+		wantSSLEnv map[string]string
+	}{
+		{
+			name: "certificates",
+			tls:  newPostgresTLS(),
+			wantSSLEnv: map[string]string{
+				"PGSSLMODE":     "verify-full",
+				"PGSSLROOTCERT": "/etc/immich/tls/postgres/ca.crt",
+				"PGSSLCERT":     "/etc/immich/tls/postgres/tls.crt",
+				"PGSSLKEY":      "/etc/immich/tls/postgres/tls.key",
+			},
+		},
+		{
+			name:    "URL only",
+			urlRef:  &mediav1alpha1.SecretKeySelector{Name: "db-url", Key: "url"},
+			wantURL: "db-url",
+		},
+		{
+			name:       "URL over TLS",
+			urlRef:     &mediav1alpha1.SecretKeySelector{Name: "db-url", Key: "url"},
+			tls:        &mediav1alpha1.TLSSpec{SSLMode: ptr.To(mediav1alpha1.SSLModeRequire)},
+			wantURL:    "test-immich-database-url",
+			wantSSLEnv: map[string]string{"PGSSLMODE": "require"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := newTestImmich(withExternalDatabase())
+			immich.Spec.Postgres.URLSecretRef = tt.urlRef
+			immich.Spec.Postgres.TLS = tt.tls
+
+			env := getPostgresClientEnv(immich)
+			dbURL := findEnv(env, "DB_URL")
+			switch {
+			case tt.wantURL == "" && (dbURL != nil || findEnv(env, "PGHOST") == nil):
+				t.Errorf("env = %+v, want PGHOST and no DB_URL", env)
+			case tt.wantURL != "" && (dbURL == nil || dbURL.ValueFrom.SecretKeyRef.Name != tt.wantURL || findEnv(env, "PGHOST") != nil):
+				t.Errorf("env = %+v, want DB_URL from Secret %s only", env, tt.wantURL)
+			}
+			for _, name := range []string{"PGSSLMODE", "PGSSLROOTCERT", "PGSSLCERT", "PGSSLKEY"} {
+				got := ""
+				if e := findEnv(env, name); e != nil {
+					got = e.Value
+				}
+				if got != tt.wantSSLEnv[name] {
+					t.Errorf("%s = %q, want %q", name, got, tt.wantSSLEnv[name])
+				}
+			}
+		})
+	}
+}
+
+func TestReconcilePostgresBackupCronJob_TLS(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+
+	immich := newTestImmich(withExternalDatabase())
+	immich.Spec.Postgres.TLS = newPostgresTLS()
+	immich.Spec.Postgres.Backup = &mediav1alpha1.PostgresBackupSpec{Enabled: ptr.To(true)}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied, newPostgresTLSSecrets()...)}
+	if err := r.reconcilePostgresBackupCronJob(context.Background(), immich); err != nil {
+		t.Fatalf("reconcilePostgresBackupCronJob() error = %v", err)
+	}
+
+	podSpec := applied["CronJob/test-immich-postgres-backup"].(*batchv1.CronJob).Spec.JobTemplate.Spec.Template.Spec
+	dump := podSpec.Containers[0]
+	if env := findEnv(dump.Env, "PGSSLROOTCERT"); env == nil {
+		t.Errorf("env = %+v, want the CA of the connection", dump.Env)
+	}
+	if volumes := podSpec.Volumes; len(volumes) != 2 || volumes[1].Name != postgresTLSVolumeName {
+		t.Errorf("volumes = %+v, want the certificates", volumes)
+	}
+	if mounts := dump.VolumeMounts; len(mounts) != 2 || mounts[1].MountPath != postgresTLSDir {
+		t.Errorf("mounts = %+v, want the certificates", mounts)
+	}
+}
+
+func TestReconcileExternalPostgres_TLSMissingCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	immich := newTestImmich(withExternalDatabase())
+	immich.Spec.Postgres.PasswordSecretRef = &mediav1alpha1.SecretKeySelector{Name: "missing", Key: "password"}
+	immich.Spec.Postgres.TLS = newPostgresTLS()
+
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, map[string]client.Object{}, newPostgresTLSSecrets()...)}
+
+	if err := r.reconcileExternalPostgres(ctx, immich); err != nil {
+		t.Fatalf("reconcileExternalPostgres() error = %v", err)
+	}
+	condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDatabaseReady)
+	if condition == nil || condition.Reason != "CredentialsNotFound" {
+		t.Errorf("condition = %+v", condition)
+	}
+}
+
+func TestReconcileValkeyURLSecret_TLS(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		tls     *mediav1alpha1.TLSSpec
+		wantTLS map[string]interface{}
+	}{
+		{
+			name: "verify-full",
+			tls: &mediav1alpha1.TLSSpec{
+				CASecretRef: &mediav1alpha1.SecretKeySelector{Name: "tls", Key: "ca.crt"},
+			},
+			wantTLS: map[string]interface{}{"ca": "CA", "servername": "redis.example.com"},
+		},
+		{
+			name: "require with a client certificate",
+			tls: &mediav1alpha1.TLSSpec{
+				SSLMode:             ptr.To(mediav1alpha1.SSLModeRequire),
+				ClientCertSecretRef: &mediav1alpha1.SecretKeySelector{Name: "tls", Key: "tls.crt"},
+				ClientKeySecretRef:  &mediav1alpha1.SecretKeySelector{Name: "tls", Key: "tls.key"},
+			},
+			wantTLS: map[string]interface{}{"cert": "CERT", "key": "KEY", "rejectUnauthorized": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := &mediav1alpha1.Immich{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+				Spec: mediav1alpha1.ImmichSpec{
+					Valkey: &mediav1alpha1.ValkeySpec{
+						Enabled:           ptr.To(false),
+						Host:              ptr.To("redis.example.com"),
+						Port:              ptr.To(int32(6380)),
+						PasswordSecretRef: &mediav1alpha1.SecretKeySelector{Name: "db", Key: "password"},
+						TLS:               tt.tls,
+					},
+				},
+			}
+
+			applied := map[string]client.Object{}
+			r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied, newPostgresTLSSecrets()...)}
+			if err := r.reconcileValkeyURLSecret(ctx, immich); err != nil {
+				t.Fatalf("reconcileValkeyURLSecret() error = %v", err)
+			}
+
+			secret := applied["Secret/test-immich-valkey-url"].(*corev1.Secret)
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(string(secret.Data["url"]), "ioredis://"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var options map[string]interface{}
+			if err := json.Unmarshal(data, &options); err != nil {
+				t.Fatal(err)
+			}
+			if options["host"] != "redis.example.com" || options["port"] != float64(6380) || options["password"] != "p@ss/word" {
+				t.Errorf("options = %v", options)
+			}
+			tlsOptions, _ := options["tls"].(map[string]interface{})
+			if len(tlsOptions) != len(tt.wantTLS) {
+				t.Errorf("tls = %v, want %v", tlsOptions, tt.wantTLS)
+			}
+			for k, v := range tt.wantTLS {
+				if tlsOptions[k] != v {
+					t.Errorf("tls.%s = %v, want %v", k, tlsOptions[k], v)
+				}
+			}
+
+			env := r.getServerEnv(immich)
+			if redisURL := findEnv(env, "REDIS_URL"); redisURL == nil || redisURL.ValueFrom.SecretKeyRef.Name != "test-immich-valkey-url" {
+				t.Errorf("REDIS_URL = %+v, want it from the URL secret", redisURL)
+			}
+			if findEnv(env, "REDIS_HOSTNAME") != nil || findEnv(env, "REDIS_PASSWORD") != nil {
+				t.Error("REDIS_HOSTNAME and REDIS_PASSWORD should not be set over TLS")
+			}
+		})
+	}
+}
+
+func TestGetServerInitContainers_TLS(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:16")
+	t.Setenv(mediav1alpha1.EnvRelatedImageValkey, "valkey/valkey:8")
+
+	immich := newTestImmich(withExternalDatabase())
+	immich.Spec.Postgres.TLS = newPostgresTLS()
+	immich.Spec.Valkey = &mediav1alpha1.ValkeySpec{
+		Enabled:           ptr.To(false),
+		Host:              ptr.To("redis.example.com"),
+		Port:              ptr.To(int32(6380)),
+		PasswordSecretRef: &mediav1alpha1.SecretKeySelector{Name: "db", Key: "password"},
+		TLS: &mediav1alpha1.TLSSpec{
+			SSLMode:     ptr.To(mediav1alpha1.SSLModeRequire),
+			CASecretRef: &mediav1alpha1.SecretKeySelector{Name: "tls", Key: "ca.crt"},
+		},
+	}
+
+	r := &ImmichReconciler{}
+	initContainers := r.getServerInitContainers(immich)
+	if len(initContainers) != 2 {
+		t.Fatalf("init containers = %+v, want wait-for-postgres and wait-for-valkey", initContainers)
+	}
+
+	postgres := initContainers[0]
+	if !strings.Contains(postgres.Command[2], "pg_isready") || postgres.Image != "postgres:16" {
+		t.Errorf("wait-for-postgres = %+v, want pg_isready", postgres)
+	}
+	if env := findEnv(postgres.Env, "PGSSLROOTCERT"); env == nil || findEnv(postgres.Env, "PGHOST").Value != "db.example.com" {
+		t.Errorf("env = %+v, want the host and the CA of the server connection", postgres.Env)
+	}
+	if len(postgres.VolumeMounts) != 1 || postgres.VolumeMounts[0].MountPath != postgresTLSDir {
+		t.Errorf("mounts = %+v, want the PostgreSQL certificates", postgres.VolumeMounts)
+	}
+
+	valkey := initContainers[1]
+	want := "valkey-cli -h redis.example.com -p 6380 --tls --insecure --cacert /etc/immich/tls/valkey/ca.crt ping"
+	if !strings.Contains(valkey.Command[2], want) || valkey.Image != "valkey/valkey:8" {
+		t.Errorf("wait-for-valkey = %+v, want %q", valkey, want)
+	}
+	if env := findEnv(valkey.Env, "REDISCLI_AUTH"); env == nil || env.ValueFrom.SecretKeyRef.Name != "db" {
+		t.Errorf("env = %+v, want the Valkey password", valkey.Env)
+	}
+	if len(valkey.VolumeMounts) != 1 || valkey.VolumeMounts[0].MountPath != valkeyTLSDir {
+		t.Errorf("mounts = %+v, want the Valkey certificates", valkey.VolumeMounts)
+	}
+	volumes := r.getServerVolumes(immich)
+	if volumes[len(volumes)-1].Name != valkeyTLSVolumeName {
+		t.Errorf("volumes = %+v, want the Valkey certificates", volumes)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// ConditionTypeHardwareTranscodingSchedulable reports whether a node selected by the server can run hardware transcoding
+const ConditionTypeHardwareTranscodingSchedulable = "HardwareTranscodingSchedulable"
+
+// defaultNvidiaRuntimeClassName is the RuntimeClass created by the NVIDIA GPU Operator
+const defaultNvidiaRuntimeClassName = "nvidia"
+
+// getFFmpegAccel returns the hardware transcoding acceleration of the effective Immich configuration,
+// or an empty string when transcoding is not accelerated
+func (r *ImmichReconciler) getFFmpegAccel(ctx context.Context, immich *mediav1alpha1.Immich) (string, error) {
+	config, err := r.buildEffectiveConfigMap(ctx, immich)
+	if err != nil {
+		return "", err
+	}
+	accel, _ := getConfigValue(config, "ffmpeg", "accel").(string)
+	if accel == "disabled" {
+		return "", nil
+	}
+	return accel, nil
+}
+
+// getTranscodingProfile returns what the pods running the microservices worker need for the hardware transcoding
+// acceleration, following the hardware transcoding setup documented by Immich, with the overrides of
+// spec.server.hardwareTranscoding
+func getTranscodingProfile(immich *mediav1alpha1.Immich, accel string) accelerationProfile {
+	spec := mediav1alpha1.HardwareTranscodingSpec{}
+	if immich.Spec.Server != nil && immich.Spec.Server.HardwareTranscoding != nil {
+		spec = *immich.Spec.Server.HardwareTranscoding
+	}
+	if !ptr.Deref(spec.Enabled, true) {
+		return accelerationProfile{}
+	}
+
+	dri := hostDevice{name: "dev-dri", path: "/dev/dri", hostType: corev1.HostPathDirectory}
+	var profile accelerationProfile
+	switch accel {
+	case mediav1alpha1.FFmpegAccelNVENC:
+		profile = accelerationProfile{
+			resources:        corev1.ResourceList{resourceNvidiaGPU: resource.MustParse("1")},
+			runtimeClassName: ptr.To(defaultNvidiaRuntimeClassName),
+			env:              []corev1.EnvVar{{Name: "NVIDIA_DRIVER_CAPABILITIES", Value: "compute,video,utility"}},
+		}
+	case mediav1alpha1.FFmpegAccelQSV, mediav1alpha1.FFmpegAccelVAAPI:
+		deviceAccess := mediav1alpha1.HardwareTranscodingDeviceAccessHostPath
+		if accel == mediav1alpha1.FFmpegAccelQSV {
+			deviceAccess = mediav1alpha1.HardwareTranscodingDeviceAccessDevicePlugin
+		}
+		if spec.DeviceAccess != nil {
+			deviceAccess = *spec.DeviceAccess
+		}
+		if deviceAccess == mediav1alpha1.HardwareTranscodingDeviceAccessDevicePlugin {
+			profile = accelerationProfile{resources: corev1.ResourceList{resourceIntelGPU: resource.MustParse("1")}}
+		} else {
+			profile = accelerationProfile{devices: []hostDevice{dri}, supplementalGroups: []int64{videoGroupID}}
+		}
+	case mediav1alpha1.FFmpegAccelRKMPP:
+		profile = accelerationProfile{
+			devices: []hostDevice{
+				{name: "dev-rga", path: "/dev/rga", hostType: corev1.HostPathCharDev},
+				dri,
+				{name: "dev-dma-heap", path: "/dev/dma_heap", hostType: corev1.HostPathDirectory},
+				{name: "dev-mpp-service", path: "/dev/mpp_service", hostType: corev1.HostPathCharDev},
+			},
+			supplementalGroups: []int64{videoGroupID},
+			arch:               "arm64",
+		}
+	default:
+		return accelerationProfile{}
+	}
+
+	if len(spec.Resources) > 0 && len(profile.devices) == 0 {
+		profile.resources = spec.Resources
+	}
+	if spec.SupplementalGroups != nil {
+		profile.supplementalGroups = spec.SupplementalGroups
+	}
+	profile.privileged = ptr.Deref(spec.Privileged, false)
+	if accel == mediav1alpha1.FFmpegAccelNVENC && spec.RuntimeClassName != nil {
+		profile.runtimeClassName = spec.RuntimeClassName
+		if *spec.RuntimeClassName == "" {
+			profile.runtimeClassName = nil
+		}
+	}
+	return profile
+}
+
+// reconcileHardwareTranscodingCondition reports in the HardwareTranscodingSchedulable condition whether a node selected
+// by the node selector of the pods running the microservices worker provides the resources and architecture needed by
+// the transcoding acceleration. Devices mounted from the node cannot be checked, only the architecture is.
+func (r *ImmichReconciler) reconcileHardwareTranscodingCondition(ctx context.Context, immich *mediav1alpha1.Immich,
+	accel string, profile accelerationProfile, nodeSelector map[string]string) error {
+	if !profile.isEnabled() {
+		meta.RemoveStatusCondition(&immich.Status.Conditions, ConditionTypeHardwareTranscodingSchedulable)
+		return nil
+	}
+
+	nodeSelector = profile.getNodeSelector(nodeSelector)
+	nodes := &corev1.NodeList{}
+	if err := r.apiReader().List(ctx, nodes, client.MatchingLabels(nodeSelector)); err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	for _, node := range nodes.Items {
+		if hasAllocatableResources(&node, profile.resources) {
+			meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+				Type:    ConditionTypeHardwareTranscodingSchedulable,
+				Status:  metav1.ConditionTrue,
+				Reason:  "NodesAvailable",
+				Message: fmt.Sprintf("Nodes can run hardware transcoding with ffmpeg.accel=%s", accel),
+			})
+			return nil
+		}
+	}
+
+	message := fmt.Sprintf("ffmpeg.accel=%s is set, but no node matches the node selector %v", accel, nodeSelector)
+	if len(profile.resources) > 0 {
+		var names []string
+		for _, name := range slices.Sorted(maps.Keys(profile.resources)) {
+			names = append(names, string(name))
+		}
+		message = fmt.Sprintf("ffmpeg.accel=%s is set, but no node matching the node selector %v has %s allocatable",
+			accel, nodeSelector, strings.Join(names, ", "))
+	}
+	logf.FromContext(ctx).Info("No node can run hardware transcoding", "accel", accel, "nodeSelector", nodeSelector)
+	meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeHardwareTranscodingSchedulable,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoMatchingNode",
+		Message: message,
+	})
+	r.recordEvent(immich, corev1.EventTypeWarning, EventReasonHardwareTranscodingUnschedulable, "%s", message)
+	return nil
+}
+
+// hasAllocatableResources returns true if the node can allocate the given quantity of each resource
+func hasAllocatableResources(node *corev1.Node, resources corev1.ResourceList) bool {
+	for name, quantity := range resources {
+		allocatable, ok := node.Status.Allocatable[name]
+		if !ok || allocatable.Cmp(quantity) < 0 {
+			return false
+		}
+	}
+	return true
+}
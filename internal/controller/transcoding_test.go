@@ -0,0 +1,238 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestReconcileServer_HardwareTranscoding(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "ghcr.io/immich-app/immich-server:v1.125.7")
+
+	tests := []struct {
+		name             string
+		accel            string
+		override         *mediav1alpha1.HardwareTranscodingSpec
+		wantResources    corev1.ResourceList
+		wantDevices      []string
+		wantGroups       []int64
+		wantRuntimeClass *string
+		wantPrivileged   bool
+		wantArch         string
+	}{
+		{
+			name:  "disabled",
+			accel: "disabled",
+		},
+		{
+			name:             "nvenc",
+			accel:            mediav1alpha1.FFmpegAccelNVENC,
+			wantResources:    corev1.ResourceList{resourceNvidiaGPU: resource.MustParse("1")},
+			wantRuntimeClass: ptr.To("nvidia"),
+		},
+		{
+			name:          "nvenc on nodes with the NVIDIA runtime by default",
+			accel:         mediav1alpha1.FFmpegAccelNVENC,
+			override:      &mediav1alpha1.HardwareTranscodingSpec{RuntimeClassName: ptr.To("")},
+			wantResources: corev1.ResourceList{resourceNvidiaGPU: resource.MustParse("1")},
+		},
+		{
+			name:          "qsv",
+			accel:         mediav1alpha1.FFmpegAccelQSV,
+			wantResources: corev1.ResourceList{resourceIntelGPU: resource.MustParse("1")},
+		},
+		{
+			name:        "vaapi",
+			accel:       mediav1alpha1.FFmpegAccelVAAPI,
+			wantDevices: []string{"/dev/dri"},
+			wantGroups:  []int64{44},
+		},
+		{
+			name:           "vaapi in a privileged container",
+			accel:          mediav1alpha1.FFmpegAccelVAAPI,
+			override:       &mediav1alpha1.HardwareTranscodingSpec{Privileged: ptr.To(true)},
+			wantDevices:    []string{"/dev/dri"},
+			wantGroups:     []int64{44},
+			wantPrivileged: true,
+		},
+		{
+			name:          "privileged ignored with a device plugin",
+			accel:         mediav1alpha1.FFmpegAccelQSV,
+			override:      &mediav1alpha1.HardwareTranscodingSpec{Privileged: ptr.To(true)},
+			wantResources: corev1.ResourceList{resourceIntelGPU: resource.MustParse("1")},
+		},
+		{
+			name:  "vaapi with the AMD device plugin",
+			accel: mediav1alpha1.FFmpegAccelVAAPI,
+			override: &mediav1alpha1.HardwareTranscodingSpec{
+				DeviceAccess:       ptr.To(mediav1alpha1.HardwareTranscodingDeviceAccessDevicePlugin),
+				Resources:          corev1.ResourceList{resourceAMDGPU: resource.MustParse("1")},
+				SupplementalGroups: []int64{993},
+			},
+			wantResources: corev1.ResourceList{resourceAMDGPU: resource.MustParse("1")},
+			wantGroups:    []int64{993},
+		},
+		{
+			name:        "rkmpp",
+			accel:       mediav1alpha1.FFmpegAccelRKMPP,
+			wantDevices: []string{"/dev/rga", "/dev/dri", "/dev/dma_heap", "/dev/mpp_service"},
+			wantGroups:  []int64{44},
+			wantArch:    "arm64",
+		},
+		{
+			name:     "device access provided through the server settings",
+			accel:    mediav1alpha1.FFmpegAccelQSV,
+			override: &mediav1alpha1.HardwareTranscodingSpec{Enabled: ptr.To(false)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := newTestImmich(withHardwareTranscoding(tt.accel, tt.override))
+
+			applied := map[string]client.Object{}
+			r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+			if err := r.reconcileServer(context.Background(), immich); err != nil {
+				t.Fatalf("reconcileServer() error = %v", err)
+			}
+
+			podSpec := applied["Deployment/test-immich-server"].(*appsv1.Deployment).Spec.Template.Spec
+			container := podSpec.Containers[0]
+
+			for _, name := range []corev1.ResourceName{resourceNvidiaGPU, resourceIntelGPU, resourceAMDGPU} {
+				want, wanted := tt.wantResources[name]
+				limit, requested := container.Resources.Limits[name]
+				if requested != wanted || (wanted && (!limit.Equal(want) || !container.Resources.Requests[name].Equal(want))) {
+					t.Errorf("%s = %v, want %v", name, container.Resources, tt.wantResources)
+				}
+			}
+
+			var devices []string
+			for _, mount := range container.VolumeMounts {
+				if strings.HasPrefix(mount.MountPath, "/dev/") {
+					devices = append(devices, mount.MountPath)
+				}
+			}
+			if !reflect.DeepEqual(devices, tt.wantDevices) {
+				t.Errorf("devices = %v, want %v", devices, tt.wantDevices)
+			}
+
+			var groups []int64
+			if podSpec.SecurityContext != nil {
+				groups = podSpec.SecurityContext.SupplementalGroups
+			}
+			if !reflect.DeepEqual(groups, tt.wantGroups) {
+				t.Errorf("supplementalGroups = %v, want %v", groups, tt.wantGroups)
+			}
+			if !reflect.DeepEqual(podSpec.RuntimeClassName, tt.wantRuntimeClass) {
+				t.Errorf("runtimeClassName = %v, want %v", podSpec.RuntimeClassName, tt.wantRuntimeClass)
+			}
+			privileged := container.SecurityContext != nil && ptr.Deref(container.SecurityContext.Privileged, false)
+			if privileged != tt.wantPrivileged {
+				t.Errorf("privileged = %v, want %v", privileged, tt.wantPrivileged)
+			}
+			if podSpec.NodeSelector[corev1.LabelArchStable] != tt.wantArch {
+				t.Errorf("nodeSelector = %v, want arch %q", podSpec.NodeSelector, tt.wantArch)
+			}
+			if tt.accel == mediav1alpha1.FFmpegAccelNVENC {
+				if env := findEnv(container.Env, "NVIDIA_DRIVER_CAPABILITIES"); env == nil || env.Value != "compute,video,utility" {
+					t.Errorf("NVIDIA_DRIVER_CAPABILITIES = %+v", env)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileServer_HardwareTranscodingSplitWorkers(t *testing.T) {
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "ghcr.io/immich-app/immich-server:v1.125.7")
+
+	immich := newTestImmich(withHardwareTranscoding(mediav1alpha1.FFmpegAccelQSV, nil))
+	immich.Spec.Server.Workers = &mediav1alpha1.ServerWorkersSpec{Mode: ptr.To(mediav1alpha1.ServerWorkersModeSplit)}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+	if err := r.reconcileServer(context.Background(), immich); err != nil {
+		t.Fatalf("reconcileServer() error = %v", err)
+	}
+
+	api := applied["Deployment/test-immich-server-api"].(*appsv1.Deployment)
+	if _, ok := api.Spec.Template.Spec.Containers[0].Resources.Limits[resourceIntelGPU]; ok {
+		t.Error("the api worker does not transcode and should not request a GPU")
+	}
+	microservices := applied["Deployment/test-immich-server-microservices"].(*appsv1.Deployment)
+	if _, ok := microservices.Spec.Template.Spec.Containers[0].Resources.Limits[resourceIntelGPU]; !ok {
+		t.Error("the microservices worker should request a GPU")
+	}
+}
+
+func TestReconcileHardwareTranscodingCondition(t *testing.T) {
+	ctx := context.Background()
+	gpuNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu", Labels: map[string]string{"pool": "gpu"}},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{resourceNvidiaGPU: resource.MustParse("1")},
+		},
+	}
+	cpuNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cpu", Labels: map[string]string{"pool": "cpu"}}}
+
+	tests := []struct {
+		name         string
+		accel        string
+		nodeSelector map[string]string
+		want         metav1.ConditionStatus
+	}{
+		{name: "no acceleration", accel: "", want: ""},
+		{name: "GPU on a selected node", accel: mediav1alpha1.FFmpegAccelNVENC, want: metav1.ConditionTrue},
+		{name: "no GPU on the selected nodes", accel: mediav1alpha1.FFmpegAccelNVENC, nodeSelector: map[string]string{"pool": "cpu"},
+			want: metav1.ConditionFalse},
+		{name: "no arm64 node", accel: mediav1alpha1.FFmpegAccelRKMPP, want: metav1.ConditionFalse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := newTestImmich(withHardwareTranscoding(tt.accel, nil))
+			r := &ImmichReconciler{Client: newApplyCapturingClient(t, map[string]client.Object{}, gpuNode, cpuNode)}
+
+			profile := getTranscodingProfile(immich, tt.accel)
+			if err := r.reconcileHardwareTranscodingCondition(ctx, immich, tt.accel, profile, tt.nodeSelector); err != nil {
+				t.Fatalf("reconcileHardwareTranscodingCondition() error = %v", err)
+			}
+
+			condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeHardwareTranscodingSchedulable)
+			if tt.want == "" {
+				if condition != nil {
+					t.Errorf("condition = %+v, want none", condition)
+				}
+				return
+			}
+			if condition == nil || condition.Status != tt.want {
+				t.Errorf("condition = %+v, want %s", condition, tt.want)
+			}
+		})
+	}
+}
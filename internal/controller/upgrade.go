@@ -0,0 +1,580 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// snapshotDir is the mount path of the snapshot PVC in the snapshot Job
+const snapshotDir = "/snapshots"
+
+// semverTag matches image tags carrying an Immich release version (e.g., "v1.120.0")
+var semverTag = regexp.MustCompile(`^v?\d+\.\d+\.\d+$`)
+
+// reconcileImmichUpgrade orchestrates changes of the server image when the upgrade policy is enabled:
+//  1. Snapshotting: the database is dumped with pg_dump to the snapshot PVC, while the previous version keeps serving
+//  2. ScalingDown: the server is scaled down, so that the previous version stops using the database
+//  3. UpgradingMachineLearning: machine learning is rolled out with its new image
+//  4. StartingServer: the new server starts, runs its migrations, and must report the new version
+//     through /api/server/ping and /api/server/version within the upgrade timeout
+//
+// Otherwise, the upgrade is marked Failed and the Immich resource Degraded, with the snapshot to roll back to.
+// The images and replicas to deploy in each phase are derived from the status by getServerDeploymentImage,
+// getMachineLearningDeploymentImage and isServerDownForUpgrade.
+func (r *ImmichReconciler) reconcileImmichUpgrade(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+
+	target := immich.GetServerImage()
+	upgrade := immich.Status.Upgrade
+
+	if upgrade == nil {
+		current := immich.Status.ServerImage
+		if current == "" {
+			// Adopt the image of a Deployment created before the image was tracked in the status
+			var err error
+			if current, err = r.getDeploymentImage(ctx, immich, getServerHTTPWorkload(immich).component); err != nil {
+				return err
+			}
+		}
+		if current == "" || current == target || !immich.IsUpgradePolicyEnabled() || !immich.IsServerEnabled() {
+			immich.Status.ServerImage = target
+			return nil
+		}
+
+		fromMLImage := ""
+		if workloads := getMachineLearningWorkloads(immich); len(workloads) > 0 {
+			var err error
+			if fromMLImage, err = r.getDeploymentImage(ctx, immich, workloads[0].component); err != nil {
+				return err
+			}
+		}
+
+		now := metav1.Now()
+		upgrade = &mediav1alpha1.ImmichUpgradeStatus{
+			Phase:                    mediav1alpha1.UpgradePhaseScalingDown,
+			FromImage:                current,
+			ToImage:                  target,
+			FromVersion:              getImageVersion(current),
+			ToVersion:                getImageVersion(target),
+			FromMachineLearningImage: fromMLImage,
+			StartTime:                &now,
+			LastTransitionTime:       &now,
+		}
+		if immich.IsUpgradeSnapshotEnabled() {
+			version := upgrade.ToVersion
+			if version == "" {
+				version = "upgrade"
+			}
+			upgrade.Phase = mediav1alpha1.UpgradePhaseSnapshotting
+			upgrade.SnapshotName = fmt.Sprintf("%s-pre-%s-%s.sql.gz", immich.Name, version, now.UTC().Format("20060102150405"))
+		}
+		log.Info("Immich server image changed, starting upgrade", "from", current, "to", target)
+		r.recordEvent(immich, corev1.EventTypeNormal, EventReasonUpgradeStarted, "Upgrading Immich from %s to %s", current, target)
+		immich.Status.Upgrade = upgrade
+	}
+
+	switch target {
+	case upgrade.FromImage:
+		return r.rollbackImmichUpgrade(ctx, immich)
+	case upgrade.ToImage:
+	default:
+		// The snapshot of the database before the upgrade remains valid for the new target
+		log.Info("Immich server image changed during the upgrade", "from", upgrade.ToImage, "to", target)
+		upgrade.ToImage = target
+		upgrade.ToVersion = getImageVersion(target)
+		if upgrade.Phase == mediav1alpha1.UpgradePhaseStartingServer || upgrade.Phase == mediav1alpha1.UpgradePhaseFailed {
+			setUpgradePhase(upgrade, mediav1alpha1.UpgradePhaseScalingDown)
+		}
+	}
+
+	switch upgrade.Phase {
+	case mediav1alpha1.UpgradePhaseSnapshotting:
+		return r.reconcileUpgradeSnapshot(ctx, immich)
+	case mediav1alpha1.UpgradePhaseScalingDown:
+		scaledDown, err := isServerScaledDown(ctx, r.Client, immich)
+		if err != nil {
+			return err
+		}
+		if !scaledDown {
+			setImmichUpgradingCondition(immich, metav1.ConditionTrue, "ScalingDown",
+				fmt.Sprintf("Scaling the server down before upgrading to %s", upgrade.ToImage))
+			return nil
+		}
+		setUpgradePhase(upgrade, mediav1alpha1.UpgradePhaseUpgradingMachineLearning)
+		fallthrough
+	case mediav1alpha1.UpgradePhaseUpgradingMachineLearning:
+		for _, workload := range getMachineLearningWorkloads(immich) {
+			rolledOut, err := r.isDeploymentRolledOut(ctx, immich, workload.component, workload.image)
+			if err != nil {
+				return err
+			}
+			if !rolledOut {
+				setImmichUpgradingCondition(immich, metav1.ConditionTrue, "UpgradingMachineLearning",
+					fmt.Sprintf("Rolling out machine learning %s", workload.image))
+				return nil
+			}
+		}
+		setUpgradePhase(upgrade, mediav1alpha1.UpgradePhaseStartingServer)
+		setImmichUpgradingCondition(immich, metav1.ConditionTrue, "StartingServer",
+			fmt.Sprintf("Starting server %s", upgrade.ToImage))
+		return nil
+	case mediav1alpha1.UpgradePhaseStartingServer, mediav1alpha1.UpgradePhaseFailed:
+		return r.reconcileUpgradedServer(ctx, immich)
+	}
+
+	return fmt.Errorf("unknown Immich upgrade phase %q", upgrade.Phase)
+}
+
+// reconcileUpgradeSnapshot dumps the database to the snapshot PVC before the upgrade
+func (r *ImmichReconciler) reconcileUpgradeSnapshot(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	upgrade := immich.Status.Upgrade
+
+	if err := r.reconcileUpgradeSnapshotPVC(ctx, immich); err != nil {
+		return err
+	}
+
+	job, err := r.ensureUpgradeSnapshotJob(ctx, immich)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case isJobFailed(job):
+		message := fmt.Sprintf("The snapshot Job %s failed: delete it to retry, or set the server image back to %s", job.Name, upgrade.FromImage)
+		setImmichUpgradingCondition(immich, metav1.ConditionFalse, EventReasonSnapshotFailed, message)
+		r.recordEvent(immich, corev1.EventTypeWarning, EventReasonSnapshotFailed, "%s", message)
+	case job.Status.Succeeded > 0:
+		setUpgradePhase(upgrade, mediav1alpha1.UpgradePhaseScalingDown)
+		setImmichUpgradingCondition(immich, metav1.ConditionTrue, "ScalingDown",
+			fmt.Sprintf("Scaling the server down before upgrading to %s", upgrade.ToImage))
+	default:
+		setImmichUpgradingCondition(immich, metav1.ConditionTrue, "Snapshotting",
+			fmt.Sprintf("Taking snapshot %s of the database before upgrading to %s", upgrade.SnapshotName, upgrade.ToImage))
+	}
+	return nil
+}
+
+// reconcileUpgradedServer waits for the new server to report the new version, failing the upgrade after the timeout.
+// A failed upgrade keeps being checked, so that a server recovering later completes it.
+func (r *ImmichReconciler) reconcileUpgradedServer(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+	upgrade := immich.Status.Upgrade
+
+	version, err := r.getServerVersion(ctx, immich)
+	if err == nil && isExpectedVersion(upgrade.ToVersion, version) {
+		log.Info("Immich upgrade completed", "from", upgrade.FromImage, "to", upgrade.ToImage, "version", version)
+		immich.Status.Version = version
+		message := fmt.Sprintf("Upgraded Immich from %s to %s", upgrade.FromImage, version)
+		if upgrade.SnapshotName != "" {
+			message += fmt.Sprintf(". The database snapshot %s is kept in PVC %s", upgrade.SnapshotName, immich.GetUpgradeSnapshotPVCName())
+		}
+		return r.finishImmichUpgrade(ctx, immich, upgrade.ToImage, EventReasonUpgradeSucceeded, message)
+	}
+
+	detail := fmt.Sprintf("the server reports %s", version)
+	if err != nil {
+		detail = err.Error()
+	}
+
+	if upgrade.Phase == mediav1alpha1.UpgradePhaseFailed {
+		return nil
+	}
+
+	timeout := immich.GetUpgradeTimeout()
+	if upgrade.LastTransitionTime == nil || time.Since(upgrade.LastTransitionTime.Time) < timeout {
+		setImmichUpgradingCondition(immich, metav1.ConditionTrue, "WaitingForServer",
+			fmt.Sprintf("Waiting for server %s to become ready: %s", upgrade.ToImage, detail))
+		return nil
+	}
+
+	log.Info("Immich upgrade failed", "to", upgrade.ToImage, "reason", detail)
+	setUpgradePhase(upgrade, mediav1alpha1.UpgradePhaseFailed)
+	message := fmt.Sprintf("Server %s did not become ready within %s (%s). To roll back, set the server image back to %s",
+		upgrade.ToImage, timeout, detail, upgrade.FromImage)
+	if upgrade.SnapshotName != "" {
+		message += fmt.Sprintf(" and restore snapshot %s from PVC %s with an ImmichRestore",
+			upgrade.SnapshotName, immich.GetUpgradeSnapshotPVCName())
+	}
+	setImmichUpgradingCondition(immich, metav1.ConditionFalse, EventReasonUpgradeFailed, message)
+	r.recordEvent(immich, corev1.EventTypeWarning, EventReasonUpgradeFailed, "%s", message)
+	meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeDegraded,
+		Status:  metav1.ConditionTrue,
+		Reason:  EventReasonUpgradeFailed,
+		Message: message,
+	})
+	return nil
+}
+
+// rollbackImmichUpgrade goes back to the previous server image.
+// The database is not restored automatically, as the snapshot may be older than the data written since.
+func (r *ImmichReconciler) rollbackImmichUpgrade(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	upgrade := immich.Status.Upgrade
+
+	switch upgrade.Phase {
+	case mediav1alpha1.UpgradePhaseSnapshotting,
+		mediav1alpha1.UpgradePhaseScalingDown,
+		mediav1alpha1.UpgradePhaseUpgradingMachineLearning:
+		return r.finishImmichUpgrade(ctx, immich, upgrade.FromImage, EventReasonUpgradeCancelled,
+			fmt.Sprintf("The upgrade to %s was cancelled", upgrade.ToImage))
+	}
+
+	message := fmt.Sprintf("Rolled back to %s", upgrade.FromImage)
+	if upgrade.SnapshotName != "" {
+		message += fmt.Sprintf(". Database migrations of %s may have run: restore snapshot %s from PVC %s with an ImmichRestore if needed",
+			upgrade.ToImage, upgrade.SnapshotName, immich.GetUpgradeSnapshotPVCName())
+	}
+	return r.finishImmichUpgrade(ctx, immich, upgrade.FromImage, EventReasonRolledBack, message)
+}
+
+// finishImmichUpgrade records the image the server now runs and deletes the snapshot Job.
+// The snapshot PVC is kept, along with the snapshot.
+func (r *ImmichReconciler) finishImmichUpgrade(ctx context.Context, immich *mediav1alpha1.Immich, image, reason, message string) error {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: getUpgradeSnapshotJobName(immich), Namespace: immich.Namespace},
+	}
+	if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	immich.Status.ServerImage = image
+	immich.Status.Upgrade = nil
+	setImmichUpgradingCondition(immich, metav1.ConditionFalse, reason, message)
+	r.recordEvent(immich, corev1.EventTypeNormal, reason, "%s", message)
+
+	degraded := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDegraded)
+	if degraded != nil && degraded.Reason == EventReasonUpgradeFailed {
+		meta.RemoveStatusCondition(&immich.Status.Conditions, ConditionTypeDegraded)
+	}
+	return nil
+}
+
+// getServerDeploymentImage returns the server image to deploy: the previous image until the server was
+// scaled down for an upgrade
+func getServerDeploymentImage(immich *mediav1alpha1.Immich) string {
+	upgrade := immich.Status.Upgrade
+	if upgrade != nil {
+		switch upgrade.Phase {
+		case mediav1alpha1.UpgradePhaseSnapshotting,
+			mediav1alpha1.UpgradePhaseScalingDown,
+			mediav1alpha1.UpgradePhaseUpgradingMachineLearning:
+			return upgrade.FromImage
+		}
+	}
+	return immich.GetServerImage()
+}
+
+// getMachineLearningDeploymentImage returns the machine learning image to deploy: the previous image until the
+// server was scaled down for an upgrade
+func getMachineLearningDeploymentImage(immich *mediav1alpha1.Immich) string {
+	upgrade := immich.Status.Upgrade
+	if isMachineLearningHeldForUpgrade(immich) && upgrade.FromMachineLearningImage != "" {
+		return upgrade.FromMachineLearningImage
+	}
+	return immich.GetMachineLearningImage()
+}
+
+// getMachineLearningWorkloadImage returns the image to deploy for a machine learning backend. Like
+// <name>-machine-learning, a backend of spec.machineLearning.backends keeps the image its Deployment runs
+// until the server was scaled down for an upgrade.
+func (r *ImmichReconciler) getMachineLearningWorkloadImage(ctx context.Context, immich *mediav1alpha1.Immich,
+	workload machineLearningWorkload) (string, error) {
+	if workload.backend == "" {
+		return getMachineLearningDeploymentImage(immich), nil
+	}
+	if !isMachineLearningHeldForUpgrade(immich) {
+		return workload.image, nil
+	}
+	current, err := r.getDeploymentImage(ctx, immich, workload.component)
+	if err != nil || current == "" {
+		return workload.image, err
+	}
+	return current, nil
+}
+
+// isMachineLearningHeldForUpgrade returns true while the machine learning keeps its previous image for an upgrade
+func isMachineLearningHeldForUpgrade(immich *mediav1alpha1.Immich) bool {
+	upgrade := immich.Status.Upgrade
+	return upgrade != nil &&
+		(upgrade.Phase == mediav1alpha1.UpgradePhaseSnapshotting || upgrade.Phase == mediav1alpha1.UpgradePhaseScalingDown)
+}
+
+// isServerDownForUpgrade returns true while the server must stay scaled down for an upgrade
+func isServerDownForUpgrade(immich *mediav1alpha1.Immich) bool {
+	upgrade := immich.Status.Upgrade
+	return upgrade != nil &&
+		(upgrade.Phase == mediav1alpha1.UpgradePhaseScalingDown || upgrade.Phase == mediav1alpha1.UpgradePhaseUpgradingMachineLearning)
+}
+
+// getImageVersion returns the tag of an image reference, ignoring any digest
+func getImageVersion(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	name := image[strings.LastIndex(image, "/")+1:]
+	if _, tag, found := strings.Cut(name, ":"); found {
+		return tag
+	}
+	return ""
+}
+
+// isExpectedVersion returns true if the version reported by the server matches the image tag.
+// Tags that are not release versions (e.g., "release") cannot be checked, and match any version.
+func isExpectedVersion(tag, reported string) bool {
+	if !semverTag.MatchString(tag) {
+		return true
+	}
+	return strings.TrimPrefix(tag, "v") == strings.TrimPrefix(reported, "v")
+}
+
+// setUpgradePhase moves the upgrade to a new phase
+func setUpgradePhase(upgrade *mediav1alpha1.ImmichUpgradeStatus, phase string) {
+	now := metav1.Now()
+	upgrade.Phase = phase
+	upgrade.LastTransitionTime = &now
+}
+
+// setImmichUpgradingCondition sets the ImmichUpgrading condition of the Immich resource
+func setImmichUpgradingCondition(immich *mediav1alpha1.Immich, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&immich.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeImmichUpgrading,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// getDeploymentImage returns the image of the main container of a component Deployment, or an empty string
+func (r *ImmichReconciler) getDeploymentImage(ctx context.Context, immich *mediav1alpha1.Immich, component string) (string, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", immich.Name, component), Namespace: immich.Namespace}, deployment)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return getContainerImage(deployment.Spec.Template.Spec), nil
+}
+
+// isDeploymentRolledOut returns true once all replicas of a component Deployment run the image and are ready
+func (r *ImmichReconciler) isDeploymentRolledOut(ctx context.Context, immich *mediav1alpha1.Immich, component, image string) (bool, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", immich.Name, component), Namespace: immich.Namespace}, deployment)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	containers := deployment.Spec.Template.Spec.Containers
+	if len(containers) == 0 || containers[0].Image != image {
+		return false, nil
+	}
+	replicas := ptr.Deref(deployment.Spec.Replicas, 1)
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.ReadyReplicas == replicas &&
+		deployment.Status.Replicas == replicas, nil
+}
+
+// reconcileUpgradeSnapshotPVC creates the PVC holding pre-upgrade snapshots if needed.
+// Note: The snapshot PVC does NOT have an owner reference, so that snapshots survive the Immich CR.
+func (r *ImmichReconciler) reconcileUpgradeSnapshotPVC(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+
+	persistence := ptr.Deref(immich.Spec.UpgradePolicy.Persistence, mediav1alpha1.BackupPersistenceSpec{})
+	if persistence.ExistingClaim != nil && *persistence.ExistingClaim != "" {
+		return nil
+	}
+
+	name := immich.GetUpgradeSnapshotPVCName()
+
+	// Check if PVC already exists - PVCs are mostly immutable
+	existing := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, existing)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	size := resource.MustParse("10Gi")
+	if persistence.Size != nil && !persistence.Size.IsZero() {
+		size = *persistence.Size
+	}
+
+	accessModes := persistence.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    r.getLabels(immich, "upgrade"),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      accessModes,
+			StorageClassName: persistence.StorageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+
+	log.Info("Creating upgrade snapshot PVC (no owner reference for data safety)", "name", name, "size", size.String())
+	if err := r.Create(ctx, pvc); err != nil {
+		return err
+	}
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonPVCCreated, "Created PersistentVolumeClaim %s", pvc.Name)
+	return nil
+}
+
+// ensureUpgradeSnapshotJob returns the Job dumping the database before the upgrade, creating it if needed
+func (r *ImmichReconciler) ensureUpgradeSnapshotJob(ctx context.Context, immich *mediav1alpha1.Immich) (*batchv1.Job, error) {
+	name := getUpgradeSnapshotJobName(immich)
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: immich.Namespace}, job)
+	if err == nil {
+		// The Job may have been created by a reconcile whose status update was lost: report the snapshot it takes
+		for _, container := range job.Spec.Template.Spec.Containers {
+			for _, env := range container.Env {
+				if env.Name == "SNAPSHOT_FILE" && env.Value != "" {
+					immich.Status.Upgrade.SnapshotName = env.Value
+				}
+			}
+		}
+		return job, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	image := immich.GetPostgresBackupImage()
+	if image == "" {
+		return nil, fmt.Errorf("snapshot image not configured: set spec.postgres.image or RELATED_IMAGE_postgres environment variable")
+	}
+
+	labels := r.getLabels(immich, "upgrade")
+	postgresSpec := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+
+	env := append(getPostgresClientEnv(immich),
+		corev1.EnvVar{Name: "SNAPSHOT_DIR", Value: snapshotDir},
+		corev1.EnvVar{Name: "SNAPSHOT_FILE", Value: immich.Status.Upgrade.SnapshotName},
+	)
+	// PostgreSQL certificates, referenced by the libpq environment
+	tlsVolumes, tlsVolumeMounts := getPostgresTLSVolumes(immich)
+
+	job = &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          ptr.To(int32(0)),
+			ActiveDeadlineSeconds: ptr.To(int64(upgradeJobDeadline.Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					ImagePullSecrets: immich.Spec.ImagePullSecrets,
+					SecurityContext:  postgresSpec.PodSecurityContext,
+					Containers: []corev1.Container{
+						{
+							Name:            "snapshot",
+							Image:           image,
+							ImagePullPolicy: postgresSpec.ImagePullPolicy,
+							Command:         []string{"/bin/sh", "-c", upgradeSnapshotScript},
+							Env:             env,
+							SecurityContext: postgresSpec.SecurityContext,
+							VolumeMounts: append([]corev1.VolumeMount{
+								{Name: "snapshots", MountPath: snapshotDir},
+							}, tlsVolumeMounts...),
+						},
+					},
+					Volumes: append([]corev1.Volume{
+						{
+							Name: "snapshots",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: immich.GetUpgradeSnapshotPVCName(),
+								},
+							},
+						},
+					}, tlsVolumes...),
+				},
+			},
+		},
+	}
+
+	logf.FromContext(ctx).Info("Creating upgrade snapshot Job", "name", name, "snapshot", immich.Status.Upgrade.SnapshotName)
+	if err := r.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// getUpgradeSnapshotJobName returns the name of the Job taking the pre-upgrade snapshot
+func getUpgradeSnapshotJobName(immich *mediav1alpha1.Immich) string {
+	return fmt.Sprintf("%s-pre-upgrade-snapshot", immich.Name)
+}
+
+// upgradeSnapshotScript dumps the database to SNAPSHOT_DIR/SNAPSHOT_FILE, in the format restored by ImmichRestore
+const upgradeSnapshotScript = `set -eu
+tmp="${SNAPSHOT_DIR}/.${SNAPSHOT_FILE%.gz}.partial"
+echo "Dumping the database${PGDATABASE:+ ${PGDATABASE}}${PGHOST:+ from ${PGHOST}:${PGPORT}} to ${SNAPSHOT_FILE}..."
+pg_dump --clean --if-exists ${DB_URL:+-d "${DB_URL}"} -f "${tmp}"
+gzip "${tmp}"
+mv "${tmp}.gz" "${SNAPSHOT_DIR}/${SNAPSHOT_FILE}"
+echo "Snapshot completed"
+`
@@ -0,0 +1,438 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newServerAPIClient returns an HTTP client answering the Immich server API with the given version.
+// An empty version makes the server unreachable.
+func newServerAPIClient(t *testing.T, version *string) *http.Client {
+	t.Helper()
+	return &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if *version == "" {
+			return nil, fmt.Errorf("connection refused")
+		}
+		body := `{"res":"pong"}`
+		if strings.HasSuffix(req.URL.Path, "/version") {
+			var major, minor, patch int
+			if _, err := fmt.Sscanf(*version, "v%d.%d.%d", &major, &minor, &patch); err != nil {
+				t.Fatalf("invalid test version %s", *version)
+			}
+			body = fmt.Sprintf(`{"major":%d,"minor":%d,"patch":%d}`, major, minor, patch)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+}
+
+func newComponentDeployment(name, image string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(replicas),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: image}}},
+			},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: replicas, ReadyReplicas: replicas, UpdatedReplicas: replicas},
+	}
+}
+
+func updateDeployment(t *testing.T, c client.Client, deployment *appsv1.Deployment, image string, replicas int32) {
+	t.Helper()
+	ctx := context.Background()
+	deployment.Spec.Replicas = ptr.To(replicas)
+	deployment.Spec.Template.Spec.Containers[0].Image = image
+	if err := c.Update(ctx, deployment); err != nil {
+		t.Fatalf("failed to update Deployment: %v", err)
+	}
+	deployment.Status = appsv1.DeploymentStatus{Replicas: replicas, ReadyReplicas: replicas, UpdatedReplicas: replicas}
+	if err := c.Status().Update(ctx, deployment); err != nil {
+		t.Fatalf("failed to update Deployment status: %v", err)
+	}
+}
+
+func TestReconcileImmichUpgrade(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "ghcr.io/immich-app/immich-server:v1.120.0")
+	t.Setenv(mediav1alpha1.EnvRelatedImageMachineLearning, "ghcr.io/immich-app/immich-machine-learning:v1.120.0")
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:14")
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			UpgradePolicy: &mediav1alpha1.UpgradePolicySpec{Enabled: ptr.To(true)},
+		},
+		Status: mediav1alpha1.ImmichStatus{ServerImage: "ghcr.io/immich-app/immich-server:v1.119.0"},
+	}
+	server := newComponentDeployment("test-immich-server", "ghcr.io/immich-app/immich-server:v1.119.0", 1)
+	ml := newComponentDeployment("test-immich-machine-learning", "ghcr.io/immich-app/immich-machine-learning:v1.119.0", 1)
+
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme(t)).
+		WithObjects(server, ml).
+		WithStatusSubresource(server, ml, &batchv1.Job{}).
+		Build()
+	reportedVersion := "v1.119.0"
+	r := &ImmichReconciler{Client: c, HTTPClient: newServerAPIClient(t, &reportedVersion)}
+
+	reconcile := func(wantPhase string) {
+		t.Helper()
+		if err := r.reconcileImmichUpgrade(ctx, immich); err != nil {
+			t.Fatalf("reconcileImmichUpgrade() error = %v", err)
+		}
+		if immich.Status.Upgrade == nil || immich.Status.Upgrade.Phase != wantPhase {
+			t.Fatalf("upgrade = %+v, want phase %s", immich.Status.Upgrade, wantPhase)
+		}
+	}
+
+	// The snapshot is taken while the previous version keeps serving
+	reconcile(mediav1alpha1.UpgradePhaseSnapshotting)
+	upgrade := immich.Status.Upgrade
+	if upgrade.FromVersion != "v1.119.0" || upgrade.ToVersion != "v1.120.0" {
+		t.Errorf("versions = %s -> %s, want v1.119.0 -> v1.120.0", upgrade.FromVersion, upgrade.ToVersion)
+	}
+	if !strings.HasPrefix(upgrade.SnapshotName, "test-immich-pre-v1.120.0-") {
+		t.Errorf("snapshotName = %s", upgrade.SnapshotName)
+	}
+	if got := getServerDeploymentImage(immich); got != "ghcr.io/immich-app/immich-server:v1.119.0" {
+		t.Errorf("server image = %s, want the previous image during the snapshot", got)
+	}
+	if isServerDownForUpgrade(immich) {
+		t.Error("the server should keep running during the snapshot")
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: "test-immich-upgrade-snapshots", Namespace: "default"}, &corev1.PersistentVolumeClaim{}); err != nil {
+		t.Errorf("snapshot PVC should have been created: %v", err)
+	}
+	job := &batchv1.Job{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "test-immich-pre-upgrade-snapshot", Namespace: "default"}, job); err != nil {
+		t.Fatalf("snapshot Job should have been created: %v", err)
+	}
+	if job.Spec.ActiveDeadlineSeconds == nil {
+		t.Error("the snapshot Job should have a deadline")
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	if env["SNAPSHOT_FILE"].Value != upgrade.SnapshotName || env["PGHOST"].Value != "test-immich-postgres" {
+		t.Errorf("unexpected snapshot environment: %+v", env)
+	}
+
+	job.Status.Succeeded = 1
+	if err := c.Status().Update(ctx, job); err != nil {
+		t.Fatalf("failed to update Job status: %v", err)
+	}
+	reconcile(mediav1alpha1.UpgradePhaseScalingDown)
+	if !isServerDownForUpgrade(immich) {
+		t.Error("the server should be scaled down")
+	}
+	if got := getMachineLearningDeploymentImage(immich); got != "ghcr.io/immich-app/immich-machine-learning:v1.119.0" {
+		t.Errorf("machine learning image = %s, want the previous image until the server is down", got)
+	}
+
+	// Machine learning is rolled out once the server is down
+	updateDeployment(t, c, server, "ghcr.io/immich-app/immich-server:v1.119.0", 0)
+	reconcile(mediav1alpha1.UpgradePhaseUpgradingMachineLearning)
+	if got := getMachineLearningDeploymentImage(immich); got != "ghcr.io/immich-app/immich-machine-learning:v1.120.0" {
+		t.Errorf("machine learning image = %s, want the new image", got)
+	}
+
+	updateDeployment(t, c, ml, "ghcr.io/immich-app/immich-machine-learning:v1.120.0", 1)
+	reconcile(mediav1alpha1.UpgradePhaseStartingServer)
+	if got := getServerDeploymentImage(immich); got != "ghcr.io/immich-app/immich-server:v1.120.0" {
+		t.Errorf("server image = %s, want the new image", got)
+	}
+	if isServerDownForUpgrade(immich) {
+		t.Error("the new server should be scaled up")
+	}
+
+	// The server still reports the previous version
+	reconcile(mediav1alpha1.UpgradePhaseStartingServer)
+
+	// Timed out
+	upgrade.LastTransitionTime = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	reconcile(mediav1alpha1.UpgradePhaseFailed)
+	degraded := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDegraded)
+	if degraded == nil || degraded.Status != metav1.ConditionTrue || !strings.Contains(degraded.Message, upgrade.SnapshotName) {
+		t.Errorf("Degraded condition = %+v, want the snapshot name", degraded)
+	}
+
+	// The server recovers
+	reportedVersion = "v1.120.0"
+	if err := r.reconcileImmichUpgrade(ctx, immich); err != nil {
+		t.Fatalf("reconcileImmichUpgrade() error = %v", err)
+	}
+	if immich.Status.Upgrade != nil {
+		t.Errorf("upgrade should be completed, got %+v", immich.Status.Upgrade)
+	}
+	if immich.Status.ServerImage != "ghcr.io/immich-app/immich-server:v1.120.0" {
+		t.Errorf("serverImage = %s", immich.Status.ServerImage)
+	}
+	if meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDegraded) != nil {
+		t.Error("Degraded condition should be cleared")
+	}
+	condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeImmichUpgrading)
+	if condition == nil || condition.Reason != "UpgradeSucceeded" {
+		t.Errorf("ImmichUpgrading condition = %+v, want reason UpgradeSucceeded", condition)
+	}
+}
+
+func TestReconcileImmichUpgrade_NotOrchestrated(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "ghcr.io/immich-app/immich-server:v1.120.0")
+
+	tests := []struct {
+		name   string
+		policy *mediav1alpha1.UpgradePolicySpec
+		status mediav1alpha1.ImmichStatus
+	}{
+		{
+			name:   "policy disabled",
+			status: mediav1alpha1.ImmichStatus{ServerImage: "ghcr.io/immich-app/immich-server:v1.119.0"},
+		},
+		{
+			name:   "unchanged image",
+			policy: &mediav1alpha1.UpgradePolicySpec{Enabled: ptr.To(true)},
+			status: mediav1alpha1.ImmichStatus{ServerImage: "ghcr.io/immich-app/immich-server:v1.120.0"},
+		},
+		{
+			name:   "new installation",
+			policy: &mediav1alpha1.UpgradePolicySpec{Enabled: ptr.To(true)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			immich := &mediav1alpha1.Immich{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+				Spec:       mediav1alpha1.ImmichSpec{UpgradePolicy: tt.policy},
+				Status:     tt.status,
+			}
+			r := &ImmichReconciler{Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build()}
+
+			if err := r.reconcileImmichUpgrade(ctx, immich); err != nil {
+				t.Fatalf("reconcileImmichUpgrade() error = %v", err)
+			}
+			if immich.Status.Upgrade != nil {
+				t.Errorf("no upgrade expected, got %+v", immich.Status.Upgrade)
+			}
+			if immich.Status.ServerImage != "ghcr.io/immich-app/immich-server:v1.120.0" {
+				t.Errorf("serverImage = %s", immich.Status.ServerImage)
+			}
+		})
+	}
+}
+
+func TestReconcileImmichUpgrade_Rollback(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "ghcr.io/immich-app/immich-server:v1.119.0")
+
+	tests := []struct {
+		phase      string
+		wantReason string
+	}{
+		{mediav1alpha1.UpgradePhaseSnapshotting, "UpgradeCancelled"},
+		{mediav1alpha1.UpgradePhaseStartingServer, "RolledBack"},
+		{mediav1alpha1.UpgradePhaseFailed, "RolledBack"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phase, func(t *testing.T) {
+			immich := &mediav1alpha1.Immich{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+				Spec: mediav1alpha1.ImmichSpec{
+					UpgradePolicy: &mediav1alpha1.UpgradePolicySpec{Enabled: ptr.To(true)},
+				},
+				Status: mediav1alpha1.ImmichStatus{
+					ServerImage: "ghcr.io/immich-app/immich-server:v1.119.0",
+					Upgrade: &mediav1alpha1.ImmichUpgradeStatus{
+						Phase:        tt.phase,
+						FromImage:    "ghcr.io/immich-app/immich-server:v1.119.0",
+						ToImage:      "ghcr.io/immich-app/immich-server:v1.120.0",
+						SnapshotName: "test-immich-pre-v1.120.0-20250101020000.sql.gz",
+					},
+					Conditions: []metav1.Condition{
+						{Type: ConditionTypeDegraded, Status: metav1.ConditionTrue, Reason: "UpgradeFailed"},
+					},
+				},
+			}
+			r := &ImmichReconciler{Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build()}
+
+			if err := r.reconcileImmichUpgrade(ctx, immich); err != nil {
+				t.Fatalf("reconcileImmichUpgrade() error = %v", err)
+			}
+			if immich.Status.Upgrade != nil {
+				t.Errorf("upgrade should be finished, got %+v", immich.Status.Upgrade)
+			}
+			if got := getServerDeploymentImage(immich); got != "ghcr.io/immich-app/immich-server:v1.119.0" {
+				t.Errorf("server image = %s, want the previous image", got)
+			}
+			condition := meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeImmichUpgrading)
+			if condition == nil || condition.Reason != tt.wantReason {
+				t.Errorf("ImmichUpgrading condition = %+v, want reason %s", condition, tt.wantReason)
+			}
+			if meta.FindStatusCondition(immich.Status.Conditions, ConditionTypeDegraded) != nil {
+				t.Error("Degraded condition should be cleared")
+			}
+		})
+	}
+}
+
+// TestReconcile_UpgradeStatusPersistedOnError checks that the upgrade state survives reconciles failing on a later step
+func TestReconcile_UpgradeStatusPersistedOnError(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageImmich, "ghcr.io/immich-app/immich-server:v1.120.0")
+	t.Setenv(mediav1alpha1.EnvRelatedImageMachineLearning, "ghcr.io/immich-app/immich-machine-learning:v1.120.0")
+	t.Setenv(mediav1alpha1.EnvRelatedImagePostgres, "postgres:14")
+	t.Setenv(mediav1alpha1.EnvRelatedImageValkey, "valkey/valkey:8")
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default", Finalizers: []string{immichFinalizer}},
+		Spec: mediav1alpha1.ImmichSpec{
+			UpgradePolicy: &mediav1alpha1.UpgradePolicySpec{Enabled: ptr.To(true)},
+		},
+		Status: mediav1alpha1.ImmichStatus{ServerImage: "ghcr.io/immich-app/immich-server:v1.119.0"},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme(t)).
+		WithObjects(immich).
+		WithStatusSubresource(immich, &batchv1.Job{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+				if obj.GetObjectKind().GroupVersionKind().Kind == "Deployment" && obj.GetName() == "test-immich-server" {
+					return fmt.Errorf("admission webhook denied the request")
+				}
+				return nil
+			},
+		}).
+		Build()
+	r := &ImmichReconciler{Client: c}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-immich", Namespace: "default"}}
+
+	getUpgrade := func() *mediav1alpha1.ImmichUpgradeStatus {
+		t.Helper()
+		stored := &mediav1alpha1.Immich{}
+		if err := c.Get(ctx, req.NamespacedName, stored); err != nil {
+			t.Fatalf("failed to get Immich: %v", err)
+		}
+		if stored.Status.Upgrade == nil || stored.Status.Upgrade.Phase != mediav1alpha1.UpgradePhaseSnapshotting {
+			t.Fatalf("upgrade = %+v, want phase %s persisted", stored.Status.Upgrade, mediav1alpha1.UpgradePhaseSnapshotting)
+		}
+		return stored.Status.Upgrade
+	}
+
+	if _, err := r.Reconcile(ctx, req); err == nil {
+		t.Fatal("Reconcile() should fail on the server")
+	}
+	first := getUpgrade()
+
+	if _, err := r.Reconcile(ctx, req); err == nil {
+		t.Fatal("Reconcile() should fail on the server")
+	}
+	if second := getUpgrade(); second.SnapshotName != first.SnapshotName || !second.StartTime.Equal(first.StartTime) {
+		t.Errorf("upgrade = %+v, want the upgrade started by the first reconcile %+v", second, first)
+	}
+
+	// A status update lost anyway restarts the upgrade, which reports the snapshot taken by the existing Job
+	job := &batchv1.Job{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "test-immich-pre-upgrade-snapshot", Namespace: "default"}, job); err != nil {
+		t.Fatalf("snapshot Job should have been created: %v", err)
+	}
+	job.Spec.Template.Spec.Containers[0].Env = append(job.Spec.Template.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "SNAPSHOT_FILE", Value: "test-immich-pre-v1.120.0-20250101020000.sql.gz"})
+	if err := c.Update(ctx, job); err != nil {
+		t.Fatalf("failed to update Job: %v", err)
+	}
+	stored := &mediav1alpha1.Immich{}
+	if err := c.Get(ctx, req.NamespacedName, stored); err != nil {
+		t.Fatalf("failed to get Immich: %v", err)
+	}
+	stored.Status.Upgrade = nil
+	if err := c.Status().Update(ctx, stored); err != nil {
+		t.Fatalf("failed to update Immich status: %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err == nil {
+		t.Fatal("Reconcile() should fail on the server")
+	}
+	if got := getUpgrade().SnapshotName; got != "test-immich-pre-v1.120.0-20250101020000.sql.gz" {
+		t.Errorf("snapshotName = %s, want the snapshot of the existing Job", got)
+	}
+}
+
+func TestGetImageVersion(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"ghcr.io/immich-app/immich-server:v1.120.0", "v1.120.0"},
+		{"ghcr.io/immich-app/immich-server:release", "release"},
+		{"registry.local:5000/immich-server:v1.120.0@sha256:abcdef", "v1.120.0"},
+		{"registry.local:5000/immich-server", ""},
+		{"immich-server@sha256:abcdef", ""},
+	}
+	for _, tt := range tests {
+		if got := getImageVersion(tt.image); got != tt.want {
+			t.Errorf("getImageVersion(%q) = %q, want %q", tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestIsExpectedVersion(t *testing.T) {
+	tests := []struct {
+		tag, reported string
+		want          bool
+	}{
+		{"v1.120.0", "v1.120.0", true},
+		{"1.120.0", "v1.120.0", true},
+		{"v1.120.0", "v1.119.0", false},
+		{"release", "v1.119.0", true},
+		{"", "v1.119.0", true},
+	}
+	for _, tt := range tests {
+		if got := isExpectedVersion(tt.tag, tt.reported); got != tt.want {
+			t.Errorf("isExpectedVersion(%q, %q) = %v, want %v", tt.tag, tt.reported, got, tt.want)
+		}
+	}
+}
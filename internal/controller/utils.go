@@ -19,9 +19,14 @@ package controller
 import (
 	"context"
 	"crypto/rand"
+	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
 )
 
 // FieldManager is the field manager name used for server-side apply
@@ -121,3 +126,16 @@ func generateRandomPassword(length int) (string, error) {
 	}
 	return string(b), nil
 }
+
+// getSecretValue returns the value of a key of a Secret in the namespace of the Immich resource
+func (r *ImmichReconciler) getSecretValue(ctx context.Context, immich *mediav1alpha1.Immich, ref mediav1alpha1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: immich.Namespace}, secret); err != nil {
+		return "", fmt.Errorf("failed to get Secret %s: %w", ref.Name, err)
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in Secret %s", ref.Key, ref.Name)
+	}
+	return string(value), nil
+}
@@ -31,8 +31,31 @@ func (r *ImmichReconciler) validateImages(immich *mediav1alpha1.Immich) error {
 		missingImages = append(missingImages, fmt.Sprintf("server (set spec.server.image or %s env var)", mediav1alpha1.EnvRelatedImageImmich))
 	}
 
-	if immich.IsMachineLearningEnabled() && immich.GetMachineLearningImage() == "" {
-		missingImages = append(missingImages, fmt.Sprintf("machine-learning (set spec.machineLearning.image or %s env var)", mediav1alpha1.EnvRelatedImageMachineLearning))
+	for _, workload := range getMachineLearningWorkloads(immich) {
+		if workload.image == "" {
+			missingImages = append(missingImages, fmt.Sprintf("%s (set spec.machineLearning.image or %s env var)", workload.component, mediav1alpha1.EnvRelatedImageMachineLearning))
+		}
+	}
+
+	if immich.IsMachineLearningEnabled() && !immich.HasMachineLearningBackends() {
+		if image, ok := immich.GetMachineLearningImageVariant(); !ok {
+			configErrors = append(configErrors, fmt.Sprintf("cannot derive the %s variant of the machine-learning image %s: "+
+				"set spec.machineLearning.image to a tag ending with -%s",
+				immich.GetMachineLearningAcceleration(), image, immich.GetMachineLearningAcceleration()))
+		}
+	}
+
+	if immich.IsMachineLearningEnabled() && immich.HasMachineLearningBackends() {
+		for _, backend := range immich.Spec.MachineLearning.Backends {
+			if backend.IsRemote() {
+				continue
+			}
+			if image, ok := immich.GetMachineLearningBackendImageVariant(&backend); !ok {
+				acceleration := immich.GetMachineLearningBackendAcceleration(&backend)
+				configErrors = append(configErrors, fmt.Sprintf("cannot derive the %s variant of the image %s of the "+
+					"machine-learning backend %s: set its image to a tag ending with -%s", acceleration, image, backend.Name, acceleration))
+			}
+		}
 	}
 
 	if immich.IsValkeyEnabled() && immich.GetValkeyImage() == "" {
@@ -43,6 +66,15 @@ func (r *ImmichReconciler) validateImages(immich *mediav1alpha1.Immich) error {
 		missingImages = append(missingImages, fmt.Sprintf("postgres (set spec.postgres.image or %s env var)", mediav1alpha1.EnvRelatedImagePostgres))
 	}
 
+	// The external database is checked with psql from the PostgreSQL image
+	if !immich.IsPostgresEnabled() && immich.GetPostgresImage() == "" {
+		missingImages = append(missingImages, fmt.Sprintf("database preflight check (set spec.postgres.image or %s env var)", mediav1alpha1.EnvRelatedImagePostgres))
+	}
+
+	if immich.IsPostgresBackupToS3() && immich.GetPostgresBackupS3Image() == "" {
+		missingImages = append(missingImages, fmt.Sprintf("postgres backup upload (set spec.postgres.backup.s3.image or %s env var)", mediav1alpha1.EnvRelatedImageBackupS3))
+	}
+
 	// Validate external PostgreSQL config when built-in is disabled
 	if !immich.IsPostgresEnabled() {
 		postgres := immich.Spec.Postgres
@@ -18,6 +18,10 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -33,6 +37,10 @@ import (
 	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
 )
 
+// valkeyPasswordHashAnnotation is set on the Valkey and server pod templates with a hash of the Valkey password,
+// so that rotating the password restarts both with the new one
+const valkeyPasswordHashAnnotation = "media.rm3l.org/valkey-password-hash"
+
 // reconcileValkey creates or updates the Valkey (Redis) deployment and service
 func (r *ImmichReconciler) reconcileValkey(ctx context.Context, immich *mediav1alpha1.Immich) error {
 	log := logf.FromContext(ctx)
@@ -41,6 +49,18 @@ func (r *ImmichReconciler) reconcileValkey(ctx context.Context, immich *mediav1a
 	valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
 	persistence := ptr.Deref(valkeySpec.Persistence, mediav1alpha1.ValkeyPersistenceSpec{})
 
+	// Generate credentials if needed (must be created before deployment)
+	if immich.IsValkeyAuthEnabled() {
+		if err := r.reconcileValkeyCredentials(ctx, immich); err != nil {
+			return err
+		}
+	}
+
+	// In sentinel mode, Valkey runs as a StatefulSet monitored by Sentinel pods
+	if immich.IsValkeySentinelEnabled() {
+		return r.reconcileValkeySentinel(ctx, immich)
+	}
+
 	// Create Valkey PVC if persistence is enabled (must be created before deployment)
 	if persistence.Enabled != nil && *persistence.Enabled {
 		if err := r.reconcileValkeyPVC(ctx, immich); err != nil {
@@ -61,7 +81,143 @@ func (r *ImmichReconciler) reconcileValkey(ctx context.Context, immich *mediav1a
 	return nil
 }
 
-// reconcileValkeyDeployment creates or updates the Valkey Deployment using server-side apply
+// reconcileValkeyCredentials creates a Secret with a generated Valkey password, unless one is provided.
+// Like the PostgreSQL credentials, the Secret has no owner reference and is reused when it exists.
+func (r *ImmichReconciler) reconcileValkeyCredentials(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+
+	valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
+
+	// Skip if user provided explicit credentials
+	if valkeySpec.PasswordSecretRef != nil {
+		log.V(1).Info("Using user-provided Valkey credentials")
+		return nil
+	}
+
+	secretName := fmt.Sprintf("%s-valkey-credentials", immich.Name)
+	labels := r.getLabels(immich, "valkey")
+
+	// Check if secret already exists - reuse it if so, so that a rotated password is kept
+	existing := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: immich.Namespace}, existing)
+	if err == nil {
+		log.V(1).Info("Valkey credentials secret already exists, reusing", "name", secretName)
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	password, err := generateRandomPassword(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate Valkey password: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string][]byte{
+			"password": []byte(password),
+		},
+	}
+
+	log.Info("Creating Valkey credentials secret (no owner reference)", "name", secretName)
+	if err := r.Create(ctx, secret); err != nil {
+		return err
+	}
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonCredentialsGenerated, "Generated Valkey credentials in Secret %s", secretName)
+	return nil
+}
+
+// getValkeyPasswordSecretRef returns the secret reference for the Valkey password,
+// or nil if Valkey does not require a password.
+// Returns generated secret name if no explicit credentials are provided for the built-in Valkey.
+func getValkeyPasswordSecretRef(immich *mediav1alpha1.Immich) *mediav1alpha1.SecretKeySelector {
+	valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
+	if immich.IsValkeyEnabled() && !immich.IsValkeyAuthEnabled() {
+		return nil
+	}
+	if valkeySpec.PasswordSecretRef != nil || !immich.IsValkeyEnabled() {
+		return valkeySpec.PasswordSecretRef
+	}
+	return &mediav1alpha1.SecretKeySelector{
+		Name: fmt.Sprintf("%s-valkey-credentials", immich.Name),
+		Key:  "password",
+	}
+}
+
+// computeValkeyPasswordHash returns a hash of the Valkey password, or an empty string if there is none
+func (r *ImmichReconciler) computeValkeyPasswordHash(ctx context.Context, immich *mediav1alpha1.Immich) (string, error) {
+	ref := getValkeyPasswordSecretRef(immich)
+	if ref == nil {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(ref.Name + "/" + ref.Key + "="))
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: immich.Namespace}, secret)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		// A missing secret is hashed as such, so that its creation triggers a rollout
+	} else {
+		hash.Write(secret.Data[ref.Key])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getValkeyPodAuth returns the annotations of the Valkey pods, including the password hash,
+// and the VALKEY_PASSWORD environment variable if Valkey requires a password
+func (r *ImmichReconciler) getValkeyPodAuth(ctx context.Context, immich *mediav1alpha1.Immich) (map[string]string, []corev1.EnvVar, error) {
+	valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
+
+	annotations := make(map[string]string)
+	for k, v := range valkeySpec.PodAnnotations {
+		annotations[k] = v
+	}
+
+	secretRef := getValkeyPasswordSecretRef(immich)
+	if secretRef == nil {
+		return annotations, nil, nil
+	}
+
+	passwordHash, err := r.computeValkeyPasswordHash(ctx, immich)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute Valkey password hash: %w", err)
+	}
+	annotations[valkeyPasswordHashAnnotation] = passwordHash
+
+	env := []corev1.EnvVar{
+		{
+			Name: "VALKEY_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretRef.Name},
+					Key:                  secretRef.Key,
+				},
+			},
+		},
+	}
+	return annotations, env, nil
+}
+
+// getValkeyCLIAuth returns the prefix authenticating valkey-cli commands with VALKEY_PASSWORD, if Valkey requires a password
+func getValkeyCLIAuth(immich *mediav1alpha1.Immich) string {
+	if getValkeyPasswordSecretRef(immich) == nil {
+		return ""
+	}
+	return `REDISCLI_AUTH="${VALKEY_PASSWORD}" `
+}
+
+// reconcileValkeyDeployment creates or updates the Valkey Deployment using server-side apply.
+// When authentication is enabled, the password is passed with --requirepass and used by the probes.
 func (r *ImmichReconciler) reconcileValkeyDeployment(ctx context.Context, immich *mediav1alpha1.Immich) error {
 	name := fmt.Sprintf("%s-valkey", immich.Name)
 	labels := r.getLabels(immich, "valkey")
@@ -69,6 +225,17 @@ func (r *ImmichReconciler) reconcileValkeyDeployment(ctx context.Context, immich
 
 	valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
 
+	annotations, env, err := r.getValkeyPodAuth(ctx, immich)
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	if len(env) > 0 {
+		args = []string{"--requirepass", "$(VALKEY_PASSWORD)"}
+	}
+	probeCommand := getValkeyCLIAuth(immich) + "valkey-cli ping | grep PONG"
+
 	deployment := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: appsv1.SchemeGroupVersion.String(),
@@ -100,19 +267,22 @@ func (r *ImmichReconciler) reconcileValkeyDeployment(ctx context.Context, immich
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels:      r.mergeMaps(labels, valkeySpec.PodLabels),
-					Annotations: valkeySpec.PodAnnotations,
+					Annotations: annotations,
 				},
 				Spec: corev1.PodSpec{
-					SecurityContext:  valkeySpec.PodSecurityContext,
-					ImagePullSecrets: immich.Spec.ImagePullSecrets,
-					NodeSelector:     valkeySpec.NodeSelector,
-					Tolerations:      valkeySpec.Tolerations,
-					Affinity:         valkeySpec.Affinity,
+					SecurityContext:           valkeySpec.PodSecurityContext,
+					ImagePullSecrets:          immich.Spec.ImagePullSecrets,
+					NodeSelector:              valkeySpec.NodeSelector,
+					Tolerations:               valkeySpec.Tolerations,
+					Affinity:                  valkeySpec.Affinity,
+					TopologySpreadConstraints: getTopologySpreadConstraints(valkeySpec.TopologySpreadConstraints, selectorLabels),
 					Containers: []corev1.Container{
 						{
 							Name:            "valkey",
 							Image:           immich.GetValkeyImage(),
 							ImagePullPolicy: valkeySpec.ImagePullPolicy,
+							Args:            args,
+							Env:             env,
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "redis",
@@ -125,7 +295,7 @@ func (r *ImmichReconciler) reconcileValkeyDeployment(ctx context.Context, immich
 							LivenessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									Exec: &corev1.ExecAction{
-										Command: []string{"sh", "-c", "valkey-cli ping | grep PONG"},
+										Command: []string{"sh", "-c", probeCommand},
 									},
 								},
 								InitialDelaySeconds: 30,
@@ -136,7 +306,7 @@ func (r *ImmichReconciler) reconcileValkeyDeployment(ctx context.Context, immich
 							ReadinessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									Exec: &corev1.ExecAction{
-										Command: []string{"sh", "-c", "valkey-cli ping | grep PONG"},
+										Command: []string{"sh", "-c", probeCommand},
 									},
 								},
 								InitialDelaySeconds: 5,
@@ -308,5 +478,112 @@ func (r *ImmichReconciler) reconcileValkeyPVC(ctx context.Context, immich *media
 		},
 	}
 
-	return r.Create(ctx, pvc)
+	if err := r.Create(ctx, pvc); err != nil {
+		return err
+	}
+	r.recordEvent(immich, corev1.EventTypeNormal, EventReasonPVCCreated, "Created PersistentVolumeClaim %s", pvc.Name)
+	return nil
+}
+
+// getValkeyURLSecretName returns the name of the Secret holding the REDIS_URL of the server
+func getValkeyURLSecretName(immich *mediav1alpha1.Immich) string {
+	return fmt.Sprintf("%s-valkey-url", immich.Name)
+}
+
+// usesValkeyURL returns true if the server connects to Valkey with the options of the REDIS_URL Secret,
+// in sentinel mode or over TLS, instead of REDIS_HOSTNAME and REDIS_PORT
+func usesValkeyURL(immich *mediav1alpha1.Immich) bool {
+	return immich.IsValkeySentinelEnabled() || immich.GetValkeyTLS() != nil
+}
+
+// reconcileValkeyURLSecret creates or updates the Secret holding the REDIS_URL of the server.
+// Immich reads ioredis options from a REDIS_URL of the form ioredis://<base64-encoded JSON>, and does not
+// support rediss:// URLs: TLS is enabled with the tls option instead. The options include the password and
+// the TLS certificates, hence the Secret.
+func (r *ImmichReconciler) reconcileValkeyURLSecret(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	type sentinelAddress struct {
+		Host string `json:"host"`
+		Port int32  `json:"port"`
+	}
+
+	options := map[string]interface{}{}
+	if immich.IsValkeySentinelEnabled() {
+		var sentinels []sentinelAddress
+		for _, host := range getValkeySentinelHosts(immich) {
+			sentinels = append(sentinels, sentinelAddress{Host: host, Port: valkeySentinelPort})
+		}
+		options["name"] = immich.GetValkeySentinelMasterName()
+		options["sentinels"] = sentinels
+	} else {
+		options["host"] = immich.GetValkeyHost()
+		options["port"] = immich.GetValkeyPort()
+		options["db"] = immich.GetValkeyDbIndex()
+	}
+
+	if ref := getValkeyPasswordSecretRef(immich); ref != nil {
+		password, err := r.getSecretValue(ctx, immich, *ref)
+		if err != nil {
+			return fmt.Errorf("failed to read the Valkey password: %w", err)
+		}
+		options["password"] = password
+		if immich.IsValkeySentinelEnabled() {
+			options["sentinelPassword"] = password
+		}
+	}
+
+	if tls := immich.GetValkeyTLS(); tls != nil {
+		tlsOptions := map[string]interface{}{}
+		if tls.GetSSLMode() == mediav1alpha1.SSLModeRequire {
+			tlsOptions["rejectUnauthorized"] = false
+		} else {
+			tlsOptions["servername"] = immich.GetValkeyHost()
+		}
+		for option, ref := range map[string]*mediav1alpha1.SecretKeySelector{
+			"ca":   tls.CASecretRef,
+			"cert": tls.ClientCertSecretRef,
+			"key":  tls.ClientKeySecretRef,
+		} {
+			if ref == nil {
+				continue
+			}
+			value, err := r.getSecretValue(ctx, immich, *ref)
+			if err != nil {
+				return fmt.Errorf("failed to read the Valkey TLS %s: %w", option, err)
+			}
+			tlsOptions[option] = value
+		}
+		options["tls"] = tlsOptions
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Valkey connection options: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getValkeyURLSecretName(immich),
+			Namespace: immich.Namespace,
+			Labels:    r.getLabels(immich, "valkey"),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Data: map[string][]byte{
+			"url": []byte("ioredis://" + base64.StdEncoding.EncodeToString(data)),
+		},
+	}
+
+	return r.apply(ctx, secret)
 }
@@ -0,0 +1,484 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// valkeySentinelPort is the port Sentinel listens on
+const valkeySentinelPort = 26379
+
+// reconcileValkeySentinel creates or updates the Valkey and Sentinel StatefulSets, their headless Services,
+// and the Secret holding the Sentinel connection options of the server
+func (r *ImmichReconciler) reconcileValkeySentinel(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	log := logf.FromContext(ctx)
+	log.V(1).Info("Reconciling Valkey in sentinel mode")
+
+	if err := r.reconcileValkeyHeadlessService(ctx, immich, getValkeyHeadlessServiceName(immich), "valkey", "redis", 6379); err != nil {
+		return err
+	}
+	if err := r.reconcileValkeyHeadlessService(ctx, immich, getValkeySentinelName(immich), "valkey-sentinel", "sentinel", valkeySentinelPort); err != nil {
+		return err
+	}
+	if err := r.reconcileValkeyStatefulSet(ctx, immich); err != nil {
+		return err
+	}
+	if err := r.reconcileValkeySentinelStatefulSet(ctx, immich); err != nil {
+		return err
+	}
+	return r.reconcileValkeyURLSecret(ctx, immich)
+}
+
+// getValkeyHeadlessServiceName returns the name of the headless Service of the Valkey pods in sentinel mode
+func getValkeyHeadlessServiceName(immich *mediav1alpha1.Immich) string {
+	return fmt.Sprintf("%s-valkey-headless", immich.Name)
+}
+
+// getValkeySentinelName returns the name of the Sentinel StatefulSet and of its headless Service
+func getValkeySentinelName(immich *mediav1alpha1.Immich) string {
+	return fmt.Sprintf("%s-valkey-sentinel", immich.Name)
+}
+
+// getValkeySentinelHosts returns the stable hostnames of the Sentinel pods
+func getValkeySentinelHosts(immich *mediav1alpha1.Immich) []string {
+	name := getValkeySentinelName(immich)
+	hosts := make([]string, 0, immich.GetValkeySentinelReplicas())
+	for i := range immich.GetValkeySentinelReplicas() {
+		hosts = append(hosts, fmt.Sprintf("%s-%d.%s", name, i, name))
+	}
+	return hosts
+}
+
+// getValkeySentinelEnv returns the environment of the scripts locating the current primary through Sentinel
+func getValkeySentinelEnv(immich *mediav1alpha1.Immich) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "VALKEY_NAME", Value: fmt.Sprintf("%s-valkey", immich.Name)},
+		{Name: "VALKEY_SERVICE", Value: getValkeyHeadlessServiceName(immich)},
+		{Name: "SENTINEL_NAME", Value: getValkeySentinelName(immich)},
+		{Name: "SENTINEL_SERVICE", Value: getValkeySentinelName(immich)},
+		{Name: "SENTINEL_REPLICAS", Value: fmt.Sprintf("%d", immich.GetValkeySentinelReplicas())},
+		{Name: "MASTER_NAME", Value: immich.GetValkeySentinelMasterName()},
+		{Name: "QUORUM", Value: fmt.Sprintf("%d", immich.GetValkeySentinelQuorum())},
+	}
+}
+
+// valkeySentinelDiscoverScript sets primary to the address of the current primary reported by the Sentinels,
+// or to the first Valkey pod when no Sentinel knows it yet (first start).
+const valkeySentinelDiscoverScript = `set -eu
+if [ -n "${VALKEY_PASSWORD:-}" ]; then
+  export REDISCLI_AUTH="${VALKEY_PASSWORD}"
+fi
+primary=""
+i=0
+while [ -z "${primary}" ] && [ "${i}" -lt "${SENTINEL_REPLICAS}" ]; do
+  primary="$(timeout 5 valkey-cli -h "${SENTINEL_NAME}-${i}.${SENTINEL_SERVICE}" -p 26379 sentinel get-master-addr-by-name "${MASTER_NAME}" 2>/dev/null | head -n 1 | grep -v ' ' || true)"
+  i=$((i + 1))
+done
+if [ -z "${primary}" ]; then
+  primary="${VALKEY_NAME}-0.${VALKEY_SERVICE}"
+fi
+`
+
+// valkeySentinelReplicaScript starts Valkey as the primary, or as a replica of the current primary
+const valkeySentinelReplicaScript = valkeySentinelDiscoverScript + `self="${HOSTNAME}.${VALKEY_SERVICE}"
+set -- --replica-announce-ip "${self}"
+if [ -n "${VALKEY_PASSWORD:-}" ]; then
+  set -- "$@" --requirepass "${VALKEY_PASSWORD}" --masterauth "${VALKEY_PASSWORD}"
+fi
+if [ "${primary}" = "${self}" ]; then
+  echo "Starting as the primary"
+else
+  echo "Starting as a replica of ${primary}"
+  set -- "$@" --replicaof "${primary}" 6379
+fi
+exec valkey-server "$@"
+`
+
+// valkeySentinelScript writes the Sentinel configuration monitoring the current primary, then starts Sentinel.
+// Sentinel rewrites its configuration file, so it is kept in a writable volume.
+const valkeySentinelScript = valkeySentinelDiscoverScript + `conf=/data/sentinel.conf
+cat > "${conf}" <<EOF
+port 26379
+sentinel resolve-hostnames yes
+sentinel announce-hostnames yes
+sentinel announce-ip ${HOSTNAME}.${SENTINEL_SERVICE}
+sentinel monitor ${MASTER_NAME} ${primary} 6379 ${QUORUM}
+sentinel down-after-milliseconds ${MASTER_NAME} 5000
+sentinel failover-timeout ${MASTER_NAME} 60000
+sentinel parallel-syncs ${MASTER_NAME} 1
+EOF
+if [ -n "${VALKEY_PASSWORD:-}" ]; then
+  cat >> "${conf}" <<EOF
+requirepass "${VALKEY_PASSWORD}"
+sentinel auth-pass ${MASTER_NAME} "${VALKEY_PASSWORD}"
+sentinel sentinel-pass "${VALKEY_PASSWORD}"
+EOF
+fi
+exec valkey-server "${conf}" --sentinel
+`
+
+// reconcileValkeyStatefulSet creates or updates the StatefulSet of the Valkey primary and replicas using server-side apply
+func (r *ImmichReconciler) reconcileValkeyStatefulSet(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	name := fmt.Sprintf("%s-valkey", immich.Name)
+	labels := r.getLabels(immich, "valkey")
+	selectorLabels := r.getSelectorLabels(immich, "valkey")
+
+	valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
+
+	annotations, env, err := r.getValkeyPodAuth(ctx, immich)
+	if err != nil {
+		return err
+	}
+	env = append(env, getValkeySentinelEnv(immich)...)
+	probeCommand := getValkeyCLIAuth(immich) + "valkey-cli ping | grep PONG"
+
+	// Each Valkey pod keeps its own copy of the data
+	var volumes []corev1.Volume
+	var volumeClaimTemplates []corev1.PersistentVolumeClaim
+	if immich.IsValkeyPersistenceEnabled() {
+		volumeClaimTemplates = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "data",
+					Labels: labels,
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes:      immich.GetValkeyAccessModes(),
+					StorageClassName: ptr.Deref(valkeySpec.Persistence, mediav1alpha1.ValkeyPersistenceSpec{}).StorageClass,
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: immich.GetValkeySize(),
+						},
+					},
+				},
+			},
+		}
+	} else {
+		volumes = []corev1.Volume{
+			{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		}
+	}
+
+	sts := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "StatefulSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: ptr.To(immich.GetValkeyReplicas()),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectorLabels,
+			},
+			ServiceName:          getValkeyHeadlessServiceName(immich),
+			VolumeClaimTemplates: volumeClaimTemplates,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      r.mergeMaps(labels, valkeySpec.PodLabels),
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					SecurityContext:           valkeySpec.PodSecurityContext,
+					ImagePullSecrets:          immich.Spec.ImagePullSecrets,
+					NodeSelector:              valkeySpec.NodeSelector,
+					Tolerations:               valkeySpec.Tolerations,
+					Affinity:                  getValkeyAffinity(valkeySpec.Affinity, selectorLabels),
+					TopologySpreadConstraints: getTopologySpreadConstraints(valkeySpec.TopologySpreadConstraints, selectorLabels),
+					Containers: []corev1.Container{
+						{
+							Name:            "valkey",
+							Image:           immich.GetValkeyImage(),
+							ImagePullPolicy: valkeySpec.ImagePullPolicy,
+							Command:         []string{"/bin/sh", "-c", valkeySentinelReplicaScript},
+							Env:             env,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "redis",
+									ContainerPort: 6379,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							Resources:       valkeySpec.Resources,
+							SecurityContext: valkeySpec.SecurityContext,
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", probeCommand},
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       10,
+								TimeoutSeconds:      5,
+								FailureThreshold:    3,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", probeCommand},
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+								TimeoutSeconds:      5,
+								FailureThreshold:    3,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "data", MountPath: "/data"},
+							},
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	return r.apply(ctx, sts)
+}
+
+// reconcileValkeySentinelStatefulSet creates or updates the Sentinel StatefulSet using server-side apply.
+// Sentinels need stable hostnames to find each other again after a restart, hence the StatefulSet.
+func (r *ImmichReconciler) reconcileValkeySentinelStatefulSet(ctx context.Context, immich *mediav1alpha1.Immich) error {
+	name := getValkeySentinelName(immich)
+	labels := r.getLabels(immich, "valkey-sentinel")
+	selectorLabels := r.getSelectorLabels(immich, "valkey-sentinel")
+
+	valkeySpec := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
+	sentinelSpec := ptr.Deref(valkeySpec.Sentinel, mediav1alpha1.ValkeySentinelSpec{})
+
+	annotations, env, err := r.getValkeyPodAuth(ctx, immich)
+	if err != nil {
+		return err
+	}
+	env = append(env, getValkeySentinelEnv(immich)...)
+	probeCommand := fmt.Sprintf("%svalkey-cli -p %d ping | grep PONG", getValkeyCLIAuth(immich), valkeySentinelPort)
+
+	sts := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "StatefulSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: ptr.To(immich.GetValkeySentinelReplicas()),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectorLabels,
+			},
+			ServiceName:         name,
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      r.mergeMaps(labels, valkeySpec.PodLabels),
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					SecurityContext:           valkeySpec.PodSecurityContext,
+					ImagePullSecrets:          immich.Spec.ImagePullSecrets,
+					NodeSelector:              valkeySpec.NodeSelector,
+					Tolerations:               valkeySpec.Tolerations,
+					Affinity:                  getValkeyAffinity(valkeySpec.Affinity, selectorLabels),
+					TopologySpreadConstraints: getTopologySpreadConstraints(valkeySpec.TopologySpreadConstraints, selectorLabels),
+					Containers: []corev1.Container{
+						{
+							Name:            "sentinel",
+							Image:           immich.GetValkeyImage(),
+							ImagePullPolicy: valkeySpec.ImagePullPolicy,
+							Command:         []string{"/bin/sh", "-c", valkeySentinelScript},
+							Env:             env,
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "sentinel",
+									ContainerPort: valkeySentinelPort,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
+							Resources:       sentinelSpec.Resources,
+							SecurityContext: valkeySpec.SecurityContext,
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", probeCommand},
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       10,
+								TimeoutSeconds:      5,
+								FailureThreshold:    3,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"sh", "-c", probeCommand},
+									},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       10,
+								TimeoutSeconds:      5,
+								FailureThreshold:    3,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "data", MountPath: "/data"},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+
+	return r.apply(ctx, sts)
+}
+
+// getValkeyAffinity returns the affinity of the Valkey and Sentinel pods: the one set in the spec,
+// or a preference for spreading the pods across nodes, so that a node drain does not stop all of them
+func getValkeyAffinity(affinity *corev1.Affinity, selectorLabels map[string]string) *corev1.Affinity {
+	if affinity != nil {
+		return affinity
+	}
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight: 100,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: selectorLabels},
+						TopologyKey:   corev1.LabelHostname,
+					},
+				},
+			},
+		},
+	}
+}
+
+// reconcileValkeyHeadlessService creates or updates a headless Service giving stable hostnames to the pods of a component.
+// Not ready pods are published, so that Valkey and Sentinel can reach each other while starting.
+func (r *ImmichReconciler) reconcileValkeyHeadlessService(ctx context.Context, immich *mediav1alpha1.Immich, name, component, portName string, port int32) error {
+	labels := r.getLabels(immich, component)
+
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: immich.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         immich.APIVersion,
+					Kind:               immich.Kind,
+					Name:               immich.Name,
+					UID:                immich.UID,
+					Controller:         ptr.To(true),
+					BlockOwnerDeletion: ptr.To(true),
+				},
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:                corev1.ClusterIPNone,
+			PublishNotReadyAddresses: true,
+			Selector:                 r.getSelectorLabels(immich, component),
+			Ports: []corev1.ServicePort{
+				{
+					Name:       portName,
+					Port:       port,
+					TargetPort: intstr.FromString(portName),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+
+	return r.apply(ctx, service)
+}
+
+// updateValkeySentinelStatus reports the status of the Valkey and Sentinel StatefulSets.
+// Valkey is ready once a Valkey pod and enough Sentinels to reach the quorum are ready,
+// as Immich locates the primary through the Sentinels.
+func (r *ImmichReconciler) updateValkeySentinelStatus(ctx context.Context, immich *mediav1alpha1.Immich,
+	previous mediav1alpha1.ComponentsStatus, components *mediav1alpha1.ComponentsStatus) error {
+	valkey := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-valkey", immich.Name), Namespace: immich.Namespace}, valkey); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		components.Valkey = getComponentStatus(previous.Valkey, "", 0, 0, false)
+	} else {
+		components.Valkey = getComponentStatus(previous.Valkey, getContainerImage(valkey.Spec.Template.Spec),
+			ptr.Deref(valkey.Spec.Replicas, 1), valkey.Status.ReadyReplicas, valkey.Status.ReadyReplicas > 0)
+	}
+
+	sentinel := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Name: getValkeySentinelName(immich), Namespace: immich.Namespace}, sentinel); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		components.ValkeySentinel = getComponentStatus(previous.ValkeySentinel, "", 0, 0, false)
+	} else {
+		components.ValkeySentinel = getComponentStatus(previous.ValkeySentinel, getContainerImage(sentinel.Spec.Template.Spec),
+			ptr.Deref(sentinel.Spec.Replicas, 1), sentinel.Status.ReadyReplicas,
+			sentinel.Status.ReadyReplicas >= immich.GetValkeySentinelQuorum())
+	}
+
+	immich.Status.ValkeyReady = components.Valkey.Ready && components.ValkeySentinel.Ready
+	return nil
+}
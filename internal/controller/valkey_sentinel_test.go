@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+func TestReconcileValkey_Sentinel(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageValkey, "valkey:9")
+
+	immich := newTestImmich(withValkeySentinel())
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+
+	if err := r.reconcileValkey(ctx, immich); err != nil {
+		t.Fatalf("reconcileValkey() error = %v", err)
+	}
+
+	if _, ok := applied["Deployment/test-immich-valkey"]; ok {
+		t.Error("no Valkey Deployment should be applied in sentinel mode")
+	}
+
+	valkey := applied["StatefulSet/test-immich-valkey"].(*appsv1.StatefulSet)
+	if *valkey.Spec.Replicas != 3 || valkey.Spec.ServiceName != "test-immich-valkey-headless" {
+		t.Errorf("valkey replicas = %d, serviceName = %s", *valkey.Spec.Replicas, valkey.Spec.ServiceName)
+	}
+	if len(valkey.Spec.VolumeClaimTemplates) != 0 || valkey.Spec.Template.Spec.Volumes[0].EmptyDir == nil {
+		t.Error("valkey data should be ephemeral without persistence")
+	}
+	container := valkey.Spec.Template.Spec.Containers[0]
+	if password := findEnv(container.Env, "VALKEY_PASSWORD"); password == nil {
+		t.Error("VALKEY_PASSWORD should be set")
+	}
+	if !strings.Contains(container.Command[2], "--replicaof") {
+		t.Errorf("valkey should start as a replica of the discovered primary, got %q", container.Command[2])
+	}
+	if valkey.Spec.Template.Spec.Affinity.PodAntiAffinity == nil {
+		t.Error("valkey pods should be spread across nodes by default")
+	}
+
+	sentinel := applied["StatefulSet/test-immich-valkey-sentinel"].(*appsv1.StatefulSet)
+	if *sentinel.Spec.Replicas != 3 || sentinel.Spec.PodManagementPolicy != appsv1.ParallelPodManagement {
+		t.Errorf("sentinel replicas = %d, podManagementPolicy = %s", *sentinel.Spec.Replicas, sentinel.Spec.PodManagementPolicy)
+	}
+	sentinelContainer := sentinel.Spec.Template.Spec.Containers[0]
+	if quorum := findEnv(sentinelContainer.Env, "QUORUM"); quorum == nil || quorum.Value != "2" {
+		t.Errorf("QUORUM = %+v, want 2", quorum)
+	}
+	if !strings.Contains(sentinelContainer.ReadinessProbe.Exec.Command[2], "-p 26379") {
+		t.Errorf("sentinel probe = %q", sentinelContainer.ReadinessProbe.Exec.Command[2])
+	}
+
+	for _, name := range []string{"test-immich-valkey-headless", "test-immich-valkey-sentinel"} {
+		service := applied["Service/"+name].(*corev1.Service)
+		if service.Spec.ClusterIP != corev1.ClusterIPNone || !service.Spec.PublishNotReadyAddresses {
+			t.Errorf("service %s should be headless and publish not ready addresses", name)
+		}
+	}
+
+	secret := applied["Secret/test-immich-valkey-url"].(*corev1.Secret)
+	url := string(secret.Data["url"])
+	if !strings.HasPrefix(url, "ioredis://") {
+		t.Fatalf("url = %s, want ioredis options", url)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(url, "ioredis://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var options struct {
+		Name      string `json:"name"`
+		Password  string `json:"password"`
+		Sentinels []struct {
+			Host string `json:"host"`
+			Port int32  `json:"port"`
+		} `json:"sentinels"`
+		SentinelPassword string `json:"sentinelPassword"`
+	}
+	if err := json.Unmarshal(data, &options); err != nil {
+		t.Fatal(err)
+	}
+	if options.Name != "immich" || len(options.Sentinels) != 3 || options.Password == "" || options.SentinelPassword != options.Password {
+		t.Errorf("options = %+v", options)
+	}
+	if options.Sentinels[2].Host != "test-immich-valkey-sentinel-2.test-immich-valkey-sentinel" || options.Sentinels[2].Port != 26379 {
+		t.Errorf("sentinel = %+v", options.Sentinels[2])
+	}
+
+	env := r.getServerEnv(immich)
+	redisURL := findEnv(env, "REDIS_URL")
+	if redisURL == nil || redisURL.ValueFrom.SecretKeyRef.Name != "test-immich-valkey-url" {
+		t.Errorf("REDIS_URL = %+v, want it from the URL secret", redisURL)
+	}
+	if findEnv(env, "REDIS_HOSTNAME") != nil || findEnv(env, "REDIS_PASSWORD") != nil {
+		t.Error("REDIS_HOSTNAME and REDIS_PASSWORD should not be set in sentinel mode")
+	}
+}
+
+func TestUpdateStatus_ValkeySentinelQuorum(t *testing.T) {
+	ctx := context.Background()
+
+	immich := newTestImmich(withValkeySentinel())
+
+	newStatefulSet := func(name string, readyReplicas int32) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To(int32(3))},
+			Status:     appsv1.StatefulSetStatus{Replicas: 3, ReadyReplicas: readyReplicas},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		sentinelsReady    int32
+		wantValkeyReady   bool
+		wantSentinelReady bool
+	}{
+		{name: "quorum reached", sentinelsReady: 2, wantValkeyReady: true, wantSentinelReady: true},
+		{name: "quorum not reached", sentinelsReady: 1, wantValkeyReady: false, wantSentinelReady: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ImmichReconciler{
+				Client: fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(
+					newStatefulSet("test-immich-valkey", 1),
+					newStatefulSet("test-immich-valkey-sentinel", tt.sentinelsReady),
+				).Build(),
+			}
+			components := &mediav1alpha1.ComponentsStatus{}
+			if err := r.updateValkeySentinelStatus(ctx, immich, mediav1alpha1.ComponentsStatus{}, components); err != nil {
+				t.Fatalf("updateValkeySentinelStatus() error = %v", err)
+			}
+			if immich.Status.ValkeyReady != tt.wantValkeyReady {
+				t.Errorf("valkeyReady = %v, want %v", immich.Status.ValkeyReady, tt.wantValkeyReady)
+			}
+			if !components.Valkey.Ready || components.ValkeySentinel.Ready != tt.wantSentinelReady {
+				t.Errorf("components = %+v / %+v", components.Valkey, components.ValkeySentinel)
+			}
+		})
+	}
+}
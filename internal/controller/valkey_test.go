@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// findEnv returns the environment variable with the given name, or nil
+func findEnv(env []corev1.EnvVar, name string) *corev1.EnvVar {
+	for i := range env {
+		if env[i].Name == name {
+			return &env[i]
+		}
+	}
+	return nil
+}
+
+func TestReconcileValkey_Auth(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageValkey, "valkey:9")
+
+	immich := &mediav1alpha1.Immich{ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"}}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+
+	if err := r.reconcileValkey(ctx, immich); err != nil {
+		t.Fatalf("reconcileValkey() error = %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "test-immich-valkey-credentials", Namespace: "default"}, secret); err != nil {
+		t.Fatalf("Valkey credentials should have been generated: %v", err)
+	}
+	if len(secret.Data["password"]) == 0 || len(secret.OwnerReferences) != 0 {
+		t.Errorf("generated secret = %+v, want a password and no owner reference", secret)
+	}
+
+	deployment := applied["Deployment/test-immich-valkey"].(*appsv1.Deployment)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if strings.Join(container.Args, " ") != "--requirepass $(VALKEY_PASSWORD)" {
+		t.Errorf("args = %v", container.Args)
+	}
+	password := findEnv(container.Env, "VALKEY_PASSWORD")
+	if password == nil || password.ValueFrom.SecretKeyRef.Name != "test-immich-valkey-credentials" {
+		t.Errorf("VALKEY_PASSWORD = %+v, want it from the generated secret", password)
+	}
+	for _, probe := range []*corev1.Probe{container.LivenessProbe, container.ReadinessProbe} {
+		if !strings.Contains(probe.Exec.Command[2], `REDISCLI_AUTH="${VALKEY_PASSWORD}"`) {
+			t.Errorf("probe should authenticate, got %q", probe.Exec.Command[2])
+		}
+	}
+	hash := deployment.Spec.Template.Annotations[valkeyPasswordHashAnnotation]
+	if hash == "" {
+		t.Fatal("Valkey pods should be annotated with the password hash")
+	}
+
+	redisPassword := findEnv(r.getServerEnv(immich), "REDIS_PASSWORD")
+	if redisPassword == nil || redisPassword.ValueFrom.SecretKeyRef.Name != "test-immich-valkey-credentials" {
+		t.Errorf("REDIS_PASSWORD = %+v, want it from the generated secret", redisPassword)
+	}
+
+	// Rotating the password in the secret keeps it and rolls Valkey out
+	secret.Data["password"] = []byte("rotated")
+	if err := r.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcileValkey(ctx, immich); err != nil {
+		t.Fatalf("reconcileValkey() error = %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "test-immich-valkey-credentials", Namespace: "default"}, secret); err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data["password"]) != "rotated" {
+		t.Error("the rotated password should be kept")
+	}
+	deployment = applied["Deployment/test-immich-valkey"].(*appsv1.Deployment)
+	if deployment.Spec.Template.Annotations[valkeyPasswordHashAnnotation] == hash {
+		t.Error("the password hash should change with the password")
+	}
+
+	if requests := r.immichesForSecret(ctx, secret); len(requests) != 0 {
+		t.Errorf("requests = %v, want none as the Immich resource does not exist", requests)
+	}
+}
+
+func TestReconcileValkey_AuthDisabled(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(mediav1alpha1.EnvRelatedImageValkey, "valkey:9")
+
+	immich := &mediav1alpha1.Immich{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-immich", Namespace: "default"},
+		Spec: mediav1alpha1.ImmichSpec{
+			Valkey: &mediav1alpha1.ValkeySpec{Auth: &mediav1alpha1.ValkeyAuthSpec{Enabled: ptr.To(false)}},
+		},
+	}
+
+	applied := map[string]client.Object{}
+	r := &ImmichReconciler{Client: newApplyCapturingClient(t, applied)}
+
+	if err := r.reconcileValkey(ctx, immich); err != nil {
+		t.Fatalf("reconcileValkey() error = %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "test-immich-valkey-credentials", Namespace: "default"}, secret); err == nil {
+		t.Error("no credentials should be generated when authentication is disabled")
+	}
+
+	deployment := applied["Deployment/test-immich-valkey"].(*appsv1.Deployment)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if len(container.Args) != 0 || len(container.Env) != 0 {
+		t.Errorf("args = %v, env = %v, want none", container.Args, container.Env)
+	}
+	if _, ok := deployment.Spec.Template.Annotations[valkeyPasswordHashAnnotation]; ok {
+		t.Error("Valkey pods should not be annotated with a password hash")
+	}
+	if findEnv(r.getServerEnv(immich), "REDIS_PASSWORD") != nil {
+		t.Error("REDIS_PASSWORD should not be set")
+	}
+}
@@ -0,0 +1,806 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mediav1alpha1 "github.com/rm3l/immich-operator/api/v1alpha1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var immichlog = logf.Log.WithName("immich-resource")
+
+// SetupImmichWebhookWithManager registers the webhook for Immich in the manager.
+func SetupImmichWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&mediav1alpha1.Immich{}).
+		WithValidator(&ImmichCustomValidator{}).
+		WithDefaulter(&ImmichCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-media-rm3l-org-v1alpha1-immich,mutating=true,failurePolicy=fail,sideEffects=None,groups=media.rm3l.org,resources=immiches,verbs=create;update,versions=v1alpha1,name=mimmich-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ImmichCustomDefaulter sets default values on the Immich resource when it is created or updated.
+// The defaults mirror the implicit ones applied by the Get*/Is* helpers, so that they are
+// visible in the stored object.
+type ImmichCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &ImmichCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Immich.
+func (d *ImmichCustomDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	immich, ok := obj.(*mediav1alpha1.Immich)
+	if !ok {
+		return fmt.Errorf("expected an Immich object but got %T", obj)
+	}
+	immichlog.Info("Defaulting for Immich", "name", immich.GetName())
+
+	defaultImmich(immich)
+	return nil
+}
+
+// defaultImmich fills in the implicit defaults of the Immich spec.
+// Images are intentionally not defaulted, so that upgrading the operator
+// (and its RELATED_IMAGE_* environment variables) keeps upgrading Immich.
+func defaultImmich(immich *mediav1alpha1.Immich) {
+	spec := &immich.Spec
+
+	// Shared configuration
+	if spec.Immich == nil {
+		spec.Immich = &mediav1alpha1.ImmichConfig{}
+	}
+	if spec.Immich.Metrics == nil {
+		spec.Immich.Metrics = &mediav1alpha1.MetricsSpec{}
+	}
+	setDefault(&spec.Immich.Metrics.Enabled, false)
+	// ConfigurationKind is not defaulted, so that Secrets referenced later still store the configuration in a Secret
+
+	if spec.Immich.Persistence == nil {
+		spec.Immich.Persistence = &mediav1alpha1.PersistenceSpec{}
+	}
+	if spec.Immich.Persistence.Library == nil {
+		spec.Immich.Persistence.Library = &mediav1alpha1.LibraryPersistenceSpec{}
+	}
+	library := spec.Immich.Persistence.Library
+	if library.ExistingClaim == nil || *library.ExistingClaim == "" {
+		setDefault(&library.Size, immich.GetLibrarySize())
+		if len(library.AccessModes) == 0 {
+			library.AccessModes = immich.GetLibraryAccessModes()
+		}
+	}
+
+	// Server
+	if spec.Server == nil {
+		spec.Server = &mediav1alpha1.ServerSpec{}
+	}
+	setDefault(&spec.Server.Enabled, immich.IsServerEnabled())
+	setDefault(&spec.Server.Replicas, immich.GetServerReplicas())
+	if spec.Server.Ingress != nil {
+		setDefault(&spec.Server.Ingress.Enabled, false)
+		for h := range spec.Server.Ingress.Hosts {
+			for p := range spec.Server.Ingress.Hosts[h].Paths {
+				path := &spec.Server.Ingress.Hosts[h].Paths[p]
+				setDefault(&path.Path, "/")
+				setDefault(&path.PathType, "Prefix")
+			}
+		}
+	}
+	if spec.Server.Autoscaling != nil {
+		defaultAutoscaling(spec.Server.Autoscaling)
+	}
+	if spec.Server.HardwareTranscoding != nil {
+		setDefault(&spec.Server.HardwareTranscoding.Enabled, true)
+	}
+	if spec.Server.Workers != nil {
+		setDefault(&spec.Server.Workers.Mode, mediav1alpha1.ServerWorkersModeCombined)
+		for _, worker := range []*mediav1alpha1.ServerWorkerSpec{spec.Server.Workers.API, spec.Server.Workers.Microservices} {
+			if worker != nil && worker.Autoscaling != nil {
+				defaultAutoscaling(worker.Autoscaling)
+			}
+		}
+	}
+	if spec.Server.Route != nil {
+		// Route.Enabled is left unset on purpose: nil means auto-detection
+		setDefault(&spec.Server.Route.Path, "/")
+		setDefault(&spec.Server.Route.WildcardPolicy, "None")
+		if spec.Server.Route.TLS != nil {
+			setDefault(&spec.Server.Route.TLS.Termination, "edge")
+			setDefault(&spec.Server.Route.TLS.InsecureEdgeTerminationPolicy, "Redirect")
+		}
+	}
+
+	// Machine Learning
+	if spec.MachineLearning == nil {
+		spec.MachineLearning = &mediav1alpha1.MachineLearningSpec{}
+	}
+	setDefault(&spec.MachineLearning.Enabled, immich.IsMachineLearningEnabled())
+	if immich.IsMachineLearningEnabled() {
+		setDefault(&spec.MachineLearning.Replicas, immich.GetMachineLearningReplicas())
+		setDefault(&spec.MachineLearning.Acceleration, immich.GetMachineLearningAcceleration())
+		if spec.MachineLearning.Autoscaling != nil {
+			defaultAutoscaling(spec.MachineLearning.Autoscaling)
+		}
+		if spec.MachineLearning.Persistence == nil {
+			spec.MachineLearning.Persistence = &mediav1alpha1.MachineLearningPersistenceSpec{}
+		}
+		setDefault(&spec.MachineLearning.Persistence.Enabled, immich.IsMLPersistenceEnabled())
+		if immich.ShouldCreateMLCachePVC() {
+			setDefault(&spec.MachineLearning.Persistence.Size, immich.GetMLCacheSize())
+		}
+	}
+
+	// Valkey
+	if spec.Valkey == nil {
+		spec.Valkey = &mediav1alpha1.ValkeySpec{}
+	}
+	setDefault(&spec.Valkey.Enabled, immich.IsValkeyEnabled())
+	setDefault(&spec.Valkey.Port, immich.GetValkeyPort())
+	setDefault(&spec.Valkey.DbIndex, immich.GetValkeyDbIndex())
+	if immich.IsValkeyEnabled() {
+		if spec.Valkey.Persistence == nil {
+			spec.Valkey.Persistence = &mediav1alpha1.ValkeyPersistenceSpec{}
+		}
+		setDefault(&spec.Valkey.Persistence.Enabled, immich.IsValkeyPersistenceEnabled())
+		if immich.ShouldCreateValkeyPVC() {
+			setDefault(&spec.Valkey.Persistence.Size, immich.GetValkeySize())
+		}
+		setDefault(&spec.Valkey.Mode, mediav1alpha1.ValkeyModeStandalone)
+		if immich.IsValkeySentinelEnabled() {
+			if spec.Valkey.Sentinel == nil {
+				spec.Valkey.Sentinel = &mediav1alpha1.ValkeySentinelSpec{}
+			}
+			setDefault(&spec.Valkey.Sentinel.Replicas, immich.GetValkeyReplicas())
+			setDefault(&spec.Valkey.Sentinel.SentinelReplicas, immich.GetValkeySentinelReplicas())
+			setDefault(&spec.Valkey.Sentinel.Quorum, immich.GetValkeySentinelQuorum())
+			setDefault(&spec.Valkey.Sentinel.MasterName, immich.GetValkeySentinelMasterName())
+		}
+	}
+
+	// PostgreSQL
+	if spec.Postgres == nil {
+		spec.Postgres = &mediav1alpha1.PostgresSpec{}
+	}
+	setDefault(&spec.Postgres.Enabled, immich.IsPostgresEnabled())
+	setDefault(&spec.Postgres.Provider, immich.GetPostgresProvider())
+	if immich.IsCloudNativePGEnabled() {
+		if spec.Postgres.CloudNativePG == nil {
+			spec.Postgres.CloudNativePG = &mediav1alpha1.CloudNativePGSpec{}
+		}
+		setDefault(&spec.Postgres.CloudNativePG.Instances, immich.GetCloudNativePGInstances())
+	}
+	setDefault(&spec.Postgres.Port, immich.GetPostgresPort())
+	setDefault(&spec.Postgres.Database, immich.GetPostgresDatabase())
+	setDefault(&spec.Postgres.Username, immich.GetPostgresUsername())
+	if immich.IsPostgresEnabled() && immich.ShouldCreatePostgresPVC() {
+		if spec.Postgres.Persistence == nil {
+			spec.Postgres.Persistence = &mediav1alpha1.PostgresPersistenceSpec{}
+		}
+		setDefault(&spec.Postgres.Persistence.Size, immich.GetPostgresSize())
+	}
+	if immich.IsPostgresBackupEnabled() {
+		backup := spec.Postgres.Backup
+		setDefault(&backup.Schedule, "0 2 * * *")
+		setDefault(&backup.Method, "pg_dump")
+		setDefault(&backup.Compression, "gzip")
+		setDefault(&backup.Retention, int32(7))
+		if immich.ShouldCreatePostgresBackupPVC() {
+			if backup.Persistence == nil {
+				backup.Persistence = &mediav1alpha1.BackupPersistenceSpec{}
+			}
+			setDefault(&backup.Persistence.Size, resource.MustParse("10Gi"))
+		}
+	}
+}
+
+// defaultAutoscaling sets the defaults of an autoscaling configuration
+func defaultAutoscaling(autoscaling *mediav1alpha1.AutoscalingSpec) {
+	setDefault(&autoscaling.Enabled, false)
+	if autoscaling.IsEnabled() {
+		setDefault(&autoscaling.MinReplicas, 1)
+	}
+}
+
+// setDefault sets *field to value if it is currently nil.
+func setDefault[T any](field **T, value T) {
+	if *field == nil {
+		*field = ptr.To(value)
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-media-rm3l-org-v1alpha1-immich,mutating=false,failurePolicy=fail,sideEffects=None,groups=media.rm3l.org,resources=immiches,verbs=create;update,versions=v1alpha1,name=vimmich-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ImmichCustomValidator validates the Immich resource when it is created or updated.
+// It rejects invalid combinations of fields that would otherwise only surface
+// later as a Degraded condition during reconciliation.
+type ImmichCustomValidator struct{}
+
+var _ webhook.CustomValidator = &ImmichCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Immich.
+func (v *ImmichCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	immich, ok := obj.(*mediav1alpha1.Immich)
+	if !ok {
+		return nil, fmt.Errorf("expected an Immich object but got %T", obj)
+	}
+	immichlog.Info("Validation for Immich upon creation", "name", immich.GetName())
+
+	return validateImmich(immich)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Immich.
+func (v *ImmichCustomValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	immich, ok := newObj.(*mediav1alpha1.Immich)
+	if !ok {
+		return nil, fmt.Errorf("expected an Immich object for the newObj but got %T", newObj)
+	}
+	immichlog.Info("Validation for Immich upon update", "name", immich.GetName())
+
+	return validateImmich(immich)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Immich.
+func (v *ImmichCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	// Nothing to validate on deletion
+	return nil, nil
+}
+
+// validateImmich runs all cross-field validations and returns an Invalid error listing every problem found.
+func validateImmich(immich *mediav1alpha1.Immich) (admission.Warnings, error) {
+	var allErrs field.ErrorList
+	var warnings admission.Warnings
+
+	specPath := field.NewPath("spec")
+
+	allErrs = append(allErrs, validatePostgres(immich, specPath.Child("postgres"))...)
+	allErrs = append(allErrs, validateValkey(immich, specPath.Child("valkey"))...)
+
+	serverPath := specPath.Child("server")
+	if immich.Spec.Server != nil {
+		allErrs = append(allErrs, validateAutoscaling(immich.Spec.Server.Autoscaling, serverPath.Child("autoscaling"))...)
+		allErrs = append(allErrs, validatePodDisruptionBudget(immich.Spec.Server.PodDisruptionBudget, serverPath.Child("podDisruptionBudget"))...)
+		if immich.Spec.Server.Ingress != nil && immich.IsIngressEnabled() {
+			allErrs = append(allErrs, validateIngress(immich.Spec.Server.Ingress, serverPath.Child("ingress"))...)
+		}
+		if immich.Spec.Server.Route != nil && immich.Spec.Server.Route.TLS != nil {
+			allErrs = append(allErrs, validateRouteTLS(immich.Spec.Server.Route.TLS, serverPath.Child("route", "tls"))...)
+		}
+		allErrs = append(allErrs, validateHardwareTranscoding(immich, serverPath)...)
+		if workers := immich.Spec.Server.Workers; workers != nil {
+			workersPath := serverPath.Child("workers")
+			if workers.API != nil {
+				allErrs = append(allErrs, validateAutoscaling(workers.API.Autoscaling, workersPath.Child("api", "autoscaling"))...)
+			}
+			if workers.Microservices != nil {
+				allErrs = append(allErrs, validateAutoscaling(workers.Microservices.Autoscaling, workersPath.Child("microservices", "autoscaling"))...)
+			}
+			if ptr.Deref(workers.Mode, "") != mediav1alpha1.ServerWorkersModeSplit && (workers.API != nil || workers.Microservices != nil) {
+				warnings = append(warnings, "spec.server.workers.api and spec.server.workers.microservices are ignored unless spec.server.workers.mode=split")
+			}
+		}
+	}
+
+	if immich.Spec.MachineLearning != nil {
+		allErrs = append(allErrs, validateAutoscaling(immich.Spec.MachineLearning.Autoscaling, specPath.Child("machineLearning", "autoscaling"))...)
+		allErrs = append(allErrs, validatePodDisruptionBudget(immich.Spec.MachineLearning.PodDisruptionBudget,
+			specPath.Child("machineLearning", "podDisruptionBudget"))...)
+		if immich.IsMachineLearningEnabled() && !immich.HasMachineLearningBackends() {
+			allErrs = append(allErrs, validateMachineLearningAcceleration(immich, specPath.Child("machineLearning"))...)
+		}
+		backendErrs, backendWarnings := validateMachineLearningBackends(immich, specPath.Child("machineLearning"))
+		allErrs = append(allErrs, backendErrs...)
+		warnings = append(warnings, backendWarnings...)
+	}
+	if immich.Spec.Valkey != nil {
+		allErrs = append(allErrs, validatePodDisruptionBudget(immich.Spec.Valkey.PodDisruptionBudget, specPath.Child("valkey", "podDisruptionBudget"))...)
+	}
+	if immich.Spec.Postgres != nil {
+		allErrs = append(allErrs, validatePodDisruptionBudget(immich.Spec.Postgres.PodDisruptionBudget, specPath.Child("postgres", "podDisruptionBudget"))...)
+	}
+
+	immichConfig := ptr.Deref(immich.Spec.Immich, mediav1alpha1.ImmichConfig{})
+	if immichConfig.Configuration != nil {
+		allErrs = append(allErrs, validateConfiguration(immichConfig.Configuration, specPath.Child("immich", "configuration"))...)
+	}
+	if immichConfig.RawConfiguration != nil {
+		allErrs = append(allErrs, validateRawConfiguration(immichConfig.RawConfiguration, specPath.Child("immich", "rawConfiguration"))...)
+	}
+
+	if !immich.IsPostgresEnabled() && immich.Spec.Postgres != nil && immich.Spec.Postgres.Backup != nil &&
+		ptr.Deref(immich.Spec.Postgres.Backup.Enabled, false) {
+		warnings = append(warnings, "spec.postgres.backup is ignored when spec.postgres.enabled=false")
+	}
+
+	if !immich.IsCloudNativePGEnabled() && immich.Spec.Postgres != nil && immich.Spec.Postgres.CloudNativePG != nil {
+		warnings = append(warnings, "spec.postgres.cloudNativePG is ignored unless spec.postgres.provider=cloudnative-pg")
+	}
+
+	if immich.IsCloudNativePGEnabled() && immich.Spec.Postgres.PodDisruptionBudget != nil {
+		warnings = append(warnings, "spec.postgres.podDisruptionBudget is ignored with provider=cloudnative-pg: CloudNativePG manages the PodDisruptionBudgets of its Cluster")
+	}
+
+	if immichConfig.ConfigurationKind != nil && *immichConfig.ConfigurationKind == "ConfigMap" && immich.HasConfigurationSecretRefs() {
+		warnings = append(warnings, "spec.immich.configurationKind=ConfigMap is ignored: a configuration referencing Secrets is stored in a Secret")
+	}
+
+	// The replicas defaulted while machine learning was enabled are not worth a warning
+	if !immich.IsMachineLearningEnabled() && immich.GetMachineLearningReplicas() != 1 {
+		warnings = append(warnings, "spec.machineLearning.replicas is ignored when spec.machineLearning.enabled=false")
+	}
+
+	if !immich.IsMachineLearningEnabled() && immich.GetMachineLearningAcceleration() != mediav1alpha1.MachineLearningAccelerationCPU {
+		warnings = append(warnings, "spec.machineLearning.acceleration is ignored when spec.machineLearning.enabled=false")
+	}
+
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+
+	return warnings, apierrors.NewInvalid(
+		mediav1alpha1.GroupVersion.WithKind("Immich").GroupKind(),
+		immich.Name, allErrs)
+}
+
+// validatePostgres checks that an external database has a host, credentials and a complete TLS configuration,
+// and that a CloudNativePG database does not use the settings of the built-in StatefulSet.
+func validatePostgres(immich *mediav1alpha1.Immich, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	postgres := ptr.Deref(immich.Spec.Postgres, mediav1alpha1.PostgresSpec{})
+
+	if !immich.IsPostgresEnabled() {
+		if postgres.Host == nil || *postgres.Host == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("host"),
+				"required when spec.postgres.enabled=false"))
+		}
+		if postgres.PasswordSecretRef == nil && postgres.URLSecretRef == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("passwordSecretRef"),
+				"passwordSecretRef or urlSecretRef is required when spec.postgres.enabled=false"))
+		}
+	}
+
+	// CloudNativePG generates the credentials and manages the storage and backups of its Cluster
+	if immich.IsCloudNativePGEnabled() {
+		if postgres.PasswordSecretRef != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("passwordSecretRef"),
+				"not supported with provider cloudnative-pg, which generates the credentials"))
+		}
+		if postgres.Persistence != nil && postgres.Persistence.ExistingClaim != nil && *postgres.Persistence.ExistingClaim != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("persistence", "existingClaim"),
+				"not supported with provider cloudnative-pg"))
+		}
+		if postgres.Backup != nil && ptr.Deref(postgres.Backup.Enabled, false) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("backup", "enabled"),
+				"not supported with provider cloudnative-pg: configure backups on the CloudNativePG Cluster"))
+		}
+	}
+
+	allErrs = append(allErrs, validateSecretKeySelector(postgres.PasswordSecretRef, fldPath.Child("passwordSecretRef"))...)
+	allErrs = append(allErrs, validateSecretKeySelector(postgres.URLSecretRef, fldPath.Child("urlSecretRef"))...)
+
+	if postgres.Port != nil && (*postgres.Port < 1 || *postgres.Port > 65535) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), *postgres.Port, "must be between 1 and 65535"))
+	}
+
+	if postgres.Backup != nil {
+		allErrs = append(allErrs, validatePostgresBackup(postgres.Backup, fldPath.Child("backup"))...)
+	}
+
+	if postgres.TLS != nil {
+		if immich.IsPostgresEnabled() {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("tls"), "only supported when spec.postgres.enabled=false"))
+		}
+		allErrs = append(allErrs, validateTLS(postgres.TLS, fldPath.Child("tls"))...)
+		sslMode := postgres.TLS.GetSSLMode()
+		if (sslMode == mediav1alpha1.SSLModeVerifyCA || sslMode == mediav1alpha1.SSLModeVerifyFull) && postgres.TLS.CASecretRef == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("tls", "caSecretRef"),
+				fmt.Sprintf("required with sslMode %s", sslMode)))
+		}
+	}
+
+	return allErrs
+}
+
+// validateTLS checks the secret references of a TLS configuration, and that the client certificate comes with its key.
+func validateTLS(tls *mediav1alpha1.TLSSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	allErrs = append(allErrs, validateSecretKeySelector(tls.CASecretRef, fldPath.Child("caSecretRef"))...)
+	allErrs = append(allErrs, validateSecretKeySelector(tls.ClientCertSecretRef, fldPath.Child("clientCertSecretRef"))...)
+	allErrs = append(allErrs, validateSecretKeySelector(tls.ClientKeySecretRef, fldPath.Child("clientKeySecretRef"))...)
+
+	if tls.ClientCertSecretRef != nil && tls.ClientKeySecretRef == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("clientKeySecretRef"), "required with clientCertSecretRef"))
+	}
+	if tls.ClientKeySecretRef != nil && tls.ClientCertSecretRef == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("clientCertSecretRef"), "required with clientKeySecretRef"))
+	}
+
+	return allErrs
+}
+
+// validateHardwareTranscoding checks that the hardware transcoding overrides apply to the ffmpeg.accel of the
+// typed configuration, and that rkmpp only runs on arm64 nodes. An acceleration set in the raw configuration is not checked.
+func validateHardwareTranscoding(immich *mediav1alpha1.Immich, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	accel := immich.GetFFmpegAccel()
+
+	spec := immich.Spec.Server.HardwareTranscoding
+	if spec != nil && spec.DeviceAccess != nil && (accel == mediav1alpha1.FFmpegAccelNVENC || accel == mediav1alpha1.FFmpegAccelRKMPP) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("hardwareTranscoding", "deviceAccess"), *spec.DeviceAccess,
+			fmt.Sprintf("only applies to the qsv and vaapi accelerations, not to ffmpeg.accel=%s", accel)))
+	}
+
+	if accel == mediav1alpha1.FFmpegAccelRKMPP && (spec == nil || ptr.Deref(spec.Enabled, true)) {
+		// Videos are transcoded by the microservices worker, which may have its own node selector in split mode
+		nodeSelector, path := immich.Spec.Server.NodeSelector, fldPath.Child("nodeSelector")
+		if immich.IsServerWorkersSplit() && immich.Spec.Server.Workers.Microservices != nil &&
+			immich.Spec.Server.Workers.Microservices.NodeSelector != nil {
+			nodeSelector, path = immich.Spec.Server.Workers.Microservices.NodeSelector, fldPath.Child("workers", "microservices", "nodeSelector")
+		}
+		if arch, ok := nodeSelector[corev1.LabelArchStable]; ok && arch != "arm64" {
+			allErrs = append(allErrs, field.Invalid(path.Key(corev1.LabelArchStable), arch,
+				"ffmpeg.accel=rkmpp is only supported on arm64 nodes"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateMachineLearningAcceleration checks that the acceleration can run with the machine learning settings:
+// the image variant for the acceleration must be derivable from the image, and ARM NN and RKNN only run on arm64 nodes.
+func validateMachineLearningAcceleration(immich *mediav1alpha1.Immich, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	acceleration := immich.GetMachineLearningAcceleration()
+	mlSpec := immich.Spec.MachineLearning
+
+	if mlSpec.Image != nil && *mlSpec.Image != "" {
+		if _, ok := immich.GetMachineLearningImageVariant(); !ok {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("image"), *mlSpec.Image,
+				fmt.Sprintf("cannot derive the image variant for acceleration=%s: use a tag ending with -%s", acceleration, acceleration)))
+		}
+	}
+
+	switch acceleration {
+	case mediav1alpha1.MachineLearningAccelerationARMNN, mediav1alpha1.MachineLearningAccelerationRKNN:
+		if arch, ok := mlSpec.NodeSelector[corev1.LabelArchStable]; ok && arch != "arm64" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("nodeSelector").Key(corev1.LabelArchStable), arch,
+				fmt.Sprintf("acceleration=%s is only supported on arm64 nodes", acceleration)))
+		}
+	}
+
+	return allErrs
+}
+
+// validateMachineLearningBackends checks the built-in backends of spec.machineLearning.backends like the machine
+// learning component, and warns about the settings ignored with backends.
+func validateMachineLearningBackends(immich *mediav1alpha1.Immich, fldPath *field.Path) (field.ErrorList, admission.Warnings) {
+	var allErrs field.ErrorList
+	var warnings admission.Warnings
+	if !immich.HasMachineLearningBackends() {
+		return allErrs, warnings
+	}
+
+	mlSpec := immich.Spec.MachineLearning
+	if mlSpec.URL != nil && *mlSpec.URL != "" {
+		warnings = append(warnings, "spec.machineLearning.url is ignored when spec.machineLearning.backends is set: add it as a backend with a url")
+	}
+
+	builtIn := 0
+	for i, backend := range mlSpec.Backends {
+		path := fldPath.Child("backends").Index(i)
+		if backend.IsRemote() {
+			if backend.Image != nil || backend.Acceleration != nil || backend.Replicas != nil || backend.Autoscaling != nil ||
+				backend.Resources != nil || backend.Env != nil || backend.NodeSelector != nil || backend.Tolerations != nil ||
+				backend.Affinity != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: the settings of a backend with a url are ignored, the operator deploys nothing for it", path))
+			}
+			continue
+		}
+		if !immich.IsMachineLearningEnabled() {
+			warnings = append(warnings, fmt.Sprintf("%s is ignored when spec.machineLearning.enabled=false: only backends with a url are used", path))
+			continue
+		}
+
+		builtIn++
+		allErrs = append(allErrs, validateAutoscaling(backend.Autoscaling, path.Child("autoscaling"))...)
+
+		acceleration := immich.GetMachineLearningBackendAcceleration(&backend)
+		imagePath := fldPath.Child("image")
+		if backend.Image != nil && *backend.Image != "" {
+			imagePath = path.Child("image")
+		}
+		if (backend.Image != nil && *backend.Image != "") || (mlSpec.Image != nil && *mlSpec.Image != "") {
+			if image, ok := immich.GetMachineLearningBackendImageVariant(&backend); !ok {
+				allErrs = append(allErrs, field.Invalid(imagePath, image,
+					fmt.Sprintf("cannot derive the image variant for acceleration=%s of backend %s: use a tag ending with -%s",
+						acceleration, backend.Name, acceleration)))
+			}
+		}
+
+		switch acceleration {
+		case mediav1alpha1.MachineLearningAccelerationARMNN, mediav1alpha1.MachineLearningAccelerationRKNN:
+			nodeSelector, selectorPath := mlSpec.NodeSelector, fldPath.Child("nodeSelector")
+			if backend.NodeSelector != nil {
+				nodeSelector, selectorPath = backend.NodeSelector, path.Child("nodeSelector")
+			}
+			if arch, ok := nodeSelector[corev1.LabelArchStable]; ok && arch != "arm64" {
+				allErrs = append(allErrs, field.Invalid(selectorPath.Key(corev1.LabelArchStable), arch,
+					fmt.Sprintf("acceleration=%s is only supported on arm64 nodes", acceleration)))
+			}
+		}
+	}
+
+	// The built-in backends share an existing cache claim, mounted on the nodes of all of them
+	if persistence := mlSpec.Persistence; builtIn > 1 && immich.IsMLPersistenceEnabled() && !immich.ShouldCreateMLCachePVC() &&
+		!slices.Contains(persistence.AccessModes, corev1.ReadWriteMany) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("persistence", "existingClaim"), *persistence.ExistingClaim,
+			fmt.Sprintf("is shared by the %d built-in backends, which may run on different nodes: "+
+				"use a ReadWriteMany claim and declare it in spec.machineLearning.persistence.accessModes, "+
+				"or remove it to give each backend its own cache", builtIn)))
+	}
+
+	return allErrs, warnings
+}
+
+// validateAutoscaling checks that an enabled autoscaling configuration has a replica range.
+func validateAutoscaling(autoscaling *mediav1alpha1.AutoscalingSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if !autoscaling.IsEnabled() {
+		return allErrs
+	}
+	if autoscaling.MaxReplicas == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("maxReplicas"), "required when autoscaling is enabled"))
+	} else if minReplicas := ptr.Deref(autoscaling.MinReplicas, 1); *autoscaling.MaxReplicas < minReplicas {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxReplicas"), *autoscaling.MaxReplicas,
+			fmt.Sprintf("must be greater than or equal to minReplicas (%d)", minReplicas)))
+	}
+
+	return allErrs
+}
+
+// validatePodDisruptionBudget checks that a PodDisruptionBudget sets at most one of minAvailable and maxUnavailable.
+func validatePodDisruptionBudget(budget *mediav1alpha1.PodDisruptionBudgetSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if budget != nil && budget.MinAvailable != nil && budget.MaxUnavailable != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("maxUnavailable"), "cannot be set with minAvailable"))
+	}
+
+	return allErrs
+}
+
+// validatePostgresBackup checks that the backup has a single, complete destination.
+func validatePostgresBackup(backup *mediav1alpha1.PostgresBackupSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if backup.Schedule != nil && strings.TrimSpace(*backup.Schedule) == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("schedule"), "must not be empty"))
+	}
+
+	if backup.S3 == nil {
+		return allErrs
+	}
+
+	s3Path := fldPath.Child("s3")
+	if backup.Persistence != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("persistence"), "persistence and s3 are mutually exclusive"))
+	}
+	if backup.S3.Bucket == "" {
+		allErrs = append(allErrs, field.Required(s3Path.Child("bucket"), ""))
+	}
+	allErrs = append(allErrs, validateSecretKeySelector(&backup.S3.AccessKeyIDSecretRef, s3Path.Child("accessKeyIdSecretRef"))...)
+	allErrs = append(allErrs, validateSecretKeySelector(&backup.S3.SecretAccessKeySecretRef, s3Path.Child("secretAccessKeySecretRef"))...)
+
+	return allErrs
+}
+
+// validateValkey checks that an external Valkey/Redis has a host, a valid database index and a supported TLS mode,
+// and that the sentinel mode of the built-in Valkey can reach its quorum.
+func validateValkey(immich *mediav1alpha1.Immich, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	valkey := ptr.Deref(immich.Spec.Valkey, mediav1alpha1.ValkeySpec{})
+
+	if !immich.IsValkeyEnabled() && (valkey.Host == nil || *valkey.Host == "") {
+		allErrs = append(allErrs, field.Required(fldPath.Child("host"),
+			"required when spec.valkey.enabled=false"))
+	}
+
+	allErrs = append(allErrs, validateSecretKeySelector(valkey.PasswordSecretRef, fldPath.Child("passwordSecretRef"))...)
+
+	if valkey.Port != nil && (*valkey.Port < 1 || *valkey.Port > 65535) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), *valkey.Port, "must be between 1 and 65535"))
+	}
+	if valkey.DbIndex != nil && (*valkey.DbIndex < 0 || *valkey.DbIndex > 15) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("dbIndex"), *valkey.DbIndex, "must be between 0 and 15"))
+	}
+
+	if valkey.TLS != nil {
+		if immich.IsValkeyEnabled() {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("tls"), "only supported when spec.valkey.enabled=false"))
+		}
+		allErrs = append(allErrs, validateTLS(valkey.TLS, fldPath.Child("tls"))...)
+		switch sslMode := valkey.TLS.GetSSLMode(); sslMode {
+		case mediav1alpha1.SSLModeDisable, mediav1alpha1.SSLModeRequire, mediav1alpha1.SSLModeVerifyFull:
+		default:
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("tls", "sslMode"), sslMode,
+				[]string{mediav1alpha1.SSLModeDisable, mediav1alpha1.SSLModeRequire, mediav1alpha1.SSLModeVerifyFull}))
+		}
+	}
+
+	if ptr.Deref(valkey.Mode, mediav1alpha1.ValkeyModeStandalone) == mediav1alpha1.ValkeyModeSentinel {
+		if !immich.IsValkeyEnabled() {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("mode"), *valkey.Mode,
+				"sentinel mode requires the built-in Valkey (spec.valkey.enabled=true)"))
+		}
+		persistence := ptr.Deref(valkey.Persistence, mediav1alpha1.ValkeyPersistenceSpec{})
+		if persistence.ExistingClaim != nil && *persistence.ExistingClaim != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("persistence", "existingClaim"),
+				"not supported in sentinel mode, where each Valkey pod gets its own PVC"))
+		}
+		if quorum := immich.GetValkeySentinelQuorum(); quorum > immich.GetValkeySentinelReplicas() {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("sentinel", "quorum"), quorum,
+				"must not exceed spec.valkey.sentinel.sentinelReplicas"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateIngress checks the hosts and paths of an enabled Ingress.
+func validateIngress(ingress *mediav1alpha1.IngressSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(ingress.Hosts) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("hosts"),
+			"at least one host is required when spec.server.ingress.enabled=true"))
+	}
+
+	validPathTypes := []string{
+		string(networkingv1.PathTypeExact),
+		string(networkingv1.PathTypePrefix),
+		string(networkingv1.PathTypeImplementationSpecific),
+	}
+	for h, host := range ingress.Hosts {
+		hostPath := fldPath.Child("hosts").Index(h)
+		for p, path := range host.Paths {
+			pathPath := hostPath.Child("paths").Index(p)
+			if path.PathType != nil && !slices.Contains(validPathTypes, *path.PathType) {
+				allErrs = append(allErrs, field.NotSupported(pathPath.Child("pathType"), *path.PathType, validPathTypes))
+			}
+			if path.Path != nil && !strings.HasPrefix(*path.Path, "/") {
+				allErrs = append(allErrs, field.Invalid(pathPath.Child("path"), *path.Path, "must be an absolute path starting with '/'"))
+			}
+		}
+	}
+
+	for t, tls := range ingress.TLS {
+		if tls.SecretName == nil || *tls.SecretName == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("tls").Index(t).Child("secretName"), ""))
+		}
+	}
+
+	return allErrs
+}
+
+// validateRouteTLS checks that the Route TLS fields are consistent with the termination type.
+func validateRouteTLS(tls *mediav1alpha1.RouteTLSConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	isSet := func(s *string) bool { return s != nil && *s != "" }
+	termination := ptr.Deref(tls.Termination, "edge")
+
+	if isSet(tls.Key) && !isSet(tls.Certificate) {
+		allErrs = append(allErrs, field.Required(fldPath.Child("certificate"), "required when key is set"))
+	}
+	if isSet(tls.Certificate) && !isSet(tls.Key) {
+		allErrs = append(allErrs, field.Required(fldPath.Child("key"), "required when certificate is set"))
+	}
+
+	if termination == "passthrough" {
+		if isSet(tls.Certificate) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("certificate"), "not allowed with passthrough termination"))
+		}
+		if isSet(tls.Key) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("key"), "not allowed with passthrough termination"))
+		}
+		if isSet(tls.CACertificate) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("caCertificate"), "not allowed with passthrough termination"))
+		}
+	}
+
+	if isSet(tls.DestinationCACertificate) && termination != "reencrypt" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("destinationCACertificate"),
+			"only allowed with reencrypt termination"))
+	}
+
+	return allErrs
+}
+
+// validateConfiguration checks the secret references of the Immich configuration.
+func validateConfiguration(config *mediav1alpha1.ConfigurationSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if config.Notifications != nil && config.Notifications.SMTP != nil && config.Notifications.SMTP.Transport != nil {
+		allErrs = append(allErrs, validateSecretKeySelector(config.Notifications.SMTP.Transport.PasswordSecretRef,
+			fldPath.Child("notifications", "smtp", "transport", "passwordSecretRef"))...)
+	}
+	if config.OAuth != nil {
+		allErrs = append(allErrs, validateSecretKeySelector(config.OAuth.ClientSecretRef,
+			fldPath.Child("oauth", "clientSecretRef"))...)
+	}
+
+	return allErrs
+}
+
+// validateRawConfiguration checks that the raw configuration has a single, complete source.
+func validateRawConfiguration(raw *mediav1alpha1.RawConfigurationSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	sources := 0
+	for _, set := range []bool{raw.Inline != nil, raw.ConfigMapRef != nil, raw.SecretRef != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, sources, "exactly one of inline, configMapRef and secretRef must be set"))
+	}
+
+	if raw.ConfigMapRef != nil {
+		if raw.ConfigMapRef.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("configMapRef", "name"), ""))
+		}
+		if raw.ConfigMapRef.Key == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("configMapRef", "key"), ""))
+		}
+	}
+	allErrs = append(allErrs, validateSecretKeySelector(raw.SecretRef, fldPath.Child("secretRef"))...)
+
+	return allErrs
+}
+
+// validateSecretKeySelector checks that a secret reference, if set, has both a name and a key.
+func validateSecretKeySelector(ref *mediav1alpha1.SecretKeySelector, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if ref == nil {
+		return allErrs
+	}
+	if ref.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	}
+	if ref.Key == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("key"), ""))
+	}
+	return allErrs
+}